@@ -41,6 +41,19 @@ type BareMetalEndpointBond struct {
 	// The bonding mode
 	// +kubebuilder:validation:Required
 	Mode BondMode `json:"mode,omitempty"`
+
+	// The MII link monitoring frequency in milliseconds
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	MiiMon *int `json:"miimon,omitempty"`
+
+	// The transmit hash policy
+	// +kubebuilder:validation:Optional
+	XmitHashPolicy BondXmitHashPolicy `json:"xmitHashPolicy,omitempty"`
+
+	// The rate that LACPDU packets are requested from the link partner
+	// +kubebuilder:validation:Optional
+	LACPRate BondLACPRate `json:"lacpRate,omitempty"`
 }
 
 // BareMetalEndpointSpec defines the desired state of BareMetalEndpoint
@@ -59,6 +72,10 @@ type BareMetalEndpointSpec struct {
 	// The reference to the network object
 	// +kubebuilder:validation:Required
 	NetworkRef kbmeta.ObjectReference `json:"networkRef"`
+
+	// A specific address to request from the network
+	// +kubebuilder:validation:Optional
+	RequestedIP string `json:"requestedIP,omitempty"`
 }
 
 // We will not enum this
@@ -75,21 +92,35 @@ const (
 )
 
 type BareMetalEndpointStatusAddress struct {
-	// +kubebuilder:validation:Required
-	IP string `json:"ip"`
+	// If the address is handed out by a dhcp server
+	// when set the ip, cidr, gateway, nameservers and routes are not set
+	// +kubebuilder:validation:Optional
+	DHCP bool `json:"dhcp,omitempty"`
 
-	// +kubebuilder:validation:Required
-	CIDR string `json:"cidr"`
-	// +kubebuilder:validation:Required
+	// The ip family of the dhcp address
+	// +kubebuilder:validation:Optional
+	IPFamily IPFamily `json:"ipFamily,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	IP string `json:"ip,omitempty"`
 
-	Gateway string `json:"gateway"`
+	// +kubebuilder:validation:Optional
+	CIDR string `json:"cidr,omitempty"`
 
-	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:MinItems=1
-	Nameservers []string `json:"nameservers"`
+	// +kubebuilder:validation:Optional
+	Gateway string `json:"gateway,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	Nameservers []string `json:"nameservers,omitempty"`
 
 	// +kubebuilder:validation:Optional
 	Search []string `json:"search,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	Routes []BareMetalNetworkRoute `json:"routes,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	MTU int `json:"mtu,omitempty"`
 }
 
 // BareMetalEndpointStatus defines the observed state of BareMetalEndpoint
@@ -109,6 +140,7 @@ type BareMetalEndpointStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="STATUS",type=string,JSONPath=`.status.phase`,priority=0
 // +kubebuilder:printcolumn:name="IP",type=string,JSONPath=`.status.address.ip`,priority=0
+// +kubebuilder:printcolumn:name="DHCP",type=boolean,JSONPath=`.status.address.dhcp`,priority=1
 // +kubebuilder:printcolumn:name="NETWORK GROUP",type=string,JSONPath=`.spec.networkRef.group`,priority=1
 // +kubebuilder:printcolumn:name="NETWORK KIND",type=string,JSONPath=`.spec.networkRef.kind`,priority=1
 // +kubebuilder:printcolumn:name="NETWORK NAME",type=string,JSONPath=`.spec.networkRef.name`,priority=1
@@ -135,6 +167,21 @@ type BareMetalEndpointList struct {
 	Items           []BareMetalEndpoint `json:"items"`
 }
 
+const (
+	// Condition Types
+	BareMetalEndpointConditionTypeRequestedAddressAllocated conditionv1.ConditionType = "RequestedAddressAllocated"
+
+	// Condition Reasons
+	BareMetalEndpointRequestedAddressAllocatedConditionReason   string = "RequestedAddressAllocated"
+	BareMetalEndpointRequestedAddressTakenConditionReason       string = "RequestedAddressTaken"
+	BareMetalEndpointRequestedAddressNotInRangeConditionReason  string = "RequestedAddressNotInRange"
+	BareMetalEndpointRequestedAddressExcludedConditionReason    string = "RequestedAddressExcluded"
+	BareMetalEndpointRequestedAddressQuarantinedConditionReason string = "RequestedAddressQuarantined"
+
+	// Event Reasons
+	BareMetalEndpointRequestedAddressUnavailableEventReason string = "RequestedAddressUnavailable"
+)
+
 func init() {
 	SchemeBuilder.Register(&BareMetalEndpoint{}, &BareMetalEndpointList{})
 }
@@ -40,9 +40,38 @@ const (
 	BondModeBalanceALB   BondMode = "balance-alb"
 )
 
+// +kubebuilder:validation:Enum=layer2;layer2+3;layer3+4;encap2+3;encap3+4
+type BondXmitHashPolicy string
+
+const (
+	BondXmitHashPolicyLayer2  BondXmitHashPolicy = "layer2"
+	BondXmitHashPolicyLayer23 BondXmitHashPolicy = "layer2+3"
+	BondXmitHashPolicyLayer34 BondXmitHashPolicy = "layer3+4"
+	BondXmitHashPolicyEncap23 BondXmitHashPolicy = "encap2+3"
+	BondXmitHashPolicyEncap34 BondXmitHashPolicy = "encap3+4"
+)
+
+// +kubebuilder:validation:Enum=slow;fast
+type BondLACPRate string
+
+const (
+	BondLACPRateSlow BondLACPRate = "slow"
+	BondLACPRateFast BondLACPRate = "fast"
+)
+
+const (
+	BondDefaultMiiMon = 100
+)
+
 var (
 	BareMetalHardwareFinalizer = "bmh." + FinalizerPrefix
 
+	// finalizer to remove the dhcp reservations from kea before the hardware is deleted
+	BareMetalHardwareDHCPReservationFinalizer = "dhcp-reservation.bmh." + FinalizerPrefix
+
+	// the macs that have reservations in kea
+	BareMetalHardwareKeaReservationsAnnotation = GroupVersion.Group + "/kea-reservations"
+
 	BareMetalHardwareTaintKeyNotReady   = "hardware." + GroupVersion.Group + "/not-ready"
 	BareMetalHardwareTaintKeyNoSchedule = "hardware." + GroupVersion.Group + "/unschedulable"
 )
@@ -56,6 +85,19 @@ type BareMetalHardwareNICBond struct {
 	// The bonding mode
 	// +kubebuilder:validation:Optional
 	Mode BondMode `json:"mode,omitempty"`
+
+	// The MII link monitoring frequency in milliseconds
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	MiiMon *int `json:"miimon,omitempty"`
+
+	// The transmit hash policy used for slave selection in balance-xor, lacp and balance-tlb modes
+	// +kubebuilder:validation:Optional
+	XmitHashPolicy BondXmitHashPolicy `json:"xmitHashPolicy,omitempty"`
+
+	// The rate that LACPDU packets are requested from the link partner in lacp mode
+	// +kubebuilder:validation:Optional
+	LACPRate BondLACPRate `json:"lacpRate,omitempty"`
 }
 
 type BareMetalHardwareNIC struct {
@@ -74,6 +116,41 @@ type BareMetalHardwareNIC struct {
 	// The reference to the network object
 	// +kubebuilder:validation:Required
 	NetworkRef kbmeta.ObjectReference `json:"networkRef"`
+
+	// References to additional network objects to address the nic on
+	// i.e an IPv6 network alongside an IPv4 network
+	// +kubebuilder:validation:Optional
+	AdditionalNetworkRefs []kbmeta.ObjectReference `json:"additionalNetworkRefs,omitempty"`
+
+	// Specific addresses to request from the networks the nic is attached to
+	// +kubebuilder:validation:Optional
+	RequestedAddresses []BareMetalHardwareNICRequestedAddress `json:"requestedAddresses,omitempty"`
+}
+
+type BareMetalHardwareNICRequestedAddress struct {
+	// The reference to the network object to request the address from
+	// +kubebuilder:validation:Required
+	NetworkRef kbmeta.ObjectReference `json:"networkRef"`
+
+	// The address to request
+	// +kubebuilder:validation:Required
+	IP string `json:"ip"`
+}
+
+// NetworkRefs returns all the network references for the nic with the primary network reference first
+func (nic *BareMetalHardwareNIC) NetworkRefs() []kbmeta.ObjectReference {
+	return append([]kbmeta.ObjectReference{nic.NetworkRef}, nic.AdditionalNetworkRefs...)
+}
+
+// RequestedIP returns the address requested from the network or an empty string if there isn't one
+func (nic *BareMetalHardwareNIC) RequestedIP(networkRef kbmeta.ObjectReference) string {
+	for _, requestedAddress := range nic.RequestedAddresses {
+		if requestedAddress.NetworkRef == networkRef {
+			return requestedAddress.IP
+		}
+	}
+
+	return ""
 }
 
 // BareMetalHardwareSpec defines the desired state of BareMetalHardware
@@ -147,6 +224,16 @@ type BareMetalHardware struct {
 	Status BareMetalHardwareStatus `json:"status,omitempty"`
 }
 
+// GetHardwareSpec implements Hardware
+func (bmh *BareMetalHardware) GetHardwareSpec() *BareMetalHardwareSpec {
+	return &bmh.Spec
+}
+
+// GetHardwareStatus implements Hardware
+func (bmh *BareMetalHardware) GetHardwareStatus() *BareMetalHardwareStatus {
+	return &bmh.Status
+}
+
 // +kubebuilder:object:root=true
 
 // BareMetalHardwareList contains a list of BareMetalHardware
@@ -188,6 +275,9 @@ const (
 
 	BareMetalHardwareCleaningEventReason string = "HardwareCleaning"
 	BareMetalHardwareCleanedEventReason  string = "HardwareCleaned"
+
+	BareMetalHardwareDHCPReservationFailedEventReason  string = "DHCPReservationFailed"
+	BareMetalHardwareDHCPReservationUpdatedEventReason string = "DHCPReservationUpdated"
 )
 
 func init() {
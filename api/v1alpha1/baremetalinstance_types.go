@@ -16,19 +16,248 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 
 	conditionv1 "github.com/rmb938/kube-baremetal/apis/condition/v1"
 )
 
 var (
 	BareMetalInstanceFinalizer = "bmi." + FinalizerPrefix
+
+	// when "true" the reasons each hardware was filtered out are added to the scheduling status
+	BareMetalInstanceSchedulingDetailsAnnotation = GroupVersion.Group + "/scheduling-details"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// +kubebuilder:validation:Enum=HDD;SSD
+type ImageDriveType string
+
+const (
+	// The image drive is rotational
+	ImageDriveTypeHDD ImageDriveType = "HDD"
+
+	// The image drive is not rotational
+	ImageDriveTypeSSD ImageDriveType = "SSD"
+)
+
+type BareMetalInstanceResources struct {
+	// The minimum number of cpus
+	// +kubebuilder:validation:Optional
+	CPUS *resource.Quantity `json:"cpus,omitempty"`
+
+	// The minimum amount of memory
+	// +kubebuilder:validation:Optional
+	Ram *resource.Quantity `json:"ram,omitempty"`
+
+	// The minimum size of the image drive
+	// +kubebuilder:validation:Optional
+	ImageDriveSize *resource.Quantity `json:"imageDriveSize,omitempty"`
+
+	// The type of the image drive, when not set any type is allowed
+	// +kubebuilder:validation:Optional
+	ImageDriveType ImageDriveType `json:"imageDriveType,omitempty"`
+
+	// The minimum number of nics, when nicSpeed is set only nics that are at least that fast are counted
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	NICCount int `json:"nicCount,omitempty"`
+
+	// The minimum speed of the nics, when nicCount is not set at least one nic must be this fast
+	// +kubebuilder:validation:Optional
+	NICSpeed *resource.Quantity `json:"nicSpeed,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=In;NotIn;Exists;DoesNotExist;Gt;Lt
+type HardwareSelectorOperator string
+
+const (
+	HardwareSelectorOpIn           HardwareSelectorOperator = "In"
+	HardwareSelectorOpNotIn        HardwareSelectorOperator = "NotIn"
+	HardwareSelectorOpExists       HardwareSelectorOperator = "Exists"
+	HardwareSelectorOpDoesNotExist HardwareSelectorOperator = "DoesNotExist"
+	HardwareSelectorOpGt           HardwareSelectorOperator = "Gt"
+	HardwareSelectorOpLt           HardwareSelectorOperator = "Lt"
+)
+
+// the label selector operators for the hardware selector operators
+var hardwareSelectorOperators = map[HardwareSelectorOperator]selection.Operator{
+	HardwareSelectorOpIn:           selection.In,
+	HardwareSelectorOpNotIn:        selection.NotIn,
+	HardwareSelectorOpExists:       selection.Exists,
+	HardwareSelectorOpDoesNotExist: selection.DoesNotExist,
+	HardwareSelectorOpGt:           selection.GreaterThan,
+	HardwareSelectorOpLt:           selection.LessThan,
+}
+
+type HardwareSelectorRequirement struct {
+	// The label key
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+
+	// How the label value is compared to the values
+	// +kubebuilder:validation:Required
+	Operator HardwareSelectorOperator `json:"operator"`
+
+	// The values to compare to, must be empty for Exists and DoesNotExist
+	// and a single integer for Gt and Lt
+	// +kubebuilder:validation:Optional
+	Values []string `json:"values,omitempty"`
+}
+
+type HardwareSelectorTerm struct {
+	// The requirements of the term are ANDed, a term without requirements matches no hardware
+	// +kubebuilder:validation:Optional
+	MatchExpressions []HardwareSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+// Selector returns the label selector for the term
+func (t *HardwareSelectorTerm) Selector() (labels.Selector, error) {
+	if len(t.MatchExpressions) == 0 {
+		return labels.Nothing(), nil
+	}
+
+	selector := labels.NewSelector()
+	for _, expression := range t.MatchExpressions {
+		operator, ok := hardwareSelectorOperators[expression.Operator]
+		if ok == false {
+			return nil, fmt.Errorf("%q is not a valid hardware selector operator", expression.Operator)
+		}
+
+		requirement, err := labels.NewRequirement(expression.Key, operator, expression.Values)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*requirement)
+	}
+
+	return selector, nil
+}
+
+type HardwareSelector struct {
+	// The terms are ORed
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	HardwareSelectorTerms []HardwareSelectorTerm `json:"hardwareSelectorTerms"`
+}
+
+type PreferredSchedulingTerm struct {
+	// How much the term counts towards the score of hardware
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// +kubebuilder:validation:Required
+	Preference HardwareSelectorTerm `json:"preference"`
+}
+
+type HardwareAffinity struct {
+	// The instance must be scheduled onto hardware that matches the selector
+	// +kubebuilder:validation:Optional
+	RequiredDuringSchedulingIgnoredDuringExecution *HardwareSelector `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+
+	// The instance prefers hardware that matches the terms, the weights of all matching terms are added up
+	// +kubebuilder:validation:Optional
+	PreferredDuringSchedulingIgnoredDuringExecution []PreferredSchedulingTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+type InstanceAffinityTerm struct {
+	// The instances the term applies to
+	// +kubebuilder:validation:Optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// The hardware label that groups hardware into a domain, i.e. a rack or a power feed
+	// hardware without the label never matches the term
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	TopologyKey string `json:"topologyKey"`
+}
+
+type WeightedInstanceAffinityTerm struct {
+	// How much the term counts towards the score of hardware
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// +kubebuilder:validation:Required
+	InstanceAffinityTerm InstanceAffinityTerm `json:"instanceAffinityTerm"`
+}
+
+type InstanceAffinity struct {
+	// The instance must be scheduled onto a domain that runs instances matching all of the terms
+	// +kubebuilder:validation:Optional
+	RequiredDuringSchedulingIgnoredDuringExecution []InstanceAffinityTerm `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+
+	// The instance prefers domains that run instances matching the terms
+	// +kubebuilder:validation:Optional
+	PreferredDuringSchedulingIgnoredDuringExecution []WeightedInstanceAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+type InstanceAntiAffinity struct {
+	// The instance must not be scheduled onto a domain that runs instances matching any of the terms
+	// +kubebuilder:validation:Optional
+	RequiredDuringSchedulingIgnoredDuringExecution []InstanceAffinityTerm `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+
+	// The instance prefers domains that don't run instances matching the terms
+	// +kubebuilder:validation:Optional
+	PreferredDuringSchedulingIgnoredDuringExecution []WeightedInstanceAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+type Affinity struct {
+	// Schedule the instance onto hardware with matching labels
+	// +kubebuilder:validation:Optional
+	HardwareAffinity *HardwareAffinity `json:"hardwareAffinity,omitempty"`
+
+	// Schedule the instance into the same domains as other instances
+	// +kubebuilder:validation:Optional
+	InstanceAffinity *InstanceAffinity `json:"instanceAffinity,omitempty"`
+
+	// Schedule the instance into different domains then other instances
+	// +kubebuilder:validation:Optional
+	InstanceAntiAffinity *InstanceAntiAffinity `json:"instanceAntiAffinity,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+type UnsatisfiableConstraintAction string
+
+const (
+	// Don't schedule the instance when the constraint can't be satisfied
+	DoNotSchedule UnsatisfiableConstraintAction = "DoNotSchedule"
+
+	// Schedule the instance anyway but prefer the domains that reduce the skew
+	ScheduleAnyway UnsatisfiableConstraintAction = "ScheduleAnyway"
+)
+
+type TopologySpreadConstraint struct {
+	// The maximum difference in the number of matching instances between any two domains
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	MaxSkew int32 `json:"maxSkew"`
+
+	// The hardware label that groups hardware into a domain
+	// hardware without the label is not part of any domain and is never picked
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	TopologyKey string `json:"topologyKey"`
+
+	// What to do when the constraint can't be satisfied
+	// +kubebuilder:validation:Required
+	WhenUnsatisfiable UnsatisfiableConstraintAction `json:"whenUnsatisfiable"`
+
+	// The instances that are counted in each domain
+	// +kubebuilder:validation:Optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
 // BareMetalInstanceSpec defines the desired state of BareMetalInstance
 type BareMetalInstanceSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -39,6 +268,50 @@ type BareMetalInstanceSpec struct {
 
 	// +kubebuilder:validation:Optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// The minimum resources the hardware must have
+	// +kubebuilder:validation:Optional
+	Resources *BareMetalInstanceResources `json:"resources,omitempty"`
+
+	// Scheduling constraints relative to other instances
+	// +kubebuilder:validation:Optional
+	Affinity *Affinity `json:"affinity,omitempty"`
+
+	// How instances are spread across domains
+	// +kubebuilder:validation:Optional
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// Schedule the instance together with other instances, either all of them get hardware or none do
+	// +kubebuilder:validation:Optional
+	Gang *InstanceGang `json:"gang,omitempty"`
+
+	// Instances with a higher priority are scheduled before instances with a lower priority, defaults to 0
+	// instances with the same priority are scheduled oldest first
+	// +kubebuilder:validation:Optional
+	Priority *int32 `json:"priority,omitempty"`
+}
+
+const (
+	// DefaultGangScheduleTimeoutSeconds is how long hardware is held for part of a gang when the gang doesn't set a timeout
+	DefaultGangScheduleTimeoutSeconds int64 = 300
+)
+
+// InstanceGang is a group of instances that are scheduled all at once
+type InstanceGang struct {
+	// The name of the gang, all instances in the namespace with the same gang name are part of the gang
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// How many instances of the gang need hardware before any of them are scheduled
+	// all instances of the gang should use the same value
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Required
+	MinMembers int `json:"minMembers"`
+
+	// How long hardware is held for part of the gang before it is released, defaults to 300
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	ScheduleTimeoutSeconds *int64 `json:"scheduleTimeoutSeconds,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=Pending;Provisioning;Imaging;Running;Cleaning;Terminating;Terminated
@@ -60,6 +333,56 @@ type BareMetalInstanceStatusAgentInfo struct {
 	// TODO: do we want to put anything else here?
 }
 
+type BareMetalInstanceSchedulingFilterResult struct {
+	// The filter plugin that rejected the hardware
+	// +kubebuilder:validation:Required
+	Plugin string `json:"plugin"`
+
+	// Why the hardware was rejected
+	// +kubebuilder:validation:Required
+	Reason string `json:"reason"`
+
+	// The number of hardware rejected for the reason
+	// +kubebuilder:validation:Required
+	Count int `json:"count"`
+}
+
+type BareMetalInstanceSchedulingHardwareResult struct {
+	// The name of the hardware
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Why the hardware was rejected
+	// +kubebuilder:validation:Required
+	Reasons []string `json:"reasons"`
+}
+
+type BareMetalInstanceScheduling struct {
+	// When the instance was last tried to be scheduled
+	// +kubebuilder:validation:Optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+
+	// The number of hardware in the namespace of the instance
+	// +kubebuilder:validation:Optional
+	TotalHardware int `json:"totalHardware"`
+
+	// The number of hardware that is not deleting or assigned to another instance
+	// +kubebuilder:validation:Optional
+	AvailableHardware int `json:"availableHardware"`
+
+	// The number of available hardware that passed all the filters
+	// +kubebuilder:validation:Optional
+	FeasibleHardware int `json:"feasibleHardware"`
+
+	// How much hardware each filter rejected and why
+	// +kubebuilder:validation:Optional
+	Filters []BareMetalInstanceSchedulingFilterResult `json:"filters,omitempty"`
+
+	// Why each hardware was rejected, only set when the scheduling-details annotation is "true"
+	// +kubebuilder:validation:Optional
+	Hardware []BareMetalInstanceSchedulingHardwareResult `json:"hardware,omitempty"`
+}
+
 // BareMetalInstanceStatus defines the observed state of BareMetalInstance
 type BareMetalInstanceStatus struct {
 	conditionv1.StatusConditions `json:",inline"`
@@ -72,8 +395,16 @@ type BareMetalInstanceStatus struct {
 	// +kubebuilder:validation:Optional
 	HardwareName string `json:"hardwareName,omitempty"`
 
+	// Set when the hardware name is the name of a ClusterBareMetalHardware instead of a BareMetalHardware
+	// +kubebuilder:validation:Optional
+	ClusterHardware bool `json:"clusterHardware,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	Phase BareMetalInstanceStatusPhase `json:"phase,omitempty"`
+
+	// The result of the last scheduling attempt
+	// +kubebuilder:validation:Optional
+	Scheduling *BareMetalInstanceScheduling `json:"scheduling,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -81,6 +412,7 @@ type BareMetalInstanceStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="STATUS",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="HARDWARE",type=string,JSONPath=`.status.hardwareName`
+// +kubebuilder:printcolumn:name="PRIORITY",type=integer,JSONPath=`.spec.priority`,priority=1
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // BareMetalInstance is the Schema for the baremetalinstances API
@@ -110,14 +442,19 @@ const (
 	BareMetalHardwareConditionTypeInstanceNetworked conditionv1.ConditionType = "InstanceNetworkConfigured"
 	BareMetalHardwareConditionTypeInstanceImaged    conditionv1.ConditionType = "InstanceImaged"
 	BareMetalHardwareConditionTypeInstanceCleaned   conditionv1.ConditionType = "InstanceCleaned"
+	BareMetalInstanceConditionTypeSchedulable       conditionv1.ConditionType = "Schedulable"
 
 	// Condition Reasons
 	BareMetalInstanceImagingFailedConditionReason  string = "ImagingFailed"
 	BareMetalInstanceCleaningFailedConditionReason string = "CleaningFailed"
+	BareMetalInstanceUnschedulableConditionReason  string = "Unschedulable"
+	BareMetalInstanceScheduledConditionReason      string = "Scheduled"
+	BareMetalInstanceWaitingForGangConditionReason string = "WaitingForGang"
 
 	// Event Reasons
-	BareMetalInstanceScheduleEventReason   string = "InstanceScheduled"
-	BareMetalInstanceUnscheduleEventReason string = "InstanceUnscheduled"
+	BareMetalInstanceScheduleEventReason    string = "InstanceScheduled"
+	BareMetalInstanceUnscheduleEventReason  string = "InstanceUnscheduled"
+	BareMetalInstanceGangTimeoutEventReason string = "GangTimeout"
 
 	BareMetalInstanceProvisioningEventReason string = "InstanceProvisioning"
 
@@ -132,6 +469,11 @@ const (
 	BareMetalInstanceNotCleanedEventReason string = "InstanceNotCleaned"
 	BareMetalInstanceCleaningEventReason   string = "InstanceCleaning"
 	BareMetalInstanceCleanedEventReason    string = "InstanceCleaned"
+
+	BareMetalInstanceDNSRecordsUpdatedEventReason string = "DNSRecordsUpdated"
+	BareMetalInstanceDNSRecordsRemovedEventReason string = "DNSRecordsRemoved"
+
+	BareMetalInstanceEvictedEventReason string = "InstanceEvicted"
 )
 
 func init() {
@@ -17,6 +17,8 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	conditionv1 "github.com/rmb938/kube-baremetal/apis/condition/v1"
 )
 
 var (
@@ -26,19 +28,76 @@ var (
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
-// BareMetalNetworkSpec defines the desired state of BareMetalNetwork
-type BareMetalNetworkSpec struct {
+type BareMetalNetworkRoute struct {
+	// The destination cidr of the route
 	// +kubebuilder:validation:Required
-	CIDR string `json:"cidr"`
+	Destination string `json:"destination"`
 
+	// The gateway to route the destination through
 	// +kubebuilder:validation:Required
 	Gateway string `json:"gateway"`
 
+	// The metric of the route
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Metric int `json:"metric,omitempty"`
+}
+
+type BareMetalNetworkRange struct {
+	// The first address of the range
 	// +kubebuilder:validation:Required
 	Start string `json:"start"`
 
+	// The last address of the range
 	// +kubebuilder:validation:Required
 	End string `json:"end"`
+}
+
+type BareMetalNetworkDNS struct {
+	// The domain that instance hostnames are published under, the record name is {instance}.{domain}
+	// +kubebuilder:validation:Required
+	Domain string `json:"domain"`
+
+	// The ttl of the published records, when not set the dns provider default is used
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+type BareMetalNetworkExclusion struct {
+	// The first address to exclude
+	// +kubebuilder:validation:Required
+	Start string `json:"start"`
+
+	// The last address to exclude, when not set only the start address is excluded
+	// +kubebuilder:validation:Optional
+	End string `json:"end,omitempty"`
+}
+
+// BareMetalNetworkSpec defines the desired state of BareMetalNetwork
+type BareMetalNetworkSpec struct {
+	// +kubebuilder:validation:Required
+	CIDR string `json:"cidr"`
+
+	// +kubebuilder:validation:Required
+	Gateway string `json:"gateway"`
+
+	// Deprecated: use ranges, the first address to allocate from
+	// +kubebuilder:validation:Optional
+	Start string `json:"start,omitempty"`
+
+	// Deprecated: use ranges, the last address to allocate from
+	// +kubebuilder:validation:Optional
+	End string `json:"end,omitempty"`
+
+	// The ranges of addresses to allocate from
+	// +kubebuilder:validation:Optional
+	Ranges []BareMetalNetworkRange `json:"ranges,omitempty"`
+
+	// Addresses inside of the ranges that are never allocated
+	// the network, gateway, broadcast and nameserver addresses are always excluded
+	// +kubebuilder:validation:Optional
+	Exclusions []BareMetalNetworkExclusion `json:"exclusions,omitempty"`
 
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinItems=1
@@ -46,16 +105,110 @@ type BareMetalNetworkSpec struct {
 
 	// +kubebuilder:validation:Optional
 	Search []string `json:"search,omitempty"`
+
+	// Static routes to configure in addition to the default gateway
+	// +kubebuilder:validation:Optional
+	Routes []BareMetalNetworkRoute `json:"routes,omitempty"`
+
+	// The MTU of the network, when not set the operating system default is used
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=68
+	// +kubebuilder:validation:Maximum=9216
+	MTU int `json:"mtu,omitempty"`
+
+	// How long a released address is quarantined before it can be allocated again
+	// this gives arp caches and dns records pointing at the old host time to expire
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	ReleaseHoldDownSeconds int64 `json:"releaseHoldDownSeconds,omitempty"`
+
+	// Publish forward and reverse dns records for the addresses allocated to instances
+	// +kubebuilder:validation:Optional
+	DNS *BareMetalNetworkDNS `json:"dns,omitempty"`
+}
+
+// AllRanges returns the ranges of the network including the deprecated start and end range when it is set
+func (spec *BareMetalNetworkSpec) AllRanges() []BareMetalNetworkRange {
+	if len(spec.Start) == 0 || len(spec.End) == 0 {
+		return spec.Ranges
+	}
+
+	legacy := BareMetalNetworkRange{Start: spec.Start, End: spec.End}
+	for _, specRange := range spec.Ranges {
+		if specRange == legacy {
+			return spec.Ranges
+		}
+	}
+
+	return append([]BareMetalNetworkRange{legacy}, spec.Ranges...)
+}
+
+type BareMetalNetworkStatusPool struct {
+	// The first address of the pool
+	// +kubebuilder:validation:Required
+	Start string `json:"start"`
+
+	// The last address of the pool
+	// +kubebuilder:validation:Required
+	End string `json:"end"`
+
+	// The allocation bitmap of the pool, bit n is set when the address start+n is allocated
+	// +kubebuilder:validation:Optional
+	Bitmap []byte `json:"bitmap,omitempty"`
+
+	// The offset in the pool to start searching for the next free address from
+	// +kubebuilder:validation:Optional
+	Next int64 `json:"next,omitempty"`
+}
+
+type BareMetalNetworkStatusQuarantine struct {
+	// The address that was released
+	// +kubebuilder:validation:Required
+	IP string `json:"ip"`
+
+	// When the address was released, it can be allocated again once the hold-down has passed
+	// +kubebuilder:validation:Required
+	ReleaseTime metav1.Time `json:"releaseTime"`
 }
 
 // BareMetalNetworkStatus defines the observed state of BareMetalNetwork
 type BareMetalNetworkStatus struct {
-	// TODO: do we need any status?
-	//  a network object is immutable and all validation is in the webhook
+	conditionv1.StatusConditions `json:",inline"`
+
+	// The allocation state of the address pools
+	// +kubebuilder:validation:Optional
+	Pools []BareMetalNetworkStatusPool `json:"pools,omitempty"`
+
+	// Addresses that have been released and are waiting for the hold-down to pass
+	// +kubebuilder:validation:Optional
+	Quarantine []BareMetalNetworkStatusQuarantine `json:"quarantine,omitempty"`
+
+	// The number of addresses that can be allocated
+	// +kubebuilder:validation:Optional
+	Total int64 `json:"total"`
+
+	// The number of addresses that are allocated
+	// +kubebuilder:validation:Optional
+	Allocated int64 `json:"allocated"`
+
+	// The number of addresses that are quarantined
+	// +kubebuilder:validation:Optional
+	Quarantined int64 `json:"quarantined"`
+
+	// The number of addresses that are free
+	// +kubebuilder:validation:Optional
+	Free int64 `json:"free"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:shortName=bmn
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CIDR",type=string,JSONPath=`.spec.cidr`
+// +kubebuilder:printcolumn:name="Total",type=integer,JSONPath=`.status.total`
+// +kubebuilder:printcolumn:name="Allocated",type=integer,JSONPath=`.status.allocated`
+// +kubebuilder:printcolumn:name="Quarantined",type=integer,JSONPath=`.status.quarantined`,priority=1
+// +kubebuilder:printcolumn:name="Free",type=integer,JSONPath=`.status.free`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // BareMetalNetwork is the Schema for the baremetalnetworks API
 type BareMetalNetwork struct {
@@ -78,6 +231,24 @@ type BareMetalNetworkList struct {
 	Items           []BareMetalNetwork `json:"items"`
 }
 
+const (
+	// Condition Types
+	BareMetalNetworkConditionTypePoolExhausted conditionv1.ConditionType = "PoolExhausted"
+	BareMetalNetworkConditionTypeInUse         conditionv1.ConditionType = "InUse"
+
+	// Condition Reasons
+	BareMetalNetworkPoolExhaustedConditionReason string = "PoolExhausted"
+	BareMetalNetworkPoolAvailableConditionReason string = "PoolAvailable"
+
+	BareMetalNetworkEndpointsAllocatedConditionReason string = "EndpointsAllocated"
+
+	// Event Reasons
+	BareMetalNetworkPoolExhaustedEventReason string = "PoolExhausted"
+	BareMetalNetworkPoolRepairedEventReason  string = "PoolRepaired"
+	BareMetalNetworkInUseEventReason         string = "NetworkInUse"
+	BareMetalNetworkUnquarantinedEventReason string = "Unquarantined"
+)
+
 func init() {
 	SchemeBuilder.Register(&BareMetalNetwork{}, &BareMetalNetworkList{})
 }
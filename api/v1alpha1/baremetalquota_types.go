@@ -0,0 +1,89 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+type BareMetalQuotaResources struct {
+	// The number of instances that aren't being deleted
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Instances *int64 `json:"instances,omitempty"`
+
+	// The cpus of the hardware assigned to instances, as discovered in the hardware status
+	// +kubebuilder:validation:Optional
+	CPUS *resource.Quantity `json:"cpus,omitempty"`
+
+	// The ram of the hardware assigned to instances, as discovered in the hardware status
+	// +kubebuilder:validation:Optional
+	Ram *resource.Quantity `json:"ram,omitempty"`
+}
+
+// BareMetalQuotaSpec defines the desired state of BareMetalQuota
+type BareMetalQuotaSpec struct {
+	// The most the namespace may use, resources that are not set are not limited
+	// +kubebuilder:validation:Required
+	Hard BareMetalQuotaResources `json:"hard"`
+}
+
+// BareMetalQuotaStatus defines the observed state of BareMetalQuota
+type BareMetalQuotaStatus struct {
+	// What the namespace currently uses
+	// +kubebuilder:validation:Optional
+	Used BareMetalQuotaResources `json:"used,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=baremetalquotas,shortName=bmq
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Instances",type=integer,JSONPath=`.status.used.instances`
+// +kubebuilder:printcolumn:name="Max Instances",type=integer,JSONPath=`.spec.hard.instances`
+// +kubebuilder:printcolumn:name="CPUS",type=string,JSONPath=`.status.used.cpus`
+// +kubebuilder:printcolumn:name="Max CPUS",type=string,JSONPath=`.spec.hard.cpus`
+// +kubebuilder:printcolumn:name="Ram",type=string,JSONPath=`.status.used.ram`
+// +kubebuilder:printcolumn:name="Max Ram",type=string,JSONPath=`.spec.hard.ram`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BareMetalQuota is the Schema for the baremetalquotas API
+type BareMetalQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:Required
+	Spec BareMetalQuotaSpec `json:"spec"`
+
+	// +kubebuilder:validation:Optional
+	Status BareMetalQuotaStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BareMetalQuotaList contains a list of BareMetalQuota
+type BareMetalQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BareMetalQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BareMetalQuota{}, &BareMetalQuotaList{})
+}
@@ -0,0 +1,118 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// Hardware is implemented by BareMetalHardware and ClusterBareMetalHardware so they can share their lifecycle
+// +kubebuilder:object:generate=false
+type Hardware interface {
+	runtime.Object
+	metav1.Object
+
+	GetHardwareSpec() *BareMetalHardwareSpec
+	GetHardwareStatus() *BareMetalHardwareStatus
+}
+
+var _ Hardware = &BareMetalHardware{}
+var _ Hardware = &ClusterBareMetalHardware{}
+
+// ClusterBareMetalHardwareSpec defines the desired state of ClusterBareMetalHardware
+type ClusterBareMetalHardwareSpec struct {
+	BareMetalHardwareSpec `json:",inline"`
+
+	// The namespaces whose instances can be scheduled onto the hardware
+	// instances in any namespace can be scheduled onto it when empty
+	// +kubebuilder:validation:Optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=cbmh
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CPU Model",type=string,JSONPath=`.status.hardware.cpu.modelName`
+// +kubebuilder:printcolumn:name="CPU Count",type=string,JSONPath=`.status.hardware.cpu.cpus`
+// +kubebuilder:printcolumn:name="Ram",type=string,JSONPath=`.status.hardware.ram`
+// +kubebuilder:printcolumn:name="Instance Namespace",type=string,JSONPath=`.status.instanceRef.namespace`,priority=1
+// +kubebuilder:printcolumn:name="Instance",type=string,JSONPath=`.status.instanceRef.name`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterBareMetalHardware is the Schema for the clusterbaremetalhardwares API
+// it is hardware that instances in any or selected namespaces can be scheduled onto
+type ClusterBareMetalHardware struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:Required
+	Spec ClusterBareMetalHardwareSpec `json:"spec"`
+
+	// +kubebuilder:validation:Optional
+	Status BareMetalHardwareStatus `json:"status,omitempty"`
+}
+
+// GetHardwareSpec implements Hardware
+func (cbmh *ClusterBareMetalHardware) GetHardwareSpec() *BareMetalHardwareSpec {
+	return &cbmh.Spec.BareMetalHardwareSpec
+}
+
+// GetHardwareStatus implements Hardware
+func (cbmh *ClusterBareMetalHardware) GetHardwareStatus() *BareMetalHardwareStatus {
+	return &cbmh.Status
+}
+
+// AllowsNamespace returns if instances in the namespace can be scheduled onto the hardware
+func (cbmh *ClusterBareMetalHardware) AllowsNamespace(namespace string) bool {
+	if len(cbmh.Spec.Namespaces) == 0 {
+		return true
+	}
+
+	for _, allowed := range cbmh.Spec.Namespaces {
+		if allowed == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AsBareMetalHardware returns a copy of the hardware as a BareMetalHardware without a namespace
+// so it can be used by code that only knows about the namespaced kind
+func (cbmh *ClusterBareMetalHardware) AsBareMetalHardware() *BareMetalHardware {
+	return &BareMetalHardware{
+		ObjectMeta: *cbmh.ObjectMeta.DeepCopy(),
+		Spec:       *cbmh.Spec.BareMetalHardwareSpec.DeepCopy(),
+		Status:     *cbmh.Status.DeepCopy(),
+	}
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterBareMetalHardwareList contains a list of ClusterBareMetalHardware
+type ClusterBareMetalHardwareList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterBareMetalHardware `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterBareMetalHardware{}, &ClusterBareMetalHardwareList{})
+}
@@ -0,0 +1,76 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var clusterbaremetalhardwarelog = logf.Log.WithName("clusterbaremetalhardware-resource")
+
+// THIS IS JUST A DUMMY FILE REAL WEBHOOK IMPLEMENTATION IS IN "github.com/rmb938/kube-baremetal/webhooks"
+
+func (r *ClusterBareMetalHardware) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+
+// +kubebuilder:webhook:path=/mutate-baremetal-com-rmb938-v1alpha1-clusterbaremetalhardware,mutating=true,failurePolicy=fail,groups=baremetal.com.rmb938,resources=clusterbaremetalhardwares,verbs=create;update,versions=v1alpha1,name=mclusterbaremetalhardware.kb.io
+
+var _ webhook.Defaulter = &ClusterBareMetalHardware{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *ClusterBareMetalHardware) Default() {
+	clusterbaremetalhardwarelog.Info("default", "name", r.Name)
+
+	// TODO(user): fill in your defaulting logic.
+}
+
+// TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
+// +kubebuilder:webhook:verbs=create;update,path=/validate-baremetal-com-rmb938-v1alpha1-clusterbaremetalhardware,mutating=false,failurePolicy=fail,groups=baremetal.com.rmb938,resources=clusterbaremetalhardwares,versions=v1alpha1,name=vclusterbaremetalhardware.kb.io
+
+var _ webhook.Validator = &ClusterBareMetalHardware{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterBareMetalHardware) ValidateCreate() error {
+	clusterbaremetalhardwarelog.Info("validate create", "name", r.Name)
+
+	// TODO(user): fill in your validation logic upon object creation.
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterBareMetalHardware) ValidateUpdate(old runtime.Object) error {
+	clusterbaremetalhardwarelog.Info("validate update", "name", r.Name)
+
+	// TODO(user): fill in your validation logic upon object update.
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ClusterBareMetalHardware) ValidateDelete() error {
+	clusterbaremetalhardwarelog.Info("validate delete", "name", r.Name)
+
+	// TODO(user): fill in your validation logic upon object deletion.
+	return nil
+}
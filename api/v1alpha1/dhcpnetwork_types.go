@@ -0,0 +1,80 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// +kubebuilder:validation:Enum=IPv4;IPv6
+type IPFamily string
+
+const (
+	IPFamilyIPv4 IPFamily = "IPv4"
+	IPFamilyIPv6 IPFamily = "IPv6"
+)
+
+// DHCPNetworkSpec defines the desired state of DHCPNetwork
+type DHCPNetworkSpec struct {
+	// The ip family the dhcp server hands out addresses for
+	// +kubebuilder:validation:Optional
+	IPFamily IPFamily `json:"ipFamily,omitempty"`
+
+	// The MTU of the network, when not set the operating system default is used
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=68
+	// +kubebuilder:validation:Maximum=9216
+	MTU int `json:"mtu,omitempty"`
+}
+
+// DHCPNetworkStatus defines the observed state of DHCPNetwork
+type DHCPNetworkStatus struct {
+	// TODO: do we need any status?
+	//  addresses are handed out by an external dhcp server
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=dhcpn
+// +kubebuilder:printcolumn:name="IP FAMILY",type=string,JSONPath=`.spec.ipFamily`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DHCPNetwork is the Schema for the dhcpnetworks API
+type DHCPNetwork struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:Required
+	Spec DHCPNetworkSpec `json:"spec"`
+
+	// +kubebuilder:validation:Optional
+	Status DHCPNetworkStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DHCPNetworkList contains a list of DHCPNetwork
+type DHCPNetworkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DHCPNetwork `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DHCPNetwork{}, &DHCPNetworkList{})
+}
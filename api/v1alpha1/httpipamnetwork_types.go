@@ -0,0 +1,88 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// HTTPIPAMNetworkSpec defines the desired state of HTTPIPAMNetwork
+type HTTPIPAMNetworkSpec struct {
+	// The base url of the external ipam
+	// allocate and release requests are sent to {url}/allocate and {url}/release
+	// +kubebuilder:validation:Required
+	URL string `json:"url"`
+
+	// A secret in the same namespace with a bearer token in the "token" key
+	// +kubebuilder:validation:Optional
+	TokenSecretRef *corev1.LocalObjectReference `json:"tokenSecretRef,omitempty"`
+
+	// Extra parameters to send to the external ipam
+	// i.e. the prefix to allocate addresses from
+	// +kubebuilder:validation:Optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// How long to wait for the external ipam to respond in seconds
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// HTTPIPAMNetworkStatus defines the observed state of HTTPIPAMNetwork
+type HTTPIPAMNetworkStatus struct {
+	// TODO: do we need any status?
+	//  all allocation state is stored in the external ipam
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=hipamn
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// HTTPIPAMNetwork is the Schema for the httpipamnetworks API
+type HTTPIPAMNetwork struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:Required
+	Spec HTTPIPAMNetworkSpec `json:"spec"`
+
+	// +kubebuilder:validation:Optional
+	Status HTTPIPAMNetworkStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HTTPIPAMNetworkList contains a list of HTTPIPAMNetwork
+type HTTPIPAMNetworkList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HTTPIPAMNetwork `json:"items"`
+}
+
+const (
+	// Event Reasons
+	HTTPIPAMNetworkAllocateFailedEventReason string = "AllocateFailed"
+	HTTPIPAMNetworkReleaseFailedEventReason  string = "ReleaseFailed"
+)
+
+func init() {
+	SchemeBuilder.Register(&HTTPIPAMNetwork{}, &HTTPIPAMNetworkList{})
+}
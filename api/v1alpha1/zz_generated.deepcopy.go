@@ -20,10 +20,42 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
+	"github.com/rmb938/kube-baremetal/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Affinity) DeepCopyInto(out *Affinity) {
+	*out = *in
+	if in.HardwareAffinity != nil {
+		in, out := &in.HardwareAffinity, &out.HardwareAffinity
+		*out = new(HardwareAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InstanceAffinity != nil {
+		in, out := &in.InstanceAffinity, &out.InstanceAffinity
+		*out = new(InstanceAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InstanceAntiAffinity != nil {
+		in, out := &in.InstanceAntiAffinity, &out.InstanceAntiAffinity
+		*out = new(InstanceAntiAffinity)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Affinity.
+func (in *Affinity) DeepCopy() *Affinity {
+	if in == nil {
+		return nil
+	}
+	out := new(Affinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BareMetalDiscovery) DeepCopyInto(out *BareMetalDiscovery) {
 	*out = *in
@@ -232,6 +264,11 @@ func (in *BareMetalEndpointBond) DeepCopyInto(out *BareMetalEndpointBond) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.MiiMon != nil {
+		in, out := &in.MiiMon, &out.MiiMon
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalEndpointBond.
@@ -331,6 +368,11 @@ func (in *BareMetalEndpointStatusAddress) DeepCopyInto(out *BareMetalEndpointSta
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]BareMetalNetworkRoute, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalEndpointStatusAddress.
@@ -411,6 +453,18 @@ func (in *BareMetalHardwareNIC) DeepCopyInto(out *BareMetalHardwareNIC) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.NetworkRef.DeepCopyInto(&out.NetworkRef)
+	if in.AdditionalNetworkRefs != nil {
+		in, out := &in.AdditionalNetworkRefs, &out.AdditionalNetworkRefs
+		*out = make([]v1.ObjectReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RequestedAddresses != nil {
+		in, out := &in.RequestedAddresses, &out.RequestedAddresses
+		*out = make([]BareMetalHardwareNICRequestedAddress, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHardwareNIC.
@@ -431,6 +485,11 @@ func (in *BareMetalHardwareNICBond) DeepCopyInto(out *BareMetalHardwareNICBond)
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.MiiMon != nil {
+		in, out := &in.MiiMon, &out.MiiMon
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHardwareNICBond.
@@ -443,6 +502,22 @@ func (in *BareMetalHardwareNICBond) DeepCopy() *BareMetalHardwareNICBond {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalHardwareNICRequestedAddress) DeepCopyInto(out *BareMetalHardwareNICRequestedAddress) {
+	*out = *in
+	in.NetworkRef.DeepCopyInto(&out.NetworkRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHardwareNICRequestedAddress.
+func (in *BareMetalHardwareNICRequestedAddress) DeepCopy() *BareMetalHardwareNICRequestedAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalHardwareNICRequestedAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BareMetalHardwareSpec) DeepCopyInto(out *BareMetalHardwareSpec) {
 	*out = *in
@@ -455,7 +530,7 @@ func (in *BareMetalHardwareSpec) DeepCopyInto(out *BareMetalHardwareSpec) {
 	}
 	if in.Taints != nil {
 		in, out := &in.Taints, &out.Taints
-		*out = make([]v1.Taint, len(*in))
+		*out = make([]corev1.Taint, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -572,6 +647,107 @@ func (in *BareMetalInstanceList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalInstanceResources) DeepCopyInto(out *BareMetalInstanceResources) {
+	*out = *in
+	if in.CPUS != nil {
+		in, out := &in.CPUS, &out.CPUS
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Ram != nil {
+		in, out := &in.Ram, &out.Ram
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ImageDriveSize != nil {
+		in, out := &in.ImageDriveSize, &out.ImageDriveSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.NICSpeed != nil {
+		in, out := &in.NICSpeed, &out.NICSpeed
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalInstanceResources.
+func (in *BareMetalInstanceResources) DeepCopy() *BareMetalInstanceResources {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalInstanceResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalInstanceScheduling) DeepCopyInto(out *BareMetalInstanceScheduling) {
+	*out = *in
+	if in.LastAttemptTime != nil {
+		in, out := &in.LastAttemptTime, &out.LastAttemptTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Filters != nil {
+		in, out := &in.Filters, &out.Filters
+		*out = make([]BareMetalInstanceSchedulingFilterResult, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hardware != nil {
+		in, out := &in.Hardware, &out.Hardware
+		*out = make([]BareMetalInstanceSchedulingHardwareResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalInstanceScheduling.
+func (in *BareMetalInstanceScheduling) DeepCopy() *BareMetalInstanceScheduling {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalInstanceScheduling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalInstanceSchedulingFilterResult) DeepCopyInto(out *BareMetalInstanceSchedulingFilterResult) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalInstanceSchedulingFilterResult.
+func (in *BareMetalInstanceSchedulingFilterResult) DeepCopy() *BareMetalInstanceSchedulingFilterResult {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalInstanceSchedulingFilterResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalInstanceSchedulingHardwareResult) DeepCopyInto(out *BareMetalInstanceSchedulingHardwareResult) {
+	*out = *in
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalInstanceSchedulingHardwareResult.
+func (in *BareMetalInstanceSchedulingHardwareResult) DeepCopy() *BareMetalInstanceSchedulingHardwareResult {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalInstanceSchedulingHardwareResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BareMetalInstanceSpec) DeepCopyInto(out *BareMetalInstanceSpec) {
 	*out = *in
@@ -584,11 +760,38 @@ func (in *BareMetalInstanceSpec) DeepCopyInto(out *BareMetalInstanceSpec) {
 	}
 	if in.Tolerations != nil {
 		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(BareMetalInstanceResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]TopologySpreadConstraint, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Gang != nil {
+		in, out := &in.Gang, &out.Gang
+		*out = new(InstanceGang)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalInstanceSpec.
@@ -610,6 +813,11 @@ func (in *BareMetalInstanceStatus) DeepCopyInto(out *BareMetalInstanceStatus) {
 		*out = new(BareMetalInstanceStatusAgentInfo)
 		**out = **in
 	}
+	if in.Scheduling != nil {
+		in, out := &in.Scheduling, &out.Scheduling
+		*out = new(BareMetalInstanceScheduling)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalInstanceStatus.
@@ -643,7 +851,7 @@ func (in *BareMetalNetwork) DeepCopyInto(out *BareMetalNetwork) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalNetwork.
@@ -664,6 +872,36 @@ func (in *BareMetalNetwork) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalNetworkDNS) DeepCopyInto(out *BareMetalNetworkDNS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalNetworkDNS.
+func (in *BareMetalNetworkDNS) DeepCopy() *BareMetalNetworkDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalNetworkDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalNetworkExclusion) DeepCopyInto(out *BareMetalNetworkExclusion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalNetworkExclusion.
+func (in *BareMetalNetworkExclusion) DeepCopy() *BareMetalNetworkExclusion {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalNetworkExclusion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BareMetalNetworkList) DeepCopyInto(out *BareMetalNetworkList) {
 	*out = *in
@@ -696,9 +934,49 @@ func (in *BareMetalNetworkList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalNetworkRange) DeepCopyInto(out *BareMetalNetworkRange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalNetworkRange.
+func (in *BareMetalNetworkRange) DeepCopy() *BareMetalNetworkRange {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalNetworkRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalNetworkRoute) DeepCopyInto(out *BareMetalNetworkRoute) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalNetworkRoute.
+func (in *BareMetalNetworkRoute) DeepCopy() *BareMetalNetworkRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalNetworkRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BareMetalNetworkSpec) DeepCopyInto(out *BareMetalNetworkSpec) {
 	*out = *in
+	if in.Ranges != nil {
+		in, out := &in.Ranges, &out.Ranges
+		*out = make([]BareMetalNetworkRange, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclusions != nil {
+		in, out := &in.Exclusions, &out.Exclusions
+		*out = make([]BareMetalNetworkExclusion, len(*in))
+		copy(*out, *in)
+	}
 	if in.Nameservers != nil {
 		in, out := &in.Nameservers, &out.Nameservers
 		*out = make([]string, len(*in))
@@ -709,6 +987,16 @@ func (in *BareMetalNetworkSpec) DeepCopyInto(out *BareMetalNetworkSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]BareMetalNetworkRoute, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(BareMetalNetworkDNS)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalNetworkSpec.
@@ -724,6 +1012,21 @@ func (in *BareMetalNetworkSpec) DeepCopy() *BareMetalNetworkSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BareMetalNetworkStatus) DeepCopyInto(out *BareMetalNetworkStatus) {
 	*out = *in
+	in.StatusConditions.DeepCopyInto(&out.StatusConditions)
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]BareMetalNetworkStatusPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Quarantine != nil {
+		in, out := &in.Quarantine, &out.Quarantine
+		*out = make([]BareMetalNetworkStatusQuarantine, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalNetworkStatus.
@@ -735,3 +1038,671 @@ func (in *BareMetalNetworkStatus) DeepCopy() *BareMetalNetworkStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalNetworkStatusPool) DeepCopyInto(out *BareMetalNetworkStatusPool) {
+	*out = *in
+	if in.Bitmap != nil {
+		in, out := &in.Bitmap, &out.Bitmap
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalNetworkStatusPool.
+func (in *BareMetalNetworkStatusPool) DeepCopy() *BareMetalNetworkStatusPool {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalNetworkStatusPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalNetworkStatusQuarantine) DeepCopyInto(out *BareMetalNetworkStatusQuarantine) {
+	*out = *in
+	in.ReleaseTime.DeepCopyInto(&out.ReleaseTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalNetworkStatusQuarantine.
+func (in *BareMetalNetworkStatusQuarantine) DeepCopy() *BareMetalNetworkStatusQuarantine {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalNetworkStatusQuarantine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalQuota) DeepCopyInto(out *BareMetalQuota) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalQuota.
+func (in *BareMetalQuota) DeepCopy() *BareMetalQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BareMetalQuota) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalQuotaList) DeepCopyInto(out *BareMetalQuotaList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BareMetalQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalQuotaList.
+func (in *BareMetalQuotaList) DeepCopy() *BareMetalQuotaList {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalQuotaList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BareMetalQuotaList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalQuotaResources) DeepCopyInto(out *BareMetalQuotaResources) {
+	*out = *in
+	if in.Instances != nil {
+		in, out := &in.Instances, &out.Instances
+		*out = new(int64)
+		**out = **in
+	}
+	if in.CPUS != nil {
+		in, out := &in.CPUS, &out.CPUS
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Ram != nil {
+		in, out := &in.Ram, &out.Ram
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalQuotaResources.
+func (in *BareMetalQuotaResources) DeepCopy() *BareMetalQuotaResources {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalQuotaResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalQuotaSpec) DeepCopyInto(out *BareMetalQuotaSpec) {
+	*out = *in
+	in.Hard.DeepCopyInto(&out.Hard)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalQuotaSpec.
+func (in *BareMetalQuotaSpec) DeepCopy() *BareMetalQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BareMetalQuotaStatus) DeepCopyInto(out *BareMetalQuotaStatus) {
+	*out = *in
+	in.Used.DeepCopyInto(&out.Used)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalQuotaStatus.
+func (in *BareMetalQuotaStatus) DeepCopy() *BareMetalQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BareMetalQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBareMetalHardware) DeepCopyInto(out *ClusterBareMetalHardware) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBareMetalHardware.
+func (in *ClusterBareMetalHardware) DeepCopy() *ClusterBareMetalHardware {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBareMetalHardware)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterBareMetalHardware) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBareMetalHardwareList) DeepCopyInto(out *ClusterBareMetalHardwareList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterBareMetalHardware, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBareMetalHardwareList.
+func (in *ClusterBareMetalHardwareList) DeepCopy() *ClusterBareMetalHardwareList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBareMetalHardwareList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterBareMetalHardwareList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBareMetalHardwareSpec) DeepCopyInto(out *ClusterBareMetalHardwareSpec) {
+	*out = *in
+	in.BareMetalHardwareSpec.DeepCopyInto(&out.BareMetalHardwareSpec)
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBareMetalHardwareSpec.
+func (in *ClusterBareMetalHardwareSpec) DeepCopy() *ClusterBareMetalHardwareSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBareMetalHardwareSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DHCPNetwork) DeepCopyInto(out *DHCPNetwork) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DHCPNetwork.
+func (in *DHCPNetwork) DeepCopy() *DHCPNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(DHCPNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DHCPNetwork) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DHCPNetworkList) DeepCopyInto(out *DHCPNetworkList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DHCPNetwork, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DHCPNetworkList.
+func (in *DHCPNetworkList) DeepCopy() *DHCPNetworkList {
+	if in == nil {
+		return nil
+	}
+	out := new(DHCPNetworkList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DHCPNetworkList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DHCPNetworkSpec) DeepCopyInto(out *DHCPNetworkSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DHCPNetworkSpec.
+func (in *DHCPNetworkSpec) DeepCopy() *DHCPNetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DHCPNetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DHCPNetworkStatus) DeepCopyInto(out *DHCPNetworkStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DHCPNetworkStatus.
+func (in *DHCPNetworkStatus) DeepCopy() *DHCPNetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DHCPNetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPIPAMNetwork) DeepCopyInto(out *HTTPIPAMNetwork) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPIPAMNetwork.
+func (in *HTTPIPAMNetwork) DeepCopy() *HTTPIPAMNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPIPAMNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HTTPIPAMNetwork) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPIPAMNetworkList) DeepCopyInto(out *HTTPIPAMNetworkList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HTTPIPAMNetwork, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPIPAMNetworkList.
+func (in *HTTPIPAMNetworkList) DeepCopy() *HTTPIPAMNetworkList {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPIPAMNetworkList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HTTPIPAMNetworkList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPIPAMNetworkSpec) DeepCopyInto(out *HTTPIPAMNetworkSpec) {
+	*out = *in
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPIPAMNetworkSpec.
+func (in *HTTPIPAMNetworkSpec) DeepCopy() *HTTPIPAMNetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPIPAMNetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPIPAMNetworkStatus) DeepCopyInto(out *HTTPIPAMNetworkStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPIPAMNetworkStatus.
+func (in *HTTPIPAMNetworkStatus) DeepCopy() *HTTPIPAMNetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPIPAMNetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareAffinity) DeepCopyInto(out *HardwareAffinity) {
+	*out = *in
+	if in.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		in, out := &in.RequiredDuringSchedulingIgnoredDuringExecution, &out.RequiredDuringSchedulingIgnoredDuringExecution
+		*out = new(HardwareSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreferredDuringSchedulingIgnoredDuringExecution != nil {
+		in, out := &in.PreferredDuringSchedulingIgnoredDuringExecution, &out.PreferredDuringSchedulingIgnoredDuringExecution
+		*out = make([]PreferredSchedulingTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareAffinity.
+func (in *HardwareAffinity) DeepCopy() *HardwareAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareSelector) DeepCopyInto(out *HardwareSelector) {
+	*out = *in
+	if in.HardwareSelectorTerms != nil {
+		in, out := &in.HardwareSelectorTerms, &out.HardwareSelectorTerms
+		*out = make([]HardwareSelectorTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSelector.
+func (in *HardwareSelector) DeepCopy() *HardwareSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareSelectorRequirement) DeepCopyInto(out *HardwareSelectorRequirement) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSelectorRequirement.
+func (in *HardwareSelectorRequirement) DeepCopy() *HardwareSelectorRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareSelectorRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareSelectorTerm) DeepCopyInto(out *HardwareSelectorTerm) {
+	*out = *in
+	if in.MatchExpressions != nil {
+		in, out := &in.MatchExpressions, &out.MatchExpressions
+		*out = make([]HardwareSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSelectorTerm.
+func (in *HardwareSelectorTerm) DeepCopy() *HardwareSelectorTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareSelectorTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceAffinity) DeepCopyInto(out *InstanceAffinity) {
+	*out = *in
+	if in.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		in, out := &in.RequiredDuringSchedulingIgnoredDuringExecution, &out.RequiredDuringSchedulingIgnoredDuringExecution
+		*out = make([]InstanceAffinityTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreferredDuringSchedulingIgnoredDuringExecution != nil {
+		in, out := &in.PreferredDuringSchedulingIgnoredDuringExecution, &out.PreferredDuringSchedulingIgnoredDuringExecution
+		*out = make([]WeightedInstanceAffinityTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceAffinity.
+func (in *InstanceAffinity) DeepCopy() *InstanceAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceAffinityTerm) DeepCopyInto(out *InstanceAffinityTerm) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceAffinityTerm.
+func (in *InstanceAffinityTerm) DeepCopy() *InstanceAffinityTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceAffinityTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceAntiAffinity) DeepCopyInto(out *InstanceAntiAffinity) {
+	*out = *in
+	if in.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		in, out := &in.RequiredDuringSchedulingIgnoredDuringExecution, &out.RequiredDuringSchedulingIgnoredDuringExecution
+		*out = make([]InstanceAffinityTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreferredDuringSchedulingIgnoredDuringExecution != nil {
+		in, out := &in.PreferredDuringSchedulingIgnoredDuringExecution, &out.PreferredDuringSchedulingIgnoredDuringExecution
+		*out = make([]WeightedInstanceAffinityTerm, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceAntiAffinity.
+func (in *InstanceAntiAffinity) DeepCopy() *InstanceAntiAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceAntiAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceGang) DeepCopyInto(out *InstanceGang) {
+	*out = *in
+	if in.ScheduleTimeoutSeconds != nil {
+		in, out := &in.ScheduleTimeoutSeconds, &out.ScheduleTimeoutSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceGang.
+func (in *InstanceGang) DeepCopy() *InstanceGang {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceGang)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreferredSchedulingTerm) DeepCopyInto(out *PreferredSchedulingTerm) {
+	*out = *in
+	in.Preference.DeepCopyInto(&out.Preference)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreferredSchedulingTerm.
+func (in *PreferredSchedulingTerm) DeepCopy() *PreferredSchedulingTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(PreferredSchedulingTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologySpreadConstraint) DeepCopyInto(out *TopologySpreadConstraint) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologySpreadConstraint.
+func (in *TopologySpreadConstraint) DeepCopy() *TopologySpreadConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologySpreadConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightedInstanceAffinityTerm) DeepCopyInto(out *WeightedInstanceAffinityTerm) {
+	*out = *in
+	in.InstanceAffinityTerm.DeepCopyInto(&out.InstanceAffinityTerm)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightedInstanceAffinityTerm.
+func (in *WeightedInstanceAffinityTerm) DeepCopy() *WeightedInstanceAffinityTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightedInstanceAffinityTerm)
+	in.DeepCopyInto(out)
+	return out
+}
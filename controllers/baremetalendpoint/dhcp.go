@@ -0,0 +1,155 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package baremetalendpoint
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// DHCP addresses endpoints that reference a DHCPNetwork
+// the address is handed out by an existing dhcp server so the endpoint is only marked as using dhcp
+type DHCP struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+func (r *DHCP) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("baremetalendpoint", req.NamespacedName)
+
+	bme := &baremetalv1alpha1.BareMetalEndpoint{}
+	if err := r.Client.Get(ctx, req.NamespacedName, bme); err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			log.Error(err, "failed to retrieve BareMetalEndpoint resource")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// we only care about stuff that has a phase
+	if len(bme.Status.Phase) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	// we only care if the bme belongs to our group
+	if bme.Spec.NetworkRef.Group != baremetalv1alpha1.GroupVersion.Group {
+		return ctrl.Result{}, nil
+	}
+
+	// we only care if the bme belongs to our kind
+	if bme.Spec.NetworkRef.Kind != "DHCPNetwork" {
+		return ctrl.Result{}, nil
+	}
+
+	if bme.DeletionTimestamp.IsZero() == false {
+		// bme is already deleted so we don't care about it
+		if bme.Status.Phase == baremetalv1alpha1.BareMetalEndpointStatusPhaseDeleted {
+			return ctrl.Result{}, nil
+		}
+
+		// address is nil so deleted it
+		if bme.Status.Address == nil {
+			bme.Status.Phase = baremetalv1alpha1.BareMetalEndpointStatusPhaseDeleted
+			err := r.Status().Update(ctx, bme)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+
+		// there is nothing to release so set address to nil
+		bme.Status.Address = nil
+		err := r.Status().Update(ctx, bme)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// bme is already addressed so we don't care about it
+	if bme.Status.Phase == baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressed {
+		return ctrl.Result{}, nil
+	}
+
+	// bme has an address so set it to addressed
+	if bme.Status.Address != nil {
+		bme.Status.Phase = baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressed
+		err := r.Status().Update(ctx, bme)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// find the network, if we can't find it event and retry back-off, it may eventually be found
+	dhcpn := &baremetalv1alpha1.DHCPNetwork{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: bme.Namespace, Name: bme.Spec.NetworkRef.Name}, dhcpn); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.Recorder.Eventf(bme, corev1.EventTypeWarning, "NetworkNotFound", "Could not find a DHCPNetwork with the name of %s", bme.Spec.NetworkRef.Name)
+			return ctrl.Result{Requeue: true}, nil
+		}
+		log.Error(err, "failed to retrieve DHCPNetwork resource")
+		return ctrl.Result{}, err
+	}
+
+	// bme is pending so set it to addressing
+	if bme.Status.Phase == baremetalv1alpha1.BareMetalEndpointStatusPhasePending {
+		bme.Status.Phase = baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressing
+		err := r.Status().Update(ctx, bme)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(bme, corev1.EventTypeNormal, "Addressing", "Endpoint is being addressed")
+		return ctrl.Result{}, nil
+	}
+
+	ipFamily := dhcpn.Spec.IPFamily
+	if len(ipFamily) == 0 {
+		ipFamily = baremetalv1alpha1.IPFamilyIPv4
+	}
+
+	bme.Status.Address = &baremetalv1alpha1.BareMetalEndpointStatusAddress{
+		DHCP:     true,
+		IPFamily: ipFamily,
+		MTU:      dhcpn.Spec.MTU,
+	}
+	err := r.Status().Update(ctx, bme)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Eventf(bme, corev1.EventTypeNormal, "Addressed", "Endpoint has been addressed using dhcp")
+	return ctrl.Result{}, nil
+}
+
+func (r *DHCP) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("BareMetalEndpointDHCP").
+		For(&baremetalv1alpha1.BareMetalEndpoint{}).
+		Complete(r)
+}
@@ -0,0 +1,315 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package baremetalendpoint
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	conditionv1 "github.com/rmb938/kube-baremetal/apis/condition/v1"
+	"github.com/rmb938/kube-baremetal/pkg/httpipam"
+)
+
+// HTTPIPAM addresses endpoints that reference a HTTPIPAMNetwork
+// by delegating allocate and release to an external ipam
+type HTTPIPAM struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Clock    clock.Clock
+	Recorder record.EventRecorder
+}
+
+func (r *HTTPIPAM) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("baremetalendpoint", req.NamespacedName)
+
+	bme := &baremetalv1alpha1.BareMetalEndpoint{}
+	if err := r.Client.Get(ctx, req.NamespacedName, bme); err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			log.Error(err, "failed to retrieve BareMetalEndpoint resource")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// we only care about stuff that has a phase
+	if len(bme.Status.Phase) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	// we only care if the bme belongs to our group
+	if bme.Spec.NetworkRef.Group != baremetalv1alpha1.GroupVersion.Group {
+		return ctrl.Result{}, nil
+	}
+
+	// we only care if the bme belongs to our kind
+	if bme.Spec.NetworkRef.Kind != "HTTPIPAMNetwork" {
+		return ctrl.Result{}, nil
+	}
+
+	// find the network, if we can't find it event and set it to nil
+	hipamn := &baremetalv1alpha1.HTTPIPAMNetwork{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: bme.Namespace, Name: bme.Spec.NetworkRef.Name}, hipamn); err != nil {
+		if apierrors.IsNotFound(err) {
+			hipamn = nil
+			r.Recorder.Eventf(bme, corev1.EventTypeWarning, "NetworkNotFound", "Could not find a HTTPIPAMNetwork with the name of %s", bme.Spec.NetworkRef.Name)
+		} else {
+			log.Error(err, "failed to retrieve HTTPIPAMNetwork resource")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if bme.DeletionTimestamp.IsZero() == false {
+		// bme is already deleted so we don't care about it
+		if bme.Status.Phase == baremetalv1alpha1.BareMetalEndpointStatusPhaseDeleted {
+			return ctrl.Result{}, nil
+		}
+
+		// address is nil so deleted it
+		if bme.Status.Address == nil {
+			bme.Status.Phase = baremetalv1alpha1.BareMetalEndpointStatusPhaseDeleted
+			err := r.Status().Update(ctx, bme)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+
+		// network exists so release the address
+		if hipamn != nil {
+			if bme.Status.Phase != baremetalv1alpha1.BareMetalEndpointStatusPhaseDeleting {
+				bme.Status.Phase = baremetalv1alpha1.BareMetalEndpointStatusPhaseDeleting
+				err := r.Status().Update(ctx, bme)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				return ctrl.Result{}, nil
+			}
+
+			ipamClient, err := r.ipamClient(ctx, hipamn)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			err = ipamClient.Release(ctx, &httpipam.ReleaseRequest{
+				Network:  r.network(hipamn),
+				Endpoint: r.endpoint(bme),
+				IP:       bme.Status.Address.IP,
+			})
+			if err != nil {
+				r.Recorder.Eventf(bme, corev1.EventTypeWarning, baremetalv1alpha1.HTTPIPAMNetworkReleaseFailedEventReason, "Could not release the address from the external ipam: %v", err)
+				return ctrl.Result{Requeue: true}, nil
+			}
+		}
+
+		// we are done so set address to nil
+		bme.Status.Address = nil
+		err := r.Status().Update(ctx, bme)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// bme is already addressed so we don't care about it
+	if bme.Status.Phase == baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressed {
+		return ctrl.Result{}, nil
+	}
+
+	// bme has an address so set it to addressed
+	if bme.Status.Address != nil {
+		bme.Status.Phase = baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressed
+		err := r.Status().Update(ctx, bme)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// if we can't find the network retry back-off, it may eventually be found
+	if hipamn == nil {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// bme is pending so set it to addressing
+	if bme.Status.Phase == baremetalv1alpha1.BareMetalEndpointStatusPhasePending {
+		bme.Status.Phase = baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressing
+		err := r.Status().Update(ctx, bme)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(bme, corev1.EventTypeNormal, "Addressing", "Endpoint is being addressed")
+		return ctrl.Result{}, nil
+	}
+
+	ipamClient, err := r.ipamClient(ctx, hipamn)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the external ipam keys allocations by the endpoint uid so retrying after a failure does not leak addresses
+	resp, err := ipamClient.Allocate(ctx, &httpipam.AllocateRequest{
+		Network:  r.network(hipamn),
+		Endpoint: r.endpoint(bme),
+	})
+	if err != nil {
+		if len(bme.Spec.RequestedIP) > 0 && httpipam.IsConflict(err) {
+			cond := bme.Status.GetCondition(baremetalv1alpha1.BareMetalEndpointConditionTypeRequestedAddressAllocated)
+			if cond == nil || cond.Reason != baremetalv1alpha1.BareMetalEndpointRequestedAddressTakenConditionReason {
+				message := fmt.Sprintf("requested address %s is already allocated", bme.Spec.RequestedIP)
+				nowTime := metav1.NewTime(r.Clock.Now())
+				err := bme.Status.SetCondition(&conditionv1.StatusCondition{
+					Type:               baremetalv1alpha1.BareMetalEndpointConditionTypeRequestedAddressAllocated,
+					Status:             conditionv1.ConditionStatusFalse,
+					LastTransitionTime: &nowTime,
+					Reason:             baremetalv1alpha1.BareMetalEndpointRequestedAddressTakenConditionReason,
+					Message:            message,
+				})
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				err = r.Status().Update(ctx, bme)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				r.Recorder.Eventf(bme, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalEndpointRequestedAddressUnavailableEventReason, "Could not allocate the requested address: %s", message)
+			}
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		r.Recorder.Eventf(bme, corev1.EventTypeWarning, baremetalv1alpha1.HTTPIPAMNetworkAllocateFailedEventReason, "Could not allocate an address from the external ipam: %v", err)
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	ip, err := validateAllocation(bme, resp)
+	if err != nil {
+		r.Recorder.Eventf(bme, corev1.EventTypeWarning, baremetalv1alpha1.HTTPIPAMNetworkAllocateFailedEventReason, "Could not use the address from the external ipam: %v", err)
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	var routes []baremetalv1alpha1.BareMetalNetworkRoute
+	for _, route := range resp.Routes {
+		routes = append(routes, baremetalv1alpha1.BareMetalNetworkRoute{
+			Destination: route.Destination,
+			Gateway:     route.Gateway,
+			Metric:      route.Metric,
+		})
+	}
+
+	bme.Status.Address = &baremetalv1alpha1.BareMetalEndpointStatusAddress{
+		IP:          ip.String(),
+		CIDR:        resp.CIDR,
+		Gateway:     resp.Gateway,
+		Nameservers: resp.Nameservers,
+		Search:      resp.Search,
+		Routes:      routes,
+		MTU:         resp.MTU,
+	}
+	if len(bme.Spec.RequestedIP) > 0 {
+		nowTime := metav1.NewTime(r.Clock.Now())
+		err := bme.Status.SetCondition(&conditionv1.StatusCondition{
+			Type:               baremetalv1alpha1.BareMetalEndpointConditionTypeRequestedAddressAllocated,
+			Status:             conditionv1.ConditionStatusTrue,
+			LastTransitionTime: &nowTime,
+			Reason:             baremetalv1alpha1.BareMetalEndpointRequestedAddressAllocatedConditionReason,
+			Message:            "requested address is allocated",
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	err = r.Status().Update(ctx, bme)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Eventf(bme, corev1.EventTypeNormal, "Addressed", "Endpoint has been addressed")
+	return ctrl.Result{}, nil
+}
+
+// helper method to make sure the external ipam gave us something usable
+func validateAllocation(bme *baremetalv1alpha1.BareMetalEndpoint, resp *httpipam.AllocateResponse) (net.IP, error) {
+	ip := net.ParseIP(resp.IP)
+	_, network, err := net.ParseCIDR(resp.CIDR)
+	if ip == nil || err != nil || network.Contains(ip) == false {
+		return nil, fmt.Errorf("invalid address %s/%s", resp.IP, resp.CIDR)
+	}
+
+	if len(resp.Nameservers) == 0 {
+		return nil, fmt.Errorf("no nameservers for address %s/%s", resp.IP, resp.CIDR)
+	}
+
+	if len(bme.Spec.RequestedIP) > 0 && ip.Equal(net.ParseIP(bme.Spec.RequestedIP)) == false {
+		return nil, fmt.Errorf("got %s instead of the requested address %s", resp.IP, bme.Spec.RequestedIP)
+	}
+
+	return ip, nil
+}
+
+// helper method to create a client for the external ipam of the network
+func (r *HTTPIPAM) ipamClient(ctx context.Context, hipamn *baremetalv1alpha1.HTTPIPAMNetwork) (*httpipam.Client, error) {
+	token := ""
+	if hipamn.Spec.TokenSecretRef != nil {
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: hipamn.Namespace, Name: hipamn.Spec.TokenSecretRef.Name}, secret)
+		if err != nil {
+			return nil, err
+		}
+		token = string(secret.Data["token"])
+	}
+
+	return httpipam.NewClient(hipamn.Spec.URL, token, time.Duration(hipamn.Spec.TimeoutSeconds)*time.Second), nil
+}
+
+func (r *HTTPIPAM) network(hipamn *baremetalv1alpha1.HTTPIPAMNetwork) httpipam.Network {
+	return httpipam.Network{
+		Namespace:  hipamn.Namespace,
+		Name:       hipamn.Name,
+		Parameters: hipamn.Spec.Parameters,
+	}
+}
+
+func (r *HTTPIPAM) endpoint(bme *baremetalv1alpha1.BareMetalEndpoint) httpipam.Endpoint {
+	return httpipam.Endpoint{
+		Namespace:   bme.Namespace,
+		Name:        bme.Name,
+		UID:         string(bme.UID),
+		MAC:         bme.Spec.MAC,
+		Primary:     bme.Spec.Primary,
+		RequestedIP: bme.Spec.RequestedIP,
+	}
+}
+
+func (r *HTTPIPAM) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("BareMetalEndpointHTTPIPAM").
+		For(&baremetalv1alpha1.BareMetalEndpoint{}).
+		Complete(r)
+}
@@ -0,0 +1,97 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package baremetalendpoint
+
+import (
+	"testing"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/pkg/httpipam"
+)
+
+func TestValidateAllocation(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestedIP string
+		response    *httpipam.AllocateResponse
+		ip          string
+		err         bool
+	}{
+		{
+			name:     "valid",
+			response: &httpipam.AllocateResponse{IP: "10.0.0.5", CIDR: "10.0.0.0/24", Nameservers: []string{"10.0.0.2"}},
+			ip:       "10.0.0.5",
+		},
+		{
+			name:     "ipv6",
+			response: &httpipam.AllocateResponse{IP: "fd00::0005", CIDR: "fd00::/64", Nameservers: []string{"fd00::2"}},
+			ip:       "fd00::5",
+		},
+		{
+			name:     "invalid address",
+			response: &httpipam.AllocateResponse{IP: "10.0.0", CIDR: "10.0.0.0/24", Nameservers: []string{"10.0.0.2"}},
+			err:      true,
+		},
+		{
+			name:     "invalid cidr",
+			response: &httpipam.AllocateResponse{IP: "10.0.0.5", CIDR: "10.0.0.0", Nameservers: []string{"10.0.0.2"}},
+			err:      true,
+		},
+		{
+			name:     "address outside of the cidr",
+			response: &httpipam.AllocateResponse{IP: "10.0.1.5", CIDR: "10.0.0.0/24", Nameservers: []string{"10.0.0.2"}},
+			err:      true,
+		},
+		{
+			name:     "no nameservers",
+			response: &httpipam.AllocateResponse{IP: "10.0.0.5", CIDR: "10.0.0.0/24"},
+			err:      true,
+		},
+		{
+			name:        "requested address",
+			requestedIP: "10.0.0.5",
+			response:    &httpipam.AllocateResponse{IP: "10.0.0.5", CIDR: "10.0.0.0/24", Nameservers: []string{"10.0.0.2"}},
+			ip:          "10.0.0.5",
+		},
+		{
+			name:        "different then the requested address",
+			requestedIP: "10.0.0.6",
+			response:    &httpipam.AllocateResponse{IP: "10.0.0.5", CIDR: "10.0.0.0/24", Nameservers: []string{"10.0.0.2"}},
+			err:         true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bme := &baremetalv1alpha1.BareMetalEndpoint{}
+			bme.Spec.RequestedIP = test.requestedIP
+
+			ip, err := validateAllocation(bme, test.response)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error got %s", ip)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error validating allocation: %v", err)
+			}
+			if ip.String() != test.ip {
+				t.Errorf("expected %s got %s", test.ip, ip)
+			}
+		})
+	}
+}
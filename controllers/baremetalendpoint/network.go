@@ -16,22 +16,27 @@ limitations under the License.
 package baremetalendpoint
 
 import (
-	"bytes"
 	"context"
+	"fmt"
 	"net"
-	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 
 	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	conditionv1 "github.com/rmb938/kube-baremetal/apis/condition/v1"
+	"github.com/rmb938/kube-baremetal/pkg/ipam"
 )
 
 type Network struct {
@@ -41,7 +46,8 @@ type Network struct {
 	Clock    clock.Clock
 	Recorder record.EventRecorder
 
-	addressLock sync.Mutex
+	// the number of endpoints that can be addressed at the same time
+	MaxConcurrentReconciles int
 }
 
 func (r *Network) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -114,7 +120,22 @@ func (r *Network) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 				return ctrl.Result{}, nil
 			}
 
-			// TODO: do we need to do any deletion things?
+			// clear the address on the endpoint before releasing it, otherwise when the endpoint update fails
+			// the retry could release the address again after it has been handed out to another endpoint
+			address := bme.Status.Address
+			bme.Status.Address = nil
+			err := r.Status().Update(ctx, bme)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			// the endpoint doesn't have the address anymore so when releasing fails
+			// the network controller frees it once the leak grace period has passed
+			err = r.releaseAddress(ctx, bme, types.NamespacedName{Namespace: bmn.Namespace, Name: bmn.Name}, net.ParseIP(address.IP))
+			if err != nil {
+				log.Error(err, "failed to release address, it will be freed by the BareMetalNetwork controller", "address", address.IP)
+			}
+			return ctrl.Result{}, nil
 		}
 
 		// we are done so set address to nil
@@ -146,6 +167,12 @@ func (r *Network) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	// the network is being deleted so don't hand out any more addresses
+	if bmn.DeletionTimestamp.IsZero() == false {
+		r.Recorder.Eventf(bme, corev1.EventTypeWarning, "NetworkDeleting", "The BareMetalNetwork %s is being deleted", bmn.Name)
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	// bme is pending so set it to addressing
 	if bme.Status.Phase == baremetalv1alpha1.BareMetalEndpointStatusPhasePending {
 		bme.Status.Phase = baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressing
@@ -157,80 +184,103 @@ func (r *Network) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
-	// lock so we don't accidentally hand out duplicate ips
-	// this doesn't need to be fast, it needs to be accurate
-	r.addressLock.Lock()
-	defer r.addressLock.Unlock()
-
-	bmeList := &baremetalv1alpha1.BareMetalEndpointList{}
-	err := r.List(ctx, bmeList, client.MatchingFields{"spec.networkRef.group,kind,name": baremetalv1alpha1.GroupVersion.Group + "." + bmn.Kind + "." + bmn.Name})
+	alloc, err := ipam.NewAllocator(bmn)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	cidrIP, network, err := net.ParseCIDR(bmn.Spec.CIDR)
-	if err != nil {
-		return ctrl.Result{}, err
+	// the network controller seeds the pools from existing endpoints
+	// so wait for it otherwise we may hand out an address that is already in use
+	if alloc.Initialized() == false {
+		log.Info("waiting for BareMetalNetwork pools to be initialized", "network", bmn.Name)
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
-	networkIP := cidrIP.Mask(network.Mask)
-
-	start := net.ParseIP(bmn.Spec.Start)
-	end := net.ParseIP(bmn.Spec.End)
 
-	gateway := net.ParseIP(bmn.Spec.Gateway)
-
-	// find the broadcast address
-	broadcast := net.IP(make([]byte, len(network.Mask)))
-	for i := range network.Mask {
-		broadcast[i] = networkIP[i] | ^network.Mask[i]
-	}
+	var nextIP net.IP
+	if len(bme.Spec.RequestedIP) > 0 {
+		// a specific address was requested so only allocate that
+		nextIP = net.ParseIP(bme.Spec.RequestedIP)
+		err = alloc.Allocate(nextIP)
+		if err != nil {
+			var reason string
+			var message string
 
-	var nextIP *net.IP
-	for ip := start; bytes.Compare(end, ip) >= 0; r.inc(ip) {
-		// ignore network, gateway and broadcast IPs
-		if ip.Equal(networkIP) || ip.Equal(gateway) || ip.Equal(broadcast) {
-			continue
-		}
+			switch err {
+			case ipam.ErrAllocated:
+				reason = baremetalv1alpha1.BareMetalEndpointRequestedAddressTakenConditionReason
+				message = fmt.Sprintf("requested address %s is already allocated", bme.Spec.RequestedIP)
 
-		found := false
-		// ignore nameservers (they might be in the range)
-		for _, ns := range bmn.Spec.Nameservers {
-			nsIP := net.ParseIP(ns)
-			if ip.Equal(nsIP) {
-				found = true
-				break
+				owner, err := r.addressOwner(ctx, bmn, nextIP)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				if len(owner) > 0 {
+					message = fmt.Sprintf("requested address %s is already allocated to the BareMetalEndpoint %s", bme.Spec.RequestedIP, owner)
+				}
+			case ipam.ErrNotInRange:
+				reason = baremetalv1alpha1.BareMetalEndpointRequestedAddressNotInRangeConditionReason
+				message = fmt.Sprintf("requested address %s is not in any of the ranges of the BareMetalNetwork %s", bme.Spec.RequestedIP, bmn.Name)
+			case ipam.ErrExcluded:
+				reason = baremetalv1alpha1.BareMetalEndpointRequestedAddressExcludedConditionReason
+				message = fmt.Sprintf("requested address %s is excluded from allocation on the BareMetalNetwork %s", bme.Spec.RequestedIP, bmn.Name)
+			case ipam.ErrQuarantined:
+				reason = baremetalv1alpha1.BareMetalEndpointRequestedAddressQuarantinedConditionReason
+				message = fmt.Sprintf("requested address %s was recently released and is quarantined on the BareMetalNetwork %s", bme.Spec.RequestedIP, bmn.Name)
+			default:
+				return ctrl.Result{}, err
 			}
-		}
 
-		// if nameserver wasn't found check allocated
-		if found == false {
-			// ignore ips already allocated
-			for _, bme := range bmeList.Items {
-				if bme.Status.Address == nil {
-					continue
+			// only update and event when the reason changes, the address may become free later so try again with back-off
+			cond := bme.Status.GetCondition(baremetalv1alpha1.BareMetalEndpointConditionTypeRequestedAddressAllocated)
+			if cond == nil || cond.Reason != reason {
+				nowTime := metav1.NewTime(r.Clock.Now())
+				err := bme.Status.SetCondition(&conditionv1.StatusCondition{
+					Type:               baremetalv1alpha1.BareMetalEndpointConditionTypeRequestedAddressAllocated,
+					Status:             conditionv1.ConditionStatusFalse,
+					LastTransitionTime: &nowTime,
+					Reason:             reason,
+					Message:            message,
+				})
+				if err != nil {
+					return ctrl.Result{}, err
 				}
-
-				bmeIP := net.ParseIP(bme.Status.Address.IP)
-				if ip.Equal(bmeIP) {
-					found = true
-					break
+				err = r.Status().Update(ctx, bme)
+				if err != nil {
+					return ctrl.Result{}, err
 				}
+				r.Recorder.Eventf(bme, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalEndpointRequestedAddressUnavailableEventReason, "Could not allocate the requested address: %s", message)
 			}
+
+			return ctrl.Result{Requeue: true}, nil
 		}
+	} else {
+		nextIP, err = alloc.AllocateNext()
+		if err != nil {
+			// if a next ip couldn't be found event and try again with back-off
+			if err == ipam.ErrFull {
+				r.Recorder.Eventf(bme, corev1.EventTypeWarning, "NoIPAvailable", "Could find an available IP address on the BareMetalNetwork %s", bme.Spec.NetworkRef.Name)
+				return ctrl.Result{Requeue: true}, nil
+			}
+			return ctrl.Result{}, err
+		}
+	}
 
-		// if ip is not allocated set it as the nextIP
-		if found == false {
-			ipCopy := make(net.IP, len(ip))
-			copy(ipCopy, ip)
-			nextIP = &ipCopy
-			break
+	// persist the allocation in the network status before handing out the address
+	// the update fails on conflict if another worker allocated at the same time so the address is never handed out twice
+	err = alloc.Save(bmn, metav1.NewTime(r.Clock.Now()))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.Status().Update(ctx, bmn)
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
 		}
+		return ctrl.Result{}, err
 	}
 
-	// if a next ip couldn't be found event and try again with back-off
-	if nextIP == nil {
-		r.Recorder.Eventf(bme, corev1.EventTypeWarning, "NoIPAvailable", "Could find an available IP address on the BareMetalNetwork %s", bme.Spec.NetworkRef.Name)
-		return ctrl.Result{Requeue: true}, err
+	if bmn.Status.Free == 0 {
+		r.Recorder.Eventf(bmn, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalNetworkPoolExhaustedEventReason, "All addresses in the BareMetalNetwork %s have been allocated", bmn.Name)
 	}
 
 	// a next ip was found so set the address
@@ -240,6 +290,21 @@ func (r *Network) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		Gateway:     bmn.Spec.Gateway,
 		Nameservers: bmn.Spec.Nameservers,
 		Search:      bmn.Spec.Search,
+		Routes:      bmn.Spec.Routes,
+		MTU:         bmn.Spec.MTU,
+	}
+	if len(bme.Spec.RequestedIP) > 0 {
+		nowTime := metav1.NewTime(r.Clock.Now())
+		err := bme.Status.SetCondition(&conditionv1.StatusCondition{
+			Type:               baremetalv1alpha1.BareMetalEndpointConditionTypeRequestedAddressAllocated,
+			Status:             conditionv1.ConditionStatusTrue,
+			LastTransitionTime: &nowTime,
+			Reason:             baremetalv1alpha1.BareMetalEndpointRequestedAddressAllocatedConditionReason,
+			Message:            "requested address is allocated",
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 	err = r.Status().Update(ctx, bme)
 	if err != nil {
@@ -249,15 +314,61 @@ func (r *Network) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	return ctrl.Result{}, nil
 }
 
-// helper method for incrementing IP addresses
-// I wish Go had a good ipaddress library like python https://docs.python.org/3/library/ipaddress.html
-func (r *Network) inc(ip net.IP) {
-	for j := len(ip) - 1; j >= 0; j-- {
-		ip[j]++
-		if ip[j] > 0 {
-			break
+// helper method to release the ip back to the pool of the network
+// conflicts are retried against the latest network since the endpoint no longer holds the ip
+func (r *Network) releaseAddress(ctx context.Context, bme *baremetalv1alpha1.BareMetalEndpoint, bmnKey types.NamespacedName, ip net.IP) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		bmn := &baremetalv1alpha1.BareMetalNetwork{}
+		err := r.Get(ctx, bmnKey, bmn)
+		if err != nil {
+			return err
+		}
+
+		alloc, err := ipam.NewAllocator(bmn)
+		if err != nil {
+			return err
+		}
+
+		if alloc.Has(ip) == false || alloc.Quarantined(ip) {
+			return nil
+		}
+
+		nowTime := metav1.NewTime(r.Clock.Now())
+		alloc.Release(ip, nowTime)
+		err = alloc.Save(bmn, nowTime)
+		if err != nil {
+			return err
+		}
+
+		err = r.Status().Update(ctx, bmn)
+		if err != nil {
+			return err
+		}
+
+		if alloc.Quarantined(ip) {
+			r.Recorder.Eventf(bme, corev1.EventTypeNormal, "Released", "Address %s has been released and is quarantined for %d seconds", ip, bmn.Spec.ReleaseHoldDownSeconds)
+		} else {
+			r.Recorder.Eventf(bme, corev1.EventTypeNormal, "Released", "Address %s has been released", ip)
+		}
+		return nil
+	})
+}
+
+// helper method to find the name of the endpoint that is using an address in the network
+func (r *Network) addressOwner(ctx context.Context, bmn *baremetalv1alpha1.BareMetalNetwork, ip net.IP) (string, error) {
+	bmeList := &baremetalv1alpha1.BareMetalEndpointList{}
+	err := r.List(ctx, bmeList, client.MatchingFields{"spec.networkRef.group,kind,name": baremetalv1alpha1.GroupVersion.Group + ".BareMetalNetwork." + bmn.Name})
+	if err != nil {
+		return "", err
+	}
+
+	for _, bme := range bmeList.Items {
+		if bme.Status.Address != nil && ip.Equal(net.ParseIP(bme.Status.Address.IP)) {
+			return bme.Name, nil
 		}
 	}
+
+	return "", nil
 }
 
 func (r *Network) SetupWithManager(mgr ctrl.Manager) error {
@@ -272,5 +383,6 @@ func (r *Network) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("BareMetalEndpointNetwork").
 		For(&baremetalv1alpha1.BareMetalEndpoint{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
@@ -64,11 +64,20 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		return ctrl.Result{}, err
 	}
 
-	// BMH is deleting
-	if bmh.DeletionTimestamp.IsZero() == false {
+	return r.reconcileHardware(ctx, bmh)
+}
+
+// helper method to reconcile the conditions, taints and instance of the hardware
+// it is shared by the BareMetalHardware and ClusterBareMetalHardware reconcilers
+func (r *BareMetalHardwareReconciler) reconcileHardware(ctx context.Context, hw baremetalv1alpha1.Hardware) (ctrl.Result, error) {
+	spec := hw.GetHardwareSpec()
+	status := hw.GetHardwareStatus()
+
+	// hardware is deleting
+	if hw.GetDeletionTimestamp().IsZero() == false {
 		hasTaint := false
 
-		for _, t := range bmh.Spec.Taints {
+		for _, t := range spec.Taints {
 			if t.Key == baremetalv1alpha1.BareMetalHardwareTaintKeyNoSchedule {
 				hasTaint = true
 				break
@@ -77,28 +86,28 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 
 		if hasTaint == false {
 			nowTime := metav1.NewTime(r.Clock.Now())
-			bmh.Spec.Taints = append(bmh.Spec.Taints, corev1.Taint{
+			spec.Taints = append(spec.Taints, corev1.Taint{
 				Key:       baremetalv1alpha1.BareMetalHardwareTaintKeyNoSchedule,
 				Effect:    corev1.TaintEffectNoSchedule,
 				TimeAdded: &nowTime,
 			})
-			err := r.Update(ctx, bmh)
+			err := r.Update(ctx, hw)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			r.Recorder.Eventf(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareNotSchedulableEventReason, "Hardware %s status is now HardwareNotSchedulable", bmh.Name)
+			r.Recorder.Eventf(hw, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareNotSchedulableEventReason, "Hardware %s status is now HardwareNotSchedulable", hw.GetName())
 			return ctrl.Result{}, nil
 		}
 
-		if bmh.Status.InstanceRef != nil {
+		if status.InstanceRef != nil {
 			bmi := &baremetalv1alpha1.BareMetalInstance{}
-			err := r.Get(ctx, types.NamespacedName{Namespace: bmh.Status.InstanceRef.Namespace, Name: bmh.Status.InstanceRef.Name}, bmi)
+			err := r.Get(ctx, types.NamespacedName{Namespace: status.InstanceRef.Namespace, Name: status.InstanceRef.Name}, bmi)
 			if err != nil {
 				if apierrors.IsNotFound(err) {
 					// bmi wasn't found so set instance ref to nil
-					bmh.Status.InstanceRef = nil
-					err := r.Status().Update(ctx, bmh)
+					status.InstanceRef = nil
+					err := r.Status().Update(ctx, hw)
 					if err != nil {
 						return ctrl.Result{}, err
 					}
@@ -108,19 +117,19 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 				}
 			}
 
-			if bmi.UID != bmh.Status.InstanceRef.UID {
+			if bmi.UID != status.InstanceRef.UID {
 				// bmi was found but doesn't match UID
 				// this means it's not our instance
 				// so lets remove the instance ref
-				bmh.Status.InstanceRef = nil
-				err := r.Status().Update(ctx, bmh)
+				status.InstanceRef = nil
+				err := r.Status().Update(ctx, hw)
 				if err != nil {
 					return ctrl.Result{}, err
 				}
 				return ctrl.Result{}, nil
 			}
 
-			r.Recorder.Eventf(bmh, corev1.EventTypeNormal, "FailedDelete", "Cannot delete hardware while an instance is scheduled.")
+			r.Recorder.Eventf(hw, corev1.EventTypeNormal, "FailedDelete", "Cannot delete hardware while an instance is scheduled.")
 
 			err = r.Delete(ctx, bmi)
 			if err != nil {
@@ -130,9 +139,9 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 			return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
 		}
 
-		// Done deleting so remove bmh finalizer
-		baremetalapi.RemoveFinalizer(bmh, baremetalv1alpha1.BareMetalHardwareFinalizer)
-		err := r.Update(ctx, bmh)
+		// Done deleting so remove hardware finalizer
+		baremetalapi.RemoveFinalizer(hw, baremetalv1alpha1.BareMetalHardwareFinalizer)
+		err := r.Update(ctx, hw)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -141,9 +150,9 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 
 	// If canProvision is true remove no schedule taint
 	//  if false add no schedule taint
-	if bmh.Spec.CanProvision {
+	if spec.CanProvision {
 		taintIndex := -1
-		for idx, t := range bmh.Spec.Taints {
+		for idx, t := range spec.Taints {
 			if t.Key == baremetalv1alpha1.BareMetalHardwareTaintKeyNoSchedule {
 				taintIndex = idx
 				break
@@ -151,19 +160,19 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		}
 
 		if taintIndex >= 0 {
-			bmh.Spec.Taints = append(bmh.Spec.Taints[:taintIndex], bmh.Spec.Taints[taintIndex+1:]...)
-			err := r.Update(ctx, bmh)
+			spec.Taints = append(spec.Taints[:taintIndex], spec.Taints[taintIndex+1:]...)
+			err := r.Update(ctx, hw)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			r.Recorder.Eventf(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareSchedulableEventReason, "Hardware %s status is now HardwareSchedulable", bmh.Name)
+			r.Recorder.Eventf(hw, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareSchedulableEventReason, "Hardware %s status is now HardwareSchedulable", hw.GetName())
 			return ctrl.Result{}, nil
 		}
 	} else {
 		hasTaint := false
 
-		for _, t := range bmh.Spec.Taints {
+		for _, t := range spec.Taints {
 			if t.Key == baremetalv1alpha1.BareMetalHardwareTaintKeyNoSchedule {
 				hasTaint = true
 				break
@@ -172,24 +181,24 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 
 		if hasTaint == false {
 			nowTime := metav1.NewTime(r.Clock.Now())
-			bmh.Spec.Taints = append(bmh.Spec.Taints, corev1.Taint{
+			spec.Taints = append(spec.Taints, corev1.Taint{
 				Key:       baremetalv1alpha1.BareMetalHardwareTaintKeyNoSchedule,
 				Effect:    corev1.TaintEffectNoSchedule,
 				TimeAdded: &nowTime,
 			})
-			err := r.Update(ctx, bmh)
+			err := r.Update(ctx, hw)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			r.Recorder.Eventf(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareNotSchedulableEventReason, "Hardware %s status is now HardwareNotSchedulable", bmh.Name)
+			r.Recorder.Eventf(hw, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareNotSchedulableEventReason, "Hardware %s status is now HardwareNotSchedulable", hw.GetName())
 			return ctrl.Result{}, nil
 		}
 	}
 
-	imageDriveValidCond := bmh.Status.GetCondition(baremetalv1alpha1.BareMetalHardwareConditionTypeImageDriveValid)
-	nicsValidCond := bmh.Status.GetCondition(baremetalv1alpha1.BareMetalHardwareConditionTypeNicsValid)
-	hardwareSetCond := bmh.Status.GetCondition(baremetalv1alpha1.BareMetalHardwareConditionTypeHardwareSet)
+	imageDriveValidCond := status.GetCondition(baremetalv1alpha1.BareMetalHardwareConditionTypeImageDriveValid)
+	nicsValidCond := status.GetCondition(baremetalv1alpha1.BareMetalHardwareConditionTypeNicsValid)
+	hardwareSetCond := status.GetCondition(baremetalv1alpha1.BareMetalHardwareConditionTypeHardwareSet)
 
 	// If conditions are met remove BareMetalHardwareTaintKeyNotReady taint
 	//  if they are not met add the taint
@@ -197,7 +206,7 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		(nicsValidCond != nil && nicsValidCond.Status == conditionv1.ConditionStatusTrue) &&
 		(hardwareSetCond != nil && hardwareSetCond.Status == conditionv1.ConditionStatusTrue) {
 		taintIndex := -1
-		for idx, t := range bmh.Spec.Taints {
+		for idx, t := range spec.Taints {
 			if t.Key == baremetalv1alpha1.BareMetalHardwareTaintKeyNotReady {
 				taintIndex = idx
 				break
@@ -205,19 +214,19 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		}
 
 		if taintIndex >= 0 {
-			bmh.Spec.Taints = append(bmh.Spec.Taints[:taintIndex], bmh.Spec.Taints[taintIndex+1:]...)
-			err := r.Update(ctx, bmh)
+			spec.Taints = append(spec.Taints[:taintIndex], spec.Taints[taintIndex+1:]...)
+			err := r.Update(ctx, hw)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			r.Recorder.Eventf(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareReadyEventReason, "Hardware %s status is now HardwareReady", bmh.Name)
+			r.Recorder.Eventf(hw, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareReadyEventReason, "Hardware %s status is now HardwareReady", hw.GetName())
 			return ctrl.Result{}, nil
 		}
 	} else {
 		hasTaint := false
 
-		for _, t := range bmh.Spec.Taints {
+		for _, t := range spec.Taints {
 			if t.Key == baremetalv1alpha1.BareMetalHardwareTaintKeyNotReady {
 				hasTaint = true
 				break
@@ -226,29 +235,29 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 
 		if hasTaint == false {
 			nowTime := metav1.NewTime(r.Clock.Now())
-			bmh.Spec.Taints = append(bmh.Spec.Taints, corev1.Taint{
+			spec.Taints = append(spec.Taints, corev1.Taint{
 				Key:       baremetalv1alpha1.BareMetalHardwareTaintKeyNotReady,
 				Effect:    corev1.TaintEffectNoSchedule,
 				TimeAdded: &nowTime,
 			})
-			err := r.Update(ctx, bmh)
+			err := r.Update(ctx, hw)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			r.Recorder.Eventf(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareNotReadyEventReason, "Hardware %s status is now HardwareNotReady", bmh.Name)
+			r.Recorder.Eventf(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareNotReadyEventReason, "Hardware %s status is now HardwareNotReady", hw.GetName())
 			return ctrl.Result{}, nil
 		}
 	}
 
-	if bmh.Status.InstanceRef != nil {
+	if status.InstanceRef != nil {
 		bmi := &baremetalv1alpha1.BareMetalInstance{}
-		err := r.Get(ctx, types.NamespacedName{Namespace: bmh.Status.InstanceRef.Namespace, Name: bmh.Status.InstanceRef.Name}, bmi)
+		err := r.Get(ctx, types.NamespacedName{Namespace: status.InstanceRef.Namespace, Name: status.InstanceRef.Name}, bmi)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
 				// bmi wasn't found so set instance ref to nil
-				bmh.Status.InstanceRef = nil
-				err := r.Status().Update(ctx, bmh)
+				status.InstanceRef = nil
+				err := r.Status().Update(ctx, hw)
 				if err != nil {
 					return ctrl.Result{}, err
 				}
@@ -258,12 +267,12 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 			}
 		}
 
-		if bmi.UID != bmh.Status.InstanceRef.UID {
+		if bmi.UID != status.InstanceRef.UID {
 			// bmi was found but doesn't match UID
 			// this means it's not our instance
 			// so lets remove the instance ref
-			bmh.Status.InstanceRef = nil
-			err := r.Status().Update(ctx, bmh)
+			status.InstanceRef = nil
+			err := r.Status().Update(ctx, hw)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
@@ -271,10 +280,10 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 		}
 	}
 
-	if bmh.Status.Hardware != nil {
+	if status.Hardware != nil {
 		if hardwareSetCond == nil || hardwareSetCond.Reason != baremetalv1alpha1.BareMetalHardwareHardwareIsSetConditionReason {
 			nowTime := metav1.NewTime(r.Clock.Now())
-			err := bmh.Status.SetCondition(&conditionv1.StatusCondition{
+			err := status.SetCondition(&conditionv1.StatusCondition{
 				Type:               baremetalv1alpha1.BareMetalHardwareConditionTypeHardwareSet,
 				Status:             conditionv1.ConditionStatusTrue,
 				LastTransitionTime: &nowTime,
@@ -284,24 +293,24 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 			if err != nil {
 				return ctrl.Result{}, err
 			}
-			err = r.Status().Update(ctx, bmh)
+			err = r.Status().Update(ctx, hw)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 			return ctrl.Result{}, nil
 		}
 
-		if len(bmh.Spec.NICS) > 0 {
+		if len(spec.NICS) > 0 {
 			foundAllNics := true
 
-			for _, nic := range bmh.Spec.NICS {
+			for _, nic := range spec.NICS {
 				if nic.Bond != nil {
 					foundAllBondNics := true
 
 					for _, bondNicName := range nic.Bond.Interfaces {
 						foundNic := false
 
-						for _, hardwareNic := range bmh.Status.Hardware.NICS {
+						for _, hardwareNic := range status.Hardware.NICS {
 							if hardwareNic.Name == bondNicName {
 								foundNic = true
 								break
@@ -321,7 +330,7 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 				} else {
 					foundNic := false
 
-					for _, hardwareNic := range bmh.Status.Hardware.NICS {
+					for _, hardwareNic := range status.Hardware.NICS {
 						if hardwareNic.Name == nic.Name {
 							foundNic = true
 							break
@@ -337,7 +346,7 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 			if foundAllNics {
 				if nicsValidCond == nil || nicsValidCond.Reason != baremetalv1alpha1.BareMetalHardwareValidNicsConditionReason {
 					nowTime := metav1.NewTime(r.Clock.Now())
-					err := bmh.Status.SetCondition(&conditionv1.StatusCondition{
+					err := status.SetCondition(&conditionv1.StatusCondition{
 						Type:               baremetalv1alpha1.BareMetalHardwareConditionTypeNicsValid,
 						Status:             conditionv1.ConditionStatusTrue,
 						LastTransitionTime: &nowTime,
@@ -347,17 +356,17 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 					if err != nil {
 						return ctrl.Result{}, err
 					}
-					err = r.Status().Update(ctx, bmh)
+					err = r.Status().Update(ctx, hw)
 					if err != nil {
 						return ctrl.Result{}, err
 					}
-					r.Recorder.Event(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareValidNicsConditionReason, "Nics are found in hardware nics list")
+					r.Recorder.Event(hw, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareValidNicsConditionReason, "Nics are found in hardware nics list")
 					return ctrl.Result{}, nil
 				}
 			} else {
 				if nicsValidCond == nil || nicsValidCond.Reason != baremetalv1alpha1.BareMetalHardwareInvalidNicsConditionReason {
 					nowTime := metav1.NewTime(r.Clock.Now())
-					err := bmh.Status.SetCondition(&conditionv1.StatusCondition{
+					err := status.SetCondition(&conditionv1.StatusCondition{
 						Type:               baremetalv1alpha1.BareMetalHardwareConditionTypeNicsValid,
 						Status:             conditionv1.ConditionStatusFalse,
 						LastTransitionTime: &nowTime,
@@ -367,18 +376,18 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 					if err != nil {
 						return ctrl.Result{}, err
 					}
-					err = r.Status().Update(ctx, bmh)
+					err = r.Status().Update(ctx, hw)
 					if err != nil {
 						return ctrl.Result{}, err
 					}
-					r.Recorder.Event(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareInvalidNicsConditionReason, "Could not find nics in hardware nics list")
+					r.Recorder.Event(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareInvalidNicsConditionReason, "Could not find nics in hardware nics list")
 					return ctrl.Result{}, nil
 				}
 			}
 		} else {
 			if nicsValidCond == nil || nicsValidCond.Reason != baremetalv1alpha1.BareMetalHardwareNicsAreNotSetConditionReason {
 				nowTime := metav1.NewTime(r.Clock.Now())
-				err := bmh.Status.SetCondition(&conditionv1.StatusCondition{
+				err := status.SetCondition(&conditionv1.StatusCondition{
 					Type:               baremetalv1alpha1.BareMetalHardwareConditionTypeNicsValid,
 					Status:             conditionv1.ConditionStatusFalse,
 					LastTransitionTime: &nowTime,
@@ -388,20 +397,20 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 				if err != nil {
 					return ctrl.Result{}, err
 				}
-				err = r.Status().Update(ctx, bmh)
+				err = r.Status().Update(ctx, hw)
 				if err != nil {
 					return ctrl.Result{}, err
 				}
-				r.Recorder.Event(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareNicsAreNotSetConditionReason, "NICS are not set")
+				r.Recorder.Event(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareNicsAreNotSetConditionReason, "NICS are not set")
 				return ctrl.Result{}, nil
 			}
 		}
 
-		if len(bmh.Spec.ImageDrive) > 0 {
+		if len(spec.ImageDrive) > 0 {
 			foundImageDrive := false
 
-			for _, storage := range bmh.Status.Hardware.Storage {
-				for storage.Name == bmh.Spec.ImageDrive {
+			for _, storage := range status.Hardware.Storage {
+				for storage.Name == spec.ImageDrive {
 					foundImageDrive = true
 					break
 				}
@@ -410,7 +419,7 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 			if foundImageDrive {
 				if imageDriveValidCond == nil || imageDriveValidCond.Reason != baremetalv1alpha1.BareMetalHardwareValidImageDriveConditionReason {
 					nowTime := metav1.NewTime(r.Clock.Now())
-					err := bmh.Status.SetCondition(&conditionv1.StatusCondition{
+					err := status.SetCondition(&conditionv1.StatusCondition{
 						Type:               baremetalv1alpha1.BareMetalHardwareConditionTypeImageDriveValid,
 						Status:             conditionv1.ConditionStatusTrue,
 						LastTransitionTime: &nowTime,
@@ -420,17 +429,17 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 					if err != nil {
 						return ctrl.Result{}, err
 					}
-					err = r.Status().Update(ctx, bmh)
+					err = r.Status().Update(ctx, hw)
 					if err != nil {
 						return ctrl.Result{}, err
 					}
-					r.Recorder.Event(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareValidImageDriveConditionReason, "Found image drive in hardware storage list")
+					r.Recorder.Event(hw, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareValidImageDriveConditionReason, "Found image drive in hardware storage list")
 					return ctrl.Result{}, nil
 				}
 			} else {
 				if imageDriveValidCond == nil || imageDriveValidCond.Reason != baremetalv1alpha1.BareMetalHardwareInvalidImageDriveConditionReason {
 					nowTime := metav1.NewTime(r.Clock.Now())
-					err := bmh.Status.SetCondition(&conditionv1.StatusCondition{
+					err := status.SetCondition(&conditionv1.StatusCondition{
 						Type:               baremetalv1alpha1.BareMetalHardwareConditionTypeImageDriveValid,
 						Status:             conditionv1.ConditionStatusFalse,
 						LastTransitionTime: &nowTime,
@@ -440,18 +449,18 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 					if err != nil {
 						return ctrl.Result{}, err
 					}
-					err = r.Status().Update(ctx, bmh)
+					err = r.Status().Update(ctx, hw)
 					if err != nil {
 						return ctrl.Result{}, err
 					}
-					r.Recorder.Event(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareInvalidImageDriveConditionReason, "Could not find image drive in hardware storage list")
+					r.Recorder.Event(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareInvalidImageDriveConditionReason, "Could not find image drive in hardware storage list")
 					return ctrl.Result{}, nil
 				}
 			}
 		} else {
 			if imageDriveValidCond == nil || imageDriveValidCond.Reason != baremetalv1alpha1.BareMetalHardwareImageDriveIsNotSetConditionReason {
 				nowTime := metav1.NewTime(r.Clock.Now())
-				err := bmh.Status.SetCondition(&conditionv1.StatusCondition{
+				err := status.SetCondition(&conditionv1.StatusCondition{
 					Type:               baremetalv1alpha1.BareMetalHardwareConditionTypeImageDriveValid,
 					Status:             conditionv1.ConditionStatusFalse,
 					LastTransitionTime: &nowTime,
@@ -461,18 +470,18 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 				if err != nil {
 					return ctrl.Result{}, err
 				}
-				err = r.Status().Update(ctx, bmh)
+				err = r.Status().Update(ctx, hw)
 				if err != nil {
 					return ctrl.Result{}, err
 				}
-				r.Recorder.Event(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareImageDriveIsNotSetConditionReason, "Image Drive is not set in the spec")
+				r.Recorder.Event(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareImageDriveIsNotSetConditionReason, "Image Drive is not set in the spec")
 				return ctrl.Result{}, nil
 			}
 		}
 	} else {
 		if hardwareSetCond == nil || hardwareSetCond.Reason != baremetalv1alpha1.BareMetalHardwareHardwareIsNotSetConditionReason {
 			nowTime := metav1.NewTime(r.Clock.Now())
-			err := bmh.Status.SetCondition(&conditionv1.StatusCondition{
+			err := status.SetCondition(&conditionv1.StatusCondition{
 				Type:               baremetalv1alpha1.BareMetalHardwareConditionTypeHardwareSet,
 				Status:             conditionv1.ConditionStatusFalse,
 				LastTransitionTime: &nowTime,
@@ -482,51 +491,51 @@ func (r *BareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result,
 			if err != nil {
 				return ctrl.Result{}, err
 			}
-			err = r.Status().Update(ctx, bmh)
+			err = r.Status().Update(ctx, hw)
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			r.Recorder.Event(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareHardwareIsNotSetConditionReason, "Hardware information is not set")
+			r.Recorder.Event(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareHardwareIsNotSetConditionReason, "Hardware information is not set")
 			return ctrl.Result{}, nil
 		}
 
 		var bmd *baremetalv1alpha1.BareMetalDiscovery
 
 		discoveryList := &baremetalv1alpha1.BareMetalDiscoveryList{}
-		err := r.List(context.Background(), discoveryList, client.MatchingFields{"spec.systemUUID": string(bmh.Spec.SystemUUID)})
+		err := r.List(context.Background(), discoveryList, client.MatchingFields{"spec.systemUUID": string(spec.SystemUUID)})
 		if err != nil {
 			return ctrl.Result{}, err
 		}
 
 		switch len(discoveryList.Items) {
 		case 0:
-			r.Recorder.Eventf(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareDiscoveryNotFoundEventReason, "Could not find the discovery resource for the systemUUID of %s", bmh.Spec.SystemUUID)
+			r.Recorder.Eventf(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareDiscoveryNotFoundEventReason, "Could not find the discovery resource for the systemUUID of %s", spec.SystemUUID)
 			return ctrl.Result{Requeue: true}, nil
 		case 1:
 			bmd = &discoveryList.Items[0]
 			break
 		default:
 			// we found multiple discoveries something messed up
-			r.Recorder.Eventf(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareManyDiscoveryFoundEventReason, "Found multiple discovery resources for the systemUUID of %s", bmh.Spec.SystemUUID)
+			r.Recorder.Eventf(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareManyDiscoveryFoundEventReason, "Found multiple discovery resources for the systemUUID of %s", spec.SystemUUID)
 			return ctrl.Result{Requeue: true}, nil
 		}
 
 		if bmd.Status.Hardware == nil {
-			r.Recorder.Eventf(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareDiscoveryNoHardwareEventReason, "Discovery for the systemUUID of %s does not have any hardware set yet", bmh.Spec.SystemUUID)
+			r.Recorder.Eventf(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareDiscoveryNoHardwareEventReason, "Discovery for the systemUUID of %s does not have any hardware set yet", spec.SystemUUID)
 			return ctrl.Result{Requeue: true}, nil
 		}
 
 		// TODO: discovery hardware may be nil due to "secure" discovery
 		//  check if it's nil and event and requeue if it is
 
-		bmh.Status.Hardware = bmd.Status.Hardware.DeepCopy()
-		err = r.Status().Update(ctx, bmh)
+		status.Hardware = bmd.Status.Hardware.DeepCopy()
+		err = r.Status().Update(ctx, hw)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
 
-		r.Recorder.Eventf(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareDiscoveryFoundEventReason, "Found discovery resource for the systemUUID of %s", bmh.Spec.SystemUUID)
+		r.Recorder.Eventf(hw, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareDiscoveryFoundEventReason, "Found discovery resource for the systemUUID of %s", spec.SystemUUID)
 		return ctrl.Result{}, nil
 	}
 
@@ -548,8 +557,8 @@ func (r *BareMetalHardwareReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			bmi := a.Object.(*baremetalv1alpha1.BareMetalInstance)
 			var req []reconcile.Request
 
-			// TODO: this should be changed once we have CBMH
-			if len(bmi.Status.HardwareName) > 0 {
+			// instances on cluster hardware are handled by the ClusterBareMetalHardwareReconciler
+			if len(bmi.Status.HardwareName) > 0 && bmi.Status.ClusterHardware == false {
 				req = append(req, reconcile.Request{NamespacedName: types.NamespacedName{
 					Namespace: bmi.Namespace,
 					Name:      bmi.Status.HardwareName,
@@ -0,0 +1,196 @@
+package baremetalinstance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// Evictor deletes instances that are on hardware with NoExecute taints they don't tolerate
+// instances that tolerate the taints for tolerationSeconds are deleted once that time has passed
+type Evictor struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Clock    clock.Clock
+	Recorder record.EventRecorder
+}
+
+func (r *Evictor) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("baremetalinstance", req.NamespacedName)
+
+	bmi := &baremetalv1alpha1.BareMetalInstance{}
+	if err := r.Client.Get(ctx, req.NamespacedName, bmi); err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			log.Error(err, "failed to retrieve BareMetalInstance resource")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// already being deleted
+	if bmi.DeletionTimestamp.IsZero() == false {
+		return ctrl.Result{}, nil
+	}
+
+	// not on any hardware
+	if len(bmi.Status.HardwareName) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	bmh, err := getHardware(ctx, r.Client, bmi)
+	if err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			log.Error(err, "failed to retrieve hardware resource")
+		}
+		return ctrl.Result{}, err
+	}
+
+	now := r.Clock.Now()
+	evictTaint, evictTime := noExecuteEviction(bmh.GetHardwareSpec().Taints, bmi.Spec.Tolerations, now)
+	if evictTaint == nil {
+		return ctrl.Result{}, nil
+	}
+
+	if evictTime != nil && evictTime.After(now) {
+		log.Info("instance tolerates NoExecute taint for a limited time", "taint", evictTaint.ToString(), "evictTime", evictTime)
+		return ctrl.Result{RequeueAfter: evictTime.Sub(now)}, nil
+	}
+
+	var message string
+	if evictTime == nil {
+		message = fmt.Sprintf("Hardware %s has the taint %s that the instance doesn't tolerate", bmh.GetName(), evictTaint.ToString())
+	} else {
+		message = fmt.Sprintf("Hardware %s has the taint %s and the instance's toleration seconds have passed", bmh.GetName(), evictTaint.ToString())
+	}
+
+	err = r.Delete(ctx, bmi)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Eventf(bmi, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceEvictedEventReason, "Evicting instance: %s", message)
+	r.Recorder.Eventf(bmh, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceEvictedEventReason, "Evicting instance %s/%s: %s", bmi.Namespace, bmi.Name, message)
+
+	return ctrl.Result{}, nil
+}
+
+// noExecuteEviction returns the NoExecute taint that causes the instance to be evicted first and when it should be evicted
+// the time is nil when the taint isn't tolerated at all and the taint is nil when the instance tolerates all the taints forever
+func noExecuteEviction(taints []corev1.Taint, tolerations []corev1.Toleration, now time.Time) (*corev1.Taint, *time.Time) {
+	var evictTaint *corev1.Taint
+	var evictTime *time.Time
+
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+
+		tolerated := false
+		var tolerationSeconds *int64
+		for i := range tolerations {
+			toleration := &tolerations[i]
+			if toleration.ToleratesTaint(taint) == false {
+				continue
+			}
+			tolerated = true
+
+			if toleration.TolerationSeconds != nil && (tolerationSeconds == nil || *toleration.TolerationSeconds < *tolerationSeconds) {
+				tolerationSeconds = toleration.TolerationSeconds
+			}
+		}
+
+		if tolerated == false {
+			// untolerated taints evict right away
+			return taint, nil
+		}
+
+		// the shortest toleration wins, only when all the matching tolerations have no seconds is it tolerated forever
+		if tolerationSeconds == nil {
+			continue
+		}
+		seconds := *tolerationSeconds
+		if seconds < 0 {
+			seconds = 0
+		}
+
+		// the webhook sets when NoExecute taints are added, without it the toleration starts now
+		var added time.Time
+		if taint.TimeAdded != nil {
+			added = taint.TimeAdded.Time
+		} else {
+			added = now
+		}
+
+		taintEvictTime := added.Add(time.Duration(seconds) * time.Second)
+		if evictTime == nil || taintEvictTime.Before(*evictTime) {
+			evictTaint = taint
+			evictTime = &taintEvictTime
+		}
+	}
+
+	return evictTaint, evictTime
+}
+
+// helper method to map hardware to the instances scheduled onto it
+func (r *Evictor) hardwareInstances(a handler.MapObject) []reconcile.Request {
+	hw := a.Object.(baremetalv1alpha1.Hardware)
+	req := hardwareInstanceRef(a)
+	instanceRef := hw.GetHardwareStatus().InstanceRef
+
+	// cluster hardware doesn't have a namespace so instances in all namespaces are listed
+	clusterHardware := len(hw.GetNamespace()) == 0
+
+	// instances that are scheduled but not provisioned yet don't have an instance ref
+	// the field index is added by the BareMetalInstanceScheduler
+	bmiList := &baremetalv1alpha1.BareMetalInstanceList{}
+	err := r.List(context.Background(), bmiList, client.InNamespace(hw.GetNamespace()), client.MatchingFields{"status.hardwareName": hw.GetName()})
+	if err != nil {
+		r.Log.Error(err, "failed to list BareMetalInstances for hardware", "hardware", hw.GetName())
+		return req
+	}
+	for _, bmi := range bmiList.Items {
+		if bmi.Status.ClusterHardware != clusterHardware {
+			continue
+		}
+		if instanceRef != nil && instanceRef.Namespace == bmi.Namespace && instanceRef.Name == bmi.Name {
+			continue
+		}
+		req = append(req, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: bmi.Namespace,
+			Name:      bmi.Name,
+		}})
+	}
+
+	return req
+}
+
+func (r *Evictor) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("BareMetalInstanceEvictor").
+		For(&baremetalv1alpha1.BareMetalInstance{}).
+		// This will cause BMH and CBMH taint changes to cause a reconcile of the instances scheduled onto it
+		Watches(&source.Kind{Type: &baremetalv1alpha1.BareMetalHardware{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.hardwareInstances)}).
+		Watches(&source.Kind{Type: &baremetalv1alpha1.ClusterBareMetalHardware{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.hardwareInstances)}).
+		Complete(r)
+}
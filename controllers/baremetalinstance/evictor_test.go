@@ -0,0 +1,135 @@
+package baremetalinstance
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func noExecuteTaint(key string, added *time.Time) corev1.Taint {
+	taint := corev1.Taint{Key: key, Value: "true", Effect: corev1.TaintEffectNoExecute}
+	if added != nil {
+		taint.TimeAdded = &metav1.Time{Time: *added}
+	}
+
+	return taint
+}
+
+func toleration(key string, seconds *int64) corev1.Toleration {
+	return corev1.Toleration{Key: key, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: seconds}
+}
+
+func seconds(s int64) *int64 {
+	return &s
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestNoExecuteEviction(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	added := now.Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		taints      []corev1.Taint
+		tolerations []corev1.Toleration
+		taint       string
+		evictTime   *time.Time
+	}{
+		{
+			name: "no taints",
+		},
+		{
+			name:   "only NoExecute taints evict",
+			taints: []corev1.Taint{{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule}},
+		},
+		{
+			name:   "no toleration",
+			taints: []corev1.Taint{noExecuteTaint("maintenance", &added)},
+			taint:  "maintenance",
+		},
+		{
+			name:        "toleration for another taint",
+			taints:      []corev1.Taint{noExecuteTaint("maintenance", &added)},
+			tolerations: []corev1.Toleration{toleration("unreachable", nil)},
+			taint:       "maintenance",
+		},
+		{
+			name:        "toleration with nil seconds",
+			taints:      []corev1.Taint{noExecuteTaint("maintenance", &added)},
+			tolerations: []corev1.Toleration{toleration("maintenance", nil)},
+		},
+		{
+			name:        "toleration with seconds",
+			taints:      []corev1.Taint{noExecuteTaint("maintenance", &added)},
+			tolerations: []corev1.Toleration{toleration("maintenance", seconds(300))},
+			taint:       "maintenance",
+			evictTime:   timePtr(added.Add(300 * time.Second)),
+		},
+		{
+			name:        "toleration with seconds without time added",
+			taints:      []corev1.Taint{noExecuteTaint("maintenance", nil)},
+			tolerations: []corev1.Toleration{toleration("maintenance", seconds(300))},
+			taint:       "maintenance",
+			evictTime:   timePtr(now.Add(300 * time.Second)),
+		},
+		{
+			name:        "negative seconds evict right away",
+			taints:      []corev1.Taint{noExecuteTaint("maintenance", &added)},
+			tolerations: []corev1.Toleration{toleration("maintenance", seconds(-10))},
+			taint:       "maintenance",
+			evictTime:   &added,
+		},
+		{
+			name:        "shortest matching toleration",
+			taints:      []corev1.Taint{noExecuteTaint("maintenance", &added)},
+			tolerations: []corev1.Toleration{toleration("maintenance", nil), toleration("maintenance", seconds(600)), toleration("", seconds(60))},
+			taint:       "maintenance",
+			evictTime:   timePtr(added.Add(60 * time.Second)),
+		},
+		{
+			name:        "several taints evict at the earliest time",
+			taints:      []corev1.Taint{noExecuteTaint("maintenance", &added), noExecuteTaint("unreachable", &now), noExecuteTaint("degraded", &added)},
+			tolerations: []corev1.Toleration{toleration("maintenance", seconds(600)), toleration("unreachable", seconds(60)), toleration("degraded", nil)},
+			taint:       "unreachable",
+			evictTime:   timePtr(now.Add(60 * time.Second)),
+		},
+		{
+			name:        "several taints with one not tolerated",
+			taints:      []corev1.Taint{noExecuteTaint("maintenance", &added), noExecuteTaint("unreachable", &added)},
+			tolerations: []corev1.Toleration{toleration("maintenance", seconds(60))},
+			taint:       "unreachable",
+		},
+		{
+			name:        "several taints all tolerated forever",
+			taints:      []corev1.Taint{noExecuteTaint("maintenance", &added), noExecuteTaint("unreachable", &added)},
+			tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			taint, evictTime := noExecuteEviction(test.taints, test.tolerations, now)
+
+			if len(test.taint) == 0 {
+				if taint != nil {
+					t.Fatalf("expected no eviction got taint %s", taint.ToString())
+				}
+			} else if taint == nil || taint.Key != test.taint {
+				t.Fatalf("expected taint %s got %v", test.taint, taint)
+			}
+
+			if test.evictTime == nil {
+				if evictTime != nil {
+					t.Errorf("expected no evict time got %v", evictTime)
+				}
+			} else if evictTime == nil || evictTime.Equal(*test.evictTime) == false {
+				t.Errorf("expected evict time %v got %v", test.evictTime, evictTime)
+			}
+		})
+	}
+}
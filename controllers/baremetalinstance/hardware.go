@@ -0,0 +1,46 @@
+package baremetalinstance
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// getHardware returns the BareMetalHardware or ClusterBareMetalHardware the instance is assigned to
+func getHardware(ctx context.Context, c client.Client, bmi *baremetalv1alpha1.BareMetalInstance) (baremetalv1alpha1.Hardware, error) {
+	if bmi.Status.ClusterHardware {
+		cbmh := &baremetalv1alpha1.ClusterBareMetalHardware{}
+		err := c.Get(ctx, types.NamespacedName{Name: bmi.Status.HardwareName}, cbmh)
+		if err != nil {
+			return nil, err
+		}
+		return cbmh, nil
+	}
+
+	bmh := &baremetalv1alpha1.BareMetalHardware{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: bmi.Namespace, Name: bmi.Status.HardwareName}, bmh)
+	if err != nil {
+		return nil, err
+	}
+	return bmh, nil
+}
+
+// hardwareInstanceRef maps hardware to the instance in its instance ref
+func hardwareInstanceRef(a handler.MapObject) []reconcile.Request {
+	hw := a.Object.(baremetalv1alpha1.Hardware)
+	var req []reconcile.Request
+
+	if instanceRef := hw.GetHardwareStatus().InstanceRef; instanceRef != nil {
+		req = append(req, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: instanceRef.Namespace,
+			Name:      instanceRef.Name,
+		}})
+	}
+
+	return req
+}
@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,13 +18,11 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
@@ -122,8 +121,7 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			return ctrl.Result{}, nil
 		}
 
-		bmh := &baremetalv1alpha1.BareMetalHardware{}
-		err := r.Get(ctx, types.NamespacedName{Namespace: bmi.Namespace, Name: bmi.Status.HardwareName}, bmh)
+		bmh, err := getHardware(ctx, r.Client, bmi)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
 				bmh = nil
@@ -136,10 +134,10 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		// or
 		// bmh instanceRef is not bmi (how did this happen?)
 		// so we can't (or shouldn't) actually clean so go straight to terminating
-		if bmh == nil || bmh.Status.InstanceRef != nil && bmh.Status.InstanceRef.UID != bmi.UID {
+		if bmh == nil || bmh.GetHardwareStatus().InstanceRef != nil && bmh.GetHardwareStatus().InstanceRef.UID != bmi.UID {
 			if bmh == nil {
 				r.Recorder.Eventf(bmi, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceNotCleanedEventReason, "Instance cannot be cleaned because the BareMetalHardware %s does not exist anymore", bmi.Status.HardwareName)
-			} else if bmh.Status.InstanceRef.UID != bmi.UID {
+			} else if bmh.GetHardwareStatus().InstanceRef.UID != bmi.UID {
 				r.Recorder.Eventf(bmi, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceNotCleanedEventReason, "Instance cannot be cleaned because the BareMetalHardware %s thinks another instance is provisioned", bmi.Status.HardwareName)
 			}
 
@@ -152,7 +150,7 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		}
 
 		// bmh instance ref is nil so we are done cleaning
-		if bmh.Status.InstanceRef == nil {
+		if bmh.GetHardwareStatus().InstanceRef == nil {
 			nowTime := metav1.NewTime(r.Clock.Now())
 			err := bmi.Status.SetCondition(&conditionv1.StatusCondition{
 				Type:               baremetalv1alpha1.BareMetalHardwareConditionTypeInstanceCleaned,
@@ -166,7 +164,7 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			if err != nil {
 				return ctrl.Result{}, err
 			}
-			r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceCleanedEventReason, "Instance was cleaned off of BareMetalHardware %s", bmh.Name)
+			r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceCleanedEventReason, "Instance was cleaned off of BareMetalHardware %s", bmh.GetName())
 			return ctrl.Result{}, nil
 		}
 
@@ -188,7 +186,7 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		}
 
 		if agentStatus == nil {
-			r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceCleaningEventReason, "Cleaning the instance off of BareMetalHardware %s", bmh.Name)
+			r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceCleaningEventReason, "Cleaning the instance off of BareMetalHardware %s", bmh.GetName())
 			r.Recorder.Eventf(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareCleaningEventReason, "Cleaning the BareMetalInstance %s off of the hardware", bmi.Name)
 
 			// tell agent to clean
@@ -258,12 +256,12 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 				// we are done cleaning so set instanceRef to nil
 				// this will cause a reconcile due to the old object not being nil
-				bmh.Status.InstanceRef = nil
+				bmh.GetHardwareStatus().InstanceRef = nil
 				err = r.Status().Update(ctx, bmh)
 				if err != nil {
 					return ctrl.Result{}, err
 				}
-				r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceCleanedEventReason, "Cleaned the instance off of BareMetalHardware %s", bmh.Name)
+				r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceCleanedEventReason, "Cleaned the instance off of BareMetalHardware %s", bmh.GetName())
 				r.Recorder.Eventf(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareCleanedEventReason, "Cleaned the BareMetalInstance %s off of the hardware", bmi.Name)
 				return ctrl.Result{}, nil
 			}
@@ -275,8 +273,7 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
-	bmh := &baremetalv1alpha1.BareMetalHardware{}
-	err := r.Get(ctx, types.NamespacedName{Namespace: bmi.Namespace, Name: bmi.Status.HardwareName}, bmh)
+	bmh, err := getHardware(ctx, r.Client, bmi)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			bmh = nil
@@ -290,17 +287,17 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
-	if bmh.DeletionTimestamp.IsZero() == false {
+	if bmh.GetDeletionTimestamp().IsZero() == false {
 		r.Recorder.Eventf(bmi, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceProvisioningEventReason, "Cannot provision onto BareMetalHardware %s when it is deleting", bmi.Status.HardwareName)
 		return ctrl.Result{}, nil
 	}
 
-	if bmh.Status.InstanceRef != nil && bmh.Status.InstanceRef.UID != bmi.UID {
+	if bmh.GetHardwareStatus().InstanceRef != nil && bmh.GetHardwareStatus().InstanceRef.UID != bmi.UID {
 		r.Recorder.Eventf(bmi, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceProvisioningEventReason, "BareMetalHardware %s thinks another instance is provisioned on it", bmi.Status.HardwareName)
 		return ctrl.Result{}, nil
 	}
 
-	for _, t := range bmh.Spec.Taints {
+	for _, t := range bmh.GetHardwareSpec().Taints {
 		if t.Key == baremetalv1alpha1.BareMetalHardwareTaintKeyNotReady {
 			r.Recorder.Eventf(bmi, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceProvisioningEventReason, "Cannot provision onto BareMetalHardware %s when it is not ready", bmi.Status.HardwareName)
 			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
@@ -308,8 +305,8 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 
 	// if bmh instance ref is nil set it to bmi
-	if bmh.Status.InstanceRef == nil {
-		bmh.Status.InstanceRef = &baremetalv1alpha1.BareMetalHardwareStatusInstanceRef{
+	if bmh.GetHardwareStatus().InstanceRef == nil {
+		bmh.GetHardwareStatus().InstanceRef = &baremetalv1alpha1.BareMetalHardwareStatusInstanceRef{
 			Name:      bmi.Name,
 			Namespace: bmi.Namespace,
 			UID:       bmi.UID,
@@ -343,98 +340,109 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	if networkedCond.Status == conditionv1.ConditionStatusFalse {
 		allAddressed := true
 	nicLoop:
-		for _, nic := range bmh.Spec.NICS {
+		for _, nic := range bmh.GetHardwareSpec().NICS {
 			bmeList := &baremetalv1alpha1.BareMetalEndpointList{}
 			err = r.List(ctx, bmeList, client.MatchingLabels{baremetalv1alpha1.BareMetalEndpointInstanceLabel: bmi.Name, baremetalv1alpha1.BareMetalEndpointNICLabel: nic.Name})
 			if err != nil {
 				return ctrl.Result{}, err
 			}
 
-			// we only care about bme's owned by us
-			ourBMEs := make([]baremetalv1alpha1.BareMetalEndpoint, 0)
-			for _, bme := range bmeList.Items {
-				ownedByUs := false
+			// a nic has an endpoint for each network it is attached to
+			for _, networkRef := range nic.NetworkRefs() {
+				// we only care about bme's owned by us for this network
+				ourBMEs := make([]baremetalv1alpha1.BareMetalEndpoint, 0)
+				for _, bme := range bmeList.Items {
+					if bme.Spec.NetworkRef != networkRef {
+						continue
+					}
+
+					ownedByUs := false
 
-				for _, ownerRef := range bme.OwnerReferences {
-					if ownerRef.UID == bmi.UID {
-						ownedByUs = true
+					for _, ownerRef := range bme.OwnerReferences {
+						if ownerRef.UID == bmi.UID {
+							ownedByUs = true
+						}
 					}
-				}
 
-				if ownedByUs == true {
-					ourBMEs = append(ourBMEs, bme)
+					if ownedByUs == true {
+						ourBMEs = append(ourBMEs, bme)
+					}
 				}
-			}
 
-			switch len(ourBMEs) {
-			case 0:
-				allAddressed = false
-				bme := &baremetalv1alpha1.BareMetalEndpoint{
-					ObjectMeta: metav1.ObjectMeta{
-						GenerateName: bmi.Name,
-						Namespace:    bmi.Namespace,
-						Labels: map[string]string{
-							baremetalv1alpha1.BareMetalEndpointInstanceLabel: bmi.Name,
-							baremetalv1alpha1.BareMetalEndpointNICLabel:      nic.Name,
-						},
-						OwnerReferences: []metav1.OwnerReference{
-							{
-								APIVersion:         bmi.APIVersion,
-								Kind:               bmi.Kind,
-								Name:               bmi.Name,
-								UID:                bmi.UID,
-								Controller:         func(b bool) *bool { return &b }(true),
-								BlockOwnerDeletion: func(b bool) *bool { return &b }(false),
+				switch len(ourBMEs) {
+				case 0:
+					allAddressed = false
+					bme := &baremetalv1alpha1.BareMetalEndpoint{
+						ObjectMeta: metav1.ObjectMeta{
+							GenerateName: bmi.Name,
+							Namespace:    bmi.Namespace,
+							Labels: map[string]string{
+								baremetalv1alpha1.BareMetalEndpointInstanceLabel: bmi.Name,
+								baremetalv1alpha1.BareMetalEndpointNICLabel:      nic.Name,
+							},
+							OwnerReferences: []metav1.OwnerReference{
+								{
+									APIVersion:         bmi.APIVersion,
+									Kind:               bmi.Kind,
+									Name:               bmi.Name,
+									UID:                bmi.UID,
+									Controller:         func(b bool) *bool { return &b }(true),
+									BlockOwnerDeletion: func(b bool) *bool { return &b }(false),
+								},
 							},
 						},
-					},
-					Spec: baremetalv1alpha1.BareMetalEndpointSpec{
-						Primary:    nic.Primary,
-						NetworkRef: nic.NetworkRef,
-					},
-				}
+						Spec: baremetalv1alpha1.BareMetalEndpointSpec{
+							Primary:     nic.Primary,
+							NetworkRef:  networkRef,
+							RequestedIP: nic.RequestedIP(networkRef),
+						},
+					}
 
-				// get mac address
-				var macs []string
-				for _, interf := range bmh.Status.Hardware.NICS {
-					if nic.Bond == nil {
-						if interf.Name == nic.Name {
-							macs = append(macs, interf.MAC)
-							break
-						}
-					} else {
-						for _, bondInterf := range nic.Bond.Interfaces {
-							if interf.Name == bondInterf {
+					// get mac address
+					var macs []string
+					for _, interf := range bmh.GetHardwareStatus().Hardware.NICS {
+						if nic.Bond == nil {
+							if interf.Name == nic.Name {
 								macs = append(macs, interf.MAC)
+								break
+							}
+						} else {
+							for _, bondInterf := range nic.Bond.Interfaces {
+								if interf.Name == bondInterf {
+									macs = append(macs, interf.MAC)
+								}
 							}
 						}
 					}
-				}
-				// Set mac addresses
-				bme.Spec.MAC = macs[0]
-				if nic.Bond != nil {
-					bme.Spec.Bond = &baremetalv1alpha1.BareMetalEndpointBond{
-						Mode: nic.Bond.Mode,
-						MACS: macs,
+					// Set mac addresses
+					bme.Spec.MAC = macs[0]
+					if nic.Bond != nil {
+						bme.Spec.Bond = &baremetalv1alpha1.BareMetalEndpointBond{
+							Mode:           nic.Bond.Mode,
+							MACS:           macs,
+							MiiMon:         nic.Bond.MiiMon,
+							XmitHashPolicy: nic.Bond.XmitHashPolicy,
+							LACPRate:       nic.Bond.LACPRate,
+						}
 					}
-				}
 
-				err := r.Create(ctx, bme)
-				if err != nil {
-					return ctrl.Result{}, err
-				}
-				break nicLoop
-			case 1:
-				bme := ourBMEs[0]
-				if bme.Status.Phase != baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressed {
-					allAddressed = false
+					err := r.Create(ctx, bme)
+					if err != nil {
+						return ctrl.Result{}, err
+					}
 					break nicLoop
+				case 1:
+					bme := ourBMEs[0]
+					if bme.Status.Phase != baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressed {
+						allAddressed = false
+						break nicLoop
+					}
+					break
+				default:
+					allAddressed = false
+					r.Recorder.Eventf(bmi, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceNetworkingEventReason, "Found multiple BareMetalEndpoints for nic %s on network %s, this shouldn't happen", nic.Name, networkRef.Name)
+					return ctrl.Result{}, nil
 				}
-				break
-			default:
-				allAddressed = false
-				r.Recorder.Eventf(bmi, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceNetworkingEventReason, "Found multiple BareMetalEndpoints for nic %s, this shouldn't happen", nic.Name)
-				return ctrl.Result{}, nil
 			}
 		}
 
@@ -528,6 +536,18 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		networkLinks := make([]NetworkDataLink, 0)
 		networks := make([]NetworkDataNetwork, 0)
 
+		// a nic may have multiple endpoints (i.e ipv4 and ipv6)
+		// but the link should only be configured once
+		configuredLinks := make(map[string]int)
+
+		// sort so the network data is stable and the primary nic is first
+		sort.SliceStable(bmeList.Items, func(i, j int) bool {
+			if bmeList.Items[i].Spec.Primary != bmeList.Items[j].Spec.Primary {
+				return bmeList.Items[i].Spec.Primary
+			}
+			return bmeList.Items[i].Labels[baremetalv1alpha1.BareMetalEndpointNICLabel] < bmeList.Items[j].Labels[baremetalv1alpha1.BareMetalEndpointNICLabel]
+		})
+
 		for _, bme := range bmeList.Items {
 			ownedByUs := false
 
@@ -543,32 +563,70 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 					continue
 				}
 
-				link := NetworkDataLink{
-					ID:  linkName,
-					MAC: bme.Spec.MAC,
+				if bme.Status.Address == nil {
+					continue
 				}
 
-				if bme.Spec.Bond != nil {
-					link.Type = "bond"
-					link.BondMode = string(bme.Spec.Bond.Mode)
-					link.BondMiiMon = 100
-					link.BondLinks = []string{}
-
-					for i, bondMAC := range bme.Spec.Bond.MACS {
-						bondLinkName := fmt.Sprintf("%s-bond-%d", linkName, i)
-						link.BondLinks = append(link.BondLinks, bondLinkName)
-
-						networkLinks = append(networkLinks, NetworkDataLink{
-							ID:   bondLinkName,
-							MAC:  bondMAC,
-							Type: "phy",
-						})
+				if linkIndex, ok := configuredLinks[linkName]; ok == false {
+					link := NetworkDataLink{
+						ID:  linkName,
+						MAC: bme.Spec.MAC,
+						MTU: bme.Status.Address.MTU,
+					}
+
+					if bme.Spec.Bond != nil {
+						link.Type = "bond"
+						link.BondMode = string(bme.Spec.Bond.Mode)
+						link.BondMiiMon = baremetalv1alpha1.BondDefaultMiiMon
+						if bme.Spec.Bond.MiiMon != nil {
+							link.BondMiiMon = *bme.Spec.Bond.MiiMon
+						}
+						link.BondXmitHashPolicy = string(bme.Spec.Bond.XmitHashPolicy)
+						link.BondLACPRate = string(bme.Spec.Bond.LACPRate)
+						link.BondLinks = []string{}
+
+						for i, bondMAC := range bme.Spec.Bond.MACS {
+							bondLinkName := fmt.Sprintf("%s-bond-%d", linkName, i)
+							link.BondLinks = append(link.BondLinks, bondLinkName)
+
+							networkLinks = append(networkLinks, NetworkDataLink{
+								ID:   bondLinkName,
+								MAC:  bondMAC,
+								Type: "phy",
+								MTU:  link.MTU,
+							})
+						}
+					} else {
+						link.Type = "phy"
+					}
+
+					configuredLinks[linkName] = len(networkLinks)
+					networkLinks = append(networkLinks, link)
+				} else if bme.Status.Address.MTU > networkLinks[linkIndex].MTU {
+					// a link only has one mtu so use the largest one of the networks on it
+					networkLinks[linkIndex].MTU = bme.Status.Address.MTU
+					for _, bondLinkName := range networkLinks[linkIndex].BondLinks {
+						for i := range networkLinks {
+							if networkLinks[i].ID == bondLinkName {
+								networkLinks[i].MTU = bme.Status.Address.MTU
+							}
+						}
 					}
-				} else {
-					link.Type = "phy"
 				}
 
-				networkLinks = append(networkLinks, link)
+				// dhcp addresses are configured by the dhcp server so only the type is needed
+				if bme.Status.Address.DHCP {
+					networkType := "ipv4_dhcp"
+					if bme.Status.Address.IPFamily == baremetalv1alpha1.IPFamilyIPv6 {
+						networkType = "ipv6_dhcp"
+					}
+
+					networks = append(networks, NetworkDataNetwork{
+						Link: linkName,
+						Type: networkType,
+					})
+					continue
+				}
 
 				_, cidrNetwork, err := net.ParseCIDR(bme.Status.Address.CIDR)
 				if err != nil {
@@ -593,6 +651,20 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 					network.Search = bme.Status.Address.Search
 				}
 
+				for _, route := range bme.Status.Address.Routes {
+					_, routeNetwork, err := net.ParseCIDR(route.Destination)
+					if err != nil {
+						return ctrl.Result{}, err
+					}
+
+					network.Routes = append(network.Routes, NetworkDataRoute{
+						Network: routeNetwork.IP.String(),
+						Netmask: net.IP(routeNetwork.Mask).String(),
+						Gateway: route.Gateway,
+						Metric:  route.Metric,
+					})
+				}
+
 				networks = append(networks, network)
 			}
 		}
@@ -620,13 +692,13 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 		// agent is not doing anything
 		if agentStatus == nil {
-			r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceImagingEventReason, "Imaging the instance onto BareMetalHardware %s", bmh.Name)
+			r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceImagingEventReason, "Imaging the instance onto BareMetalHardware %s", bmh.GetName())
 			r.Recorder.Eventf(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceImagingEventReason, "Imaging the BareMetalInstance %s onto the hardware", bmi.Name)
 
 			imageRequest := action.ImageRequest{
 				ImageURL: "https://cloud.centos.org/centos/7/images/CentOS-7-x86_64-GenericCloud-2003.raw.tar.gz",
 				// ImageURL: "https://download.fedoraproject.org/pub/fedora/linux/releases/32/Cloud/x86_64/images/Fedora-Cloud-Base-32-1.6.x86_64.raw.xz",
-				DiskPath:            fmt.Sprintf("/dev/%s", bmh.Spec.ImageDrive),
+				DiskPath:            fmt.Sprintf("/dev/%s", bmh.GetHardwareSpec().ImageDrive),
 				MetadataContents:    base64.StdEncoding.EncodeToString([]byte(strings.TrimSpace(metadata))),
 				NetworkDataContents: base64.StdEncoding.EncodeToString(networkDataBytes),
 				UserDataContents:    base64.StdEncoding.EncodeToString([]byte(strings.TrimSpace(userdata))),
@@ -702,7 +774,7 @@ func (r *Provisioner) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 				}
 
 				r.Recorder.Eventf(bmi, corev1.EventTypeNormal, "AgentFinished", "Agent has finished imaging")
-				r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceImagedEventReason, "Imaged the instance onto BareMetalHardware %s", bmh.Name)
+				r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceImagedEventReason, "Imaged the instance onto BareMetalHardware %s", bmh.GetName())
 				r.Recorder.Eventf(bmh, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceImagedEventReason, "Imaged the BareMetalInstance %s onto the hardware", bmi.Name)
 
 				// we are done imaging so set image cond to true
@@ -767,19 +839,8 @@ func (r *Provisioner) SetupWithManager(mgr ctrl.Manager) error {
 		Named("BareMetalInstanceProvisioner").
 		For(&baremetalv1alpha1.BareMetalInstance{}).
 		Owns(&baremetalv1alpha1.BareMetalEndpoint{}).
-		// This will cause BMH changes to cause a BMI reconcile if instance ref is set
-		Watches(&source.Kind{Type: &baremetalv1alpha1.BareMetalHardware{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
-			bmh := a.Object.(*baremetalv1alpha1.BareMetalHardware)
-			var req []reconcile.Request
-
-			if bmh.Status.InstanceRef != nil {
-				req = append(req, reconcile.Request{NamespacedName: types.NamespacedName{
-					Namespace: bmh.Status.InstanceRef.Namespace,
-					Name:      bmh.Status.InstanceRef.Name,
-				}})
-			}
-
-			return req
-		})}).
+		// This will cause BMH and CBMH changes to cause a BMI reconcile if instance ref is set
+		Watches(&source.Kind{Type: &baremetalv1alpha1.BareMetalHardware{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(hardwareInstanceRef)}).
+		Watches(&source.Kind{Type: &baremetalv1alpha1.ClusterBareMetalHardware{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(hardwareInstanceRef)}).
 		Complete(r)
 }
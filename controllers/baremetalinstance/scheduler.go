@@ -3,23 +3,38 @@ package baremetalinstance
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
 	conditionv1 "github.com/rmb938/kube-baremetal/apis/condition/v1"
+	"github.com/rmb938/kube-baremetal/pkg/scheduler"
+)
+
+const (
+	// how long to wait before trying to schedule an instance that didn't fit again
+	scheduleRetryInterval = 1 * time.Minute
+
+	// the most hardware that is listed in the scheduling status of an instance
+	maxSchedulingHardwareDetails = 100
 )
 
 type Scheduler struct {
@@ -29,7 +44,21 @@ type Scheduler struct {
 	Clock    clock.Clock
 	Recorder record.EventRecorder
 
-	scheduleLock sync.Mutex
+	// The filter and score plugins used to pick hardware
+	Framework *scheduler.Framework
+
+	// The number of instances that can be scheduled at the same time
+	Workers int
+
+	// The hardware and instances from the informers along with the assumed assignments
+	cache *scheduler.Cache
+
+	// The pending instances waiting for the scheduling workers
+	queue *scheduler.Queue
+
+	// Used by the scheduling workers to reconcile instances later
+	events chan event.GenericEvent
+	stop   <-chan struct{}
 }
 
 func (r *Scheduler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
@@ -47,6 +76,9 @@ func (r *Scheduler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 	// if bmi is deleting
 	if bmi.DeletionTimestamp.IsZero() == false {
+		// deleting instances no longer need hardware
+		r.queue.Delete(bmi)
+
 		// if we are already terminated ignore
 		if bmi.Status.Phase == baremetalv1alpha1.BareMetalInstanceStatusPhaseTerminated {
 			return ctrl.Result{}, nil
@@ -69,10 +101,6 @@ func (r *Scheduler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			return ctrl.Result{}, nil
 		}
 
-		// we want to lock to remove the hardware name
-		r.scheduleLock.Lock()
-		defer r.scheduleLock.Unlock()
-
 		// TODO: do we need to do anything else to unschedule?
 
 		nowTime := metav1.NewTime(r.Clock.Now())
@@ -87,6 +115,7 @@ func (r *Scheduler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 		existing := bmi.Status.HardwareName
 		bmi.Status.HardwareName = "" // I don't like assigning "" maybe make this a pointer or something?
+		bmi.Status.ClusterHardware = false
 		err = r.Status().Update(ctx, bmi)
 		if err != nil {
 			return ctrl.Result{}, err
@@ -149,100 +178,162 @@ func (r *Scheduler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
-	// we want to lock while finding and setting a hardware name
-	r.scheduleLock.Lock()
-	defer r.scheduleLock.Unlock()
+	// we already picked hardware and are waiting for the informer to see it
+	if r.cache.IsAssumed(bmi) {
+		// or for the rest of the gang to have hardware
+		if r.cache.IsWaitingForGang(bmi) {
+			return r.permitGang(ctx, bmi, nil)
+		}
+		return ctrl.Result{}, nil
+	}
 
-	// TODO: scheduling stuff here
+	// the scheduling workers pick hardware for the queued instances in priority order
+	r.queue.Add(bmi)
 
-	bmhList := &baremetalv1alpha1.BareMetalHardwareList{}
-	err := r.List(ctx, bmhList, client.InNamespace(bmi.Namespace))
-	if err != nil {
-		return ctrl.Result{}, err
-	}
+	return ctrl.Result{}, nil
+}
 
-	totalBMH := bmhList.Items
-	scheduledBMH := make([]*baremetalv1alpha1.BareMetalHardware, 0)
-	unscheduableBMH := make([]*baremetalv1alpha1.BareMetalHardware, 0)
-	notMatchSelectorBMH := make([]*baremetalv1alpha1.BareMetalHardware, 0)
-	notTolerateTaint := make([]*baremetalv1alpha1.BareMetalHardware, 0)
-	acceptableBMH := make([]*baremetalv1alpha1.BareMetalHardware, 0)
+// helper method to run the scheduling workers until the stop channel is closed
+func (r *Scheduler) runWorkers(stop <-chan struct{}) error {
+	r.stop = stop
+	go r.queue.Run(stop)
 
-	var labelSelector labels.Selector
+	workers := r.Workers
+	if workers < 1 {
+		workers = 1
+	}
 
-	if len(bmi.Spec.Selector) > 0 {
-		labelSelector = labels.SelectorFromSet(bmi.Spec.Selector)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r.processNextInstance() {
+			}
+		}()
 	}
 
-	for _, bmh := range totalBMH {
-		if bmh.DeletionTimestamp.IsZero() == false {
-			continue
-		}
+	<-stop
+	wg.Wait()
 
-		if bmh.Status.InstanceRef != nil {
-			scheduledBMH = append(scheduledBMH, &bmh)
-			continue
-		}
+	return nil
+}
 
-		bmiList := &baremetalv1alpha1.BareMetalInstanceList{}
-		err = r.List(ctx, bmiList, client.MatchingFields{"status.hardwareName": bmh.Name})
-		if err != nil {
-			return ctrl.Result{}, err
-		}
+// helper method to schedule the next instance in the queue, returns false when the queue is shut down
+func (r *Scheduler) processNextInstance() bool {
+	key, ok := r.queue.Pop()
+	if ok == false {
+		return false
+	}
+	defer r.queue.Done(key)
 
-		if len(bmiList.Items) > 0 {
-			scheduledBMH = append(scheduledBMH, &bmh)
-			continue
-		}
+	ctx := context.Background()
+	log := r.Log.WithValues("baremetalinstance", key)
 
-		if labelSelector != nil {
-			if labelSelector.Matches(labels.Set(bmh.Labels)) == false {
-				notMatchSelectorBMH = append(notMatchSelectorBMH, &bmh)
-				continue
-			}
+	bmi := &baremetalv1alpha1.BareMetalInstance{}
+	if err := r.Client.Get(ctx, key, bmi); err != nil {
+		if apierrors.IsNotFound(err) == false {
+			log.Error(err, "failed to retrieve BareMetalInstance resource")
+			bmi.Namespace = key.Namespace
+			bmi.Name = key.Name
+			r.queue.AddUnschedulable(bmi)
 		}
+		return true
+	}
 
-		if bmh.Spec.CanProvision == false {
-			unscheduableBMH = append(unscheduableBMH, &bmh)
-			continue
+	// the instance may have changed since it was queued
+	scheduledCond := bmi.Status.GetCondition(baremetalv1alpha1.BareMetalHardwareConditionTypeInstanceScheduled)
+	if bmi.DeletionTimestamp.IsZero() == false || bmi.Status.Phase != baremetalv1alpha1.BareMetalInstanceStatusPhasePending ||
+		scheduledCond == nil || scheduledCond.Status == conditionv1.ConditionStatusTrue || len(bmi.Status.HardwareName) > 0 || r.cache.IsAssumed(bmi) {
+		return true
+	}
+
+	result, err := r.schedule(ctx, log, bmi)
+	if err != nil {
+		log.Error(err, "failed to schedule instance")
+		if apierrors.IsConflict(err) {
+			// the cache is behind, try again once it catches up
+			r.queue.Add(bmi)
+		} else {
+			r.queue.AddUnschedulable(bmi)
 		}
+		return true
+	}
 
-		toleratesAll := true
+	if result.Requeue {
+		r.queue.Add(bmi)
+	} else if result.RequeueAfter > 0 {
+		r.reconcileAfter(bmi, result.RequeueAfter)
+	}
 
-		for _, taint := range bmh.Spec.Taints {
-			if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
-				continue
-			}
+	return true
+}
 
-			tolerates := false
+// helper method to reconcile the instance again after the duration
+func (r *Scheduler) reconcileAfter(bmi *baremetalv1alpha1.BareMetalInstance, duration time.Duration) {
+	time.AfterFunc(duration, func() {
+		select {
+		case r.events <- event.GenericEvent{Meta: bmi, Object: bmi}:
+		case <-r.stop:
+		}
+	})
+}
 
-			for _, toleration := range bmi.Spec.Tolerations {
-				if toleration.ToleratesTaint(&taint) {
-					tolerates = true
-					break
-				}
-			}
+// helper method to find hardware for the instance and bind it
+// instances that don't fit are parked in the queue as unschedulable
+func (r *Scheduler) schedule(ctx context.Context, log logr.Logger, bmi *baremetalv1alpha1.BareMetalInstance) (ctrl.Result, error) {
+	snapshot := r.cache.Snapshot(bmi.Namespace)
 
-			if tolerates == false {
-				toleratesAll = false
-				break
-			}
+	totalBMH := snapshot.Hardware
+	scheduledBMH := make([]*baremetalv1alpha1.BareMetalHardware, 0)
+	freeBMH := make([]*baremetalv1alpha1.BareMetalHardware, 0)
 
+	for _, bmh := range totalBMH {
+		if bmh.DeletionTimestamp.IsZero() == false {
+			continue
 		}
 
-		if toleratesAll == false {
-			notTolerateTaint = append(notTolerateTaint, &bmh)
+		if bmh.Status.InstanceRef != nil {
+			scheduledBMH = append(scheduledBMH, bmh)
 			continue
 		}
 
-		acceptableBMH = append(acceptableBMH, &bmh)
+		if snapshot.HardwareAssigned(bmh) {
+			scheduledBMH = append(scheduledBMH, bmh)
+			continue
+		}
+
+		freeBMH = append(freeBMH, bmh)
+	}
+
+	acceptableBMH, diagnosis, err := r.Framework.Filter(snapshot, bmi, freeBMH)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	scheduling := &baremetalv1alpha1.BareMetalInstanceScheduling{
+		TotalHardware:     len(totalBMH),
+		AvailableHardware: len(freeBMH),
+		FeasibleHardware:  len(acceptableBMH),
+	}
+	for _, result := range diagnosis.Filters() {
+		scheduling.Filters = append(scheduling.Filters, baremetalv1alpha1.BareMetalInstanceSchedulingFilterResult{
+			Plugin: result.Plugin,
+			Reason: result.Reason,
+			Count:  result.Count,
+		})
+	}
+	if bmi.Annotations[baremetalv1alpha1.BareMetalInstanceSchedulingDetailsAnnotation] == "true" {
+		for _, result := range diagnosis.Hardware(maxSchedulingHardwareDetails) {
+			scheduling.Hardware = append(scheduling.Hardware, baremetalv1alpha1.BareMetalInstanceSchedulingHardwareResult{
+				Name:    result.Name,
+				Reasons: result.Reasons,
+			})
+		}
 	}
 
 	if len(acceptableBMH) == 0 {
 		allScheduled := "0 hardware is available to be scheduled"
-		notMatchSelectorMessage := fmt.Sprintf("%v hardware(s) didn't match hardware selector", len(notMatchSelectorBMH))
-		unschedulableMessage := fmt.Sprintf("%v hardware(s) were unschedulable", len(unscheduableBMH))
-		notnotTolerateTaintMessage := fmt.Sprintf("%v hardware(s) had taints that the instance didn't tolerate", len(notTolerateTaint))
 
 		message := fmt.Sprintf("0/%v hardwares are available: ", len(totalBMH))
 
@@ -251,17 +342,7 @@ func (r *Scheduler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		if len(scheduledBMH) == len(totalBMH) {
 			reasons = append(reasons, allScheduled)
 		} else {
-			if len(notMatchSelectorBMH) > 0 {
-				reasons = append(reasons, notMatchSelectorMessage)
-			}
-
-			if len(unscheduableBMH) > 0 {
-				reasons = append(reasons, unschedulableMessage)
-			}
-
-			if len(notTolerateTaint) > 0 {
-				reasons = append(reasons, notnotTolerateTaintMessage)
-			}
+			reasons = append(reasons, diagnosis.Reasons()...)
 		}
 
 		message += strings.Join(reasons, ", ")
@@ -269,21 +350,279 @@ func (r *Scheduler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 
 		r.Recorder.Event(bmi, corev1.EventTypeNormal, "FailedScheduling", message)
 
-		return ctrl.Result{RequeueAfter: 1 * time.Minute}, nil
+		updated, err := r.setScheduling(bmi, scheduling, conditionv1.ConditionStatusFalse, baremetalv1alpha1.BareMetalInstanceUnschedulableConditionReason, message)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if updated {
+			err = r.Status().Update(ctx, bmi)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		r.queue.AddUnschedulable(bmi)
+
+		return ctrl.Result{}, nil
 	}
 
-	bmi.Status.HardwareName = acceptableBMH[rand.Intn(len(acceptableBMH))].Name
-	err = r.Status().Update(ctx, bmi)
+	selectedBMH, err := r.Framework.Select(snapshot, bmi, acceptableBMH)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// gangs reserve hardware until enough members have it
+	if bmi.Spec.Gang != nil {
+		err = r.cache.Reserve(bmi, selectedBMH)
+		if err != nil {
+			log.Info("hardware was picked by another instance or exceeded a quota, retrying later", "baremetalhardware", selectedBMH.Name, "reason", err.Error())
+			// parked until the hardware changes so a cache that is behind doesn't cause a hot loop
+			r.queue.AddUnschedulable(bmi)
+			return ctrl.Result{}, nil
+		}
+
+		return r.permitGang(ctx, bmi, scheduling)
+	}
+
+	// reserve the hardware so other workers don't pick it while we bind
+	err = r.cache.Assume(bmi, selectedBMH)
+	if err != nil {
+		log.Info("hardware was picked by another instance or exceeded a quota, retrying later", "baremetalhardware", selectedBMH.Name, "reason", err.Error())
+		// parked until the hardware changes so a cache that is behind doesn't cause a hot loop
+		r.queue.AddUnschedulable(bmi)
+		return ctrl.Result{}, nil
+	}
+
+	err = r.bind(ctx, bmi, scheduling, selectedBMH.Name, len(selectedBMH.Namespace) == 0)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceScheduleEventReason, "Successfully assigned %s/%s to %s", bmi.Namespace, bmi.Name, bmi.Status.HardwareName)
 
 	return ctrl.Result{}, nil
 }
 
+// helper method to write the hardware name of an assumed instance
+// the assumption is forgotten when writing fails so the instance is scheduled again
+// clusterHardware is set when the hardware is a ClusterBareMetalHardware
+func (r *Scheduler) bind(ctx context.Context, bmi *baremetalv1alpha1.BareMetalInstance, scheduling *baremetalv1alpha1.BareMetalInstanceScheduling, hardwareName string, clusterHardware bool) error {
+	message := fmt.Sprintf("Successfully assigned %s/%s to %s", bmi.Namespace, bmi.Name, hardwareName)
+	_, err := r.setScheduling(bmi, scheduling, conditionv1.ConditionStatusTrue, baremetalv1alpha1.BareMetalInstanceScheduledConditionReason, message)
+	if err != nil {
+		r.cache.Forget(bmi)
+		return err
+	}
+
+	bmi.Status.HardwareName = hardwareName
+	bmi.Status.ClusterHardware = clusterHardware
+	err = r.Status().Update(ctx, bmi)
+	if err != nil {
+		r.cache.Forget(bmi)
+		return err
+	}
+	r.cache.FinishBinding(bmi)
+	r.Recorder.Event(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceScheduleEventReason, message)
+
+	return nil
+}
+
+// helper method to bind the waiting members of the gang of the instance once enough of them have hardware
+// when the gang doesn't have enough members before the timeout the reserved hardware is released
+// scheduling is the result of the attempt that reserved hardware for the instance, nil when it was reserved before
+func (r *Scheduler) permitGang(ctx context.Context, bmi *baremetalv1alpha1.BareMetalInstance, scheduling *baremetalv1alpha1.BareMetalInstanceScheduling) (ctrl.Result, error) {
+	gang := bmi.Spec.Gang
+
+	if scheduling == nil {
+		scheduling = &baremetalv1alpha1.BareMetalInstanceScheduling{}
+		if bmi.Status.Scheduling != nil {
+			scheduling = bmi.Status.Scheduling.DeepCopy()
+			scheduling.LastAttemptTime = nil
+		}
+	}
+
+	members, status := r.cache.PermitGang(bmi)
+	if members != nil {
+		var bindErr error
+		for _, member := range members {
+			memberBMI := bmi
+			memberScheduling := scheduling
+
+			if member.Instance.Name != bmi.Name {
+				memberBMI = &baremetalv1alpha1.BareMetalInstance{}
+				err := r.Get(ctx, member.Instance, memberBMI)
+				if err != nil {
+					// the member is scheduled again on its own, the rest of the gang already has hardware
+					r.cache.Forget(&baremetalv1alpha1.BareMetalInstance{ObjectMeta: metav1.ObjectMeta{Namespace: member.Instance.Namespace, Name: member.Instance.Name}})
+					bindErr = err
+					continue
+				}
+
+				memberScheduling = &baremetalv1alpha1.BareMetalInstanceScheduling{}
+				if memberBMI.Status.Scheduling != nil {
+					memberScheduling = memberBMI.Status.Scheduling.DeepCopy()
+				}
+			}
+
+			err := r.bind(ctx, memberBMI, memberScheduling, member.HardwareName, member.ClusterHardware)
+			if err != nil {
+				bindErr = err
+			}
+		}
+
+		return ctrl.Result{}, bindErr
+	}
+
+	timeoutSeconds := baremetalv1alpha1.DefaultGangScheduleTimeoutSeconds
+	if gang.ScheduleTimeoutSeconds != nil {
+		timeoutSeconds = *gang.ScheduleTimeoutSeconds
+	}
+	deadline := status.Started.Add(time.Duration(timeoutSeconds) * time.Second)
+
+	now := r.Clock.Now()
+	if now.Before(deadline) == false {
+		released := r.cache.ReleaseGang(bmi)
+		r.queue.MoveAllToActive()
+		r.Recorder.Eventf(bmi, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalInstanceGangTimeoutEventReason,
+			"Released the hardware of %d instances of gang %s, only %d/%d instances had hardware after %d seconds",
+			len(released), gang.Name, status.Waiting+status.Bound, gang.MinMembers, timeoutSeconds)
+		return ctrl.Result{RequeueAfter: scheduleRetryInterval}, nil
+	}
+
+	message := fmt.Sprintf("Waiting for gang %s, %d/%d instances have hardware", gang.Name, status.Waiting+status.Bound, gang.MinMembers)
+	updated, err := r.setScheduling(bmi, scheduling, conditionv1.ConditionStatusFalse, baremetalv1alpha1.BareMetalInstanceWaitingForGangConditionReason, message)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if updated {
+		err = r.Status().Update(ctx, bmi)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: deadline.Sub(now)}, nil
+}
+
+// helper method to record the result of a scheduling attempt and the schedulable condition, returns if the status changed
+// failed attempts that have the same result as the last attempt only change it once the retry interval passed
+// so the status update doesn't cause the instance to be retried right away
+func (r *Scheduler) setScheduling(bmi *baremetalv1alpha1.BareMetalInstance, scheduling *baremetalv1alpha1.BareMetalInstanceScheduling, status conditionv1.ConditionStatus, reason, message string) (bool, error) {
+	nowTime := metav1.NewTime(r.Clock.Now())
+
+	if existing := bmi.Status.Scheduling; existing != nil && existing.LastAttemptTime != nil && status == conditionv1.ConditionStatusFalse {
+		schedulableCond := bmi.Status.GetCondition(baremetalv1alpha1.BareMetalInstanceConditionTypeSchedulable)
+
+		previous := existing.DeepCopy()
+		previous.LastAttemptTime = nil
+
+		if schedulableCond != nil && schedulableCond.Status == status && schedulableCond.Message == message &&
+			apiequality.Semantic.DeepEqual(previous, scheduling) && nowTime.Sub(existing.LastAttemptTime.Time) < scheduleRetryInterval {
+			return false, nil
+		}
+	}
+
+	scheduling.LastAttemptTime = &nowTime
+	bmi.Status.Scheduling = scheduling
+
+	err := bmi.Status.SetCondition(&conditionv1.StatusCondition{
+		Type:               baremetalv1alpha1.BareMetalInstanceConditionTypeSchedulable,
+		Status:             status,
+		LastTransitionTime: &nowTime,
+		Reason:             reason,
+		Message:            message,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (r *Scheduler) SetupWithManager(mgr ctrl.Manager) error {
-	r.scheduleLock = sync.Mutex{}
+	r.cache = scheduler.NewCache(r.Clock, scheduler.DefaultAssumeTTL)
+	r.queue = scheduler.NewQueue(r.Clock, scheduleRetryInterval)
+	r.events = make(chan event.GenericEvent)
+
+	bmhInformer, err := mgr.GetCache().GetInformer(&baremetalv1alpha1.BareMetalHardware{})
+	if err != nil {
+		return err
+	}
+	bmhInformer.AddEventHandler(r.cache.HardwareEventHandler())
+	// any hardware change could let unschedulable instances fit
+	bmhInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			r.queue.MoveAllToActive()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			r.queue.MoveAllToActive()
+		},
+	})
+
+	cbmhInformer, err := mgr.GetCache().GetInformer(&baremetalv1alpha1.ClusterBareMetalHardware{})
+	if err != nil {
+		return err
+	}
+	cbmhInformer.AddEventHandler(r.cache.ClusterHardwareEventHandler())
+	cbmhInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			r.queue.MoveAllToActive()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			r.queue.MoveAllToActive()
+		},
+	})
+
+	bmiInformer, err := mgr.GetCache().GetInformer(&baremetalv1alpha1.BareMetalInstance{})
+	if err != nil {
+		return err
+	}
+	bmiInformer.AddEventHandler(r.cache.InstanceEventHandler())
+	// instances leaving hardware free it up for unschedulable instances
+	bmiInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldBMI, ok := oldObj.(*baremetalv1alpha1.BareMetalInstance)
+			if ok == false {
+				return
+			}
+			newBMI, ok := newObj.(*baremetalv1alpha1.BareMetalInstance)
+			if ok == false {
+				return
+			}
+			if len(oldBMI.Status.HardwareName) > 0 && len(newBMI.Status.HardwareName) == 0 {
+				r.queue.MoveAllToActive()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if bmi, ok := obj.(*baremetalv1alpha1.BareMetalInstance); ok {
+				r.queue.Delete(bmi)
+				if len(bmi.Status.HardwareName) > 0 {
+					r.queue.MoveAllToActive()
+				}
+			}
+		},
+	})
+
+	bmqInformer, err := mgr.GetCache().GetInformer(&baremetalv1alpha1.BareMetalQuota{})
+	if err != nil {
+		return err
+	}
+	bmqInformer.AddEventHandler(r.cache.QuotaEventHandler())
+	// raising or removing a quota could let unschedulable instances fit
+	bmqInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			r.queue.MoveAllToActive()
+		},
+		DeleteFunc: func(obj interface{}) {
+			r.queue.MoveAllToActive()
+		},
+	})
+
+	err = mgr.Add(manager.RunnableFunc(r.runWorkers))
+	if err != nil {
+		return err
+	}
 
 	if err := mgr.GetFieldIndexer().IndexField(&baremetalv1alpha1.BareMetalInstance{}, "status.hardwareName", func(rawObj runtime.Object) []string {
 		bmi := rawObj.(*baremetalv1alpha1.BareMetalInstance)
@@ -295,5 +634,8 @@ func (r *Scheduler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("BareMetalInstanceScheduler").
 		For(&baremetalv1alpha1.BareMetalInstance{}).
+		// the scheduling workers use this to reconcile instances later
+		Watches(&source.Channel{Source: r.events}, &handler.EnqueueRequestForObject{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.Workers}).
 		Complete(r)
 }
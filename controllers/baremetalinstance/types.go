@@ -1,22 +1,33 @@
 package baremetalinstance
 
 type NetworkDataLink struct {
-	ID         string   `json:"id"`
-	MAC        string   `json:"ethernet_mac_address"`
-	Type       string   `json:"type"`
-	BondMode   string   `json:"bond_mode,omitempty"`
-	BondMiiMon int      `json:"bond_miimon,omitempty"`
-	BondLinks  []string `json:"bond_links,omitempty"`
+	ID                 string   `json:"id"`
+	MAC                string   `json:"ethernet_mac_address"`
+	Type               string   `json:"type"`
+	MTU                int      `json:"mtu,omitempty"`
+	BondMode           string   `json:"bond_mode,omitempty"`
+	BondMiiMon         int      `json:"bond_miimon,omitempty"`
+	BondXmitHashPolicy string   `json:"bond_xmit_hash_policy,omitempty"`
+	BondLACPRate       string   `json:"bond_lacp_rate,omitempty"`
+	BondLinks          []string `json:"bond_links,omitempty"`
+}
+
+type NetworkDataRoute struct {
+	Network string `json:"network"`
+	Netmask string `json:"netmask"`
+	Gateway string `json:"gateway"`
+	Metric  int    `json:"metric,omitempty"`
 }
 
 type NetworkDataNetwork struct {
-	Link        string   `json:"link"`
-	Type        string   `json:"type"`
-	IPAddress   string   `json:"ip_address"`
-	Netmask     string   `json:"netmask"`
-	Gateway     string   `json:"gateway,omitempty"`
-	Nameservers []string `json:"dns_nameservers,omitempty"`
-	Search      []string `json:"dns_search,omitempty"`
+	Link        string             `json:"link"`
+	Type        string             `json:"type"`
+	IPAddress   string             `json:"ip_address,omitempty"`
+	Netmask     string             `json:"netmask,omitempty"`
+	Gateway     string             `json:"gateway,omitempty"`
+	Routes      []NetworkDataRoute `json:"routes,omitempty"`
+	Nameservers []string           `json:"dns_nameservers,omitempty"`
+	Search      []string           `json:"dns_search,omitempty"`
 }
 
 type NetworkData struct {
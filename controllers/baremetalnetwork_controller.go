@@ -17,21 +17,51 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	baremetalapi "github.com/rmb938/kube-baremetal/api"
 	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	conditionv1 "github.com/rmb938/kube-baremetal/apis/condition/v1"
+	"github.com/rmb938/kube-baremetal/pkg/ipam"
 )
 
+// how long an address can be allocated without an endpoint using it before it is released
+// endpoints are addressed after the allocation is saved so this needs to be longer then that takes
+const bareMetalNetworkLeakGracePeriod = 1 * time.Minute
+
 // BareMetalNetworkReconciler reconciles a BareMetalNetwork object
 type BareMetalNetworkReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Clock    clock.Clock
+	Recorder record.EventRecorder
+
+	// addresses that are allocated but not used by any endpoint
+	// keyed by network and address with the time the address was first seen unused
+	leaks     map[types.NamespacedName]map[string]time.Time
+	leaksLock sync.Mutex
 }
 
 // +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=baremetalnetworks,verbs=get;list;watch;create;update;patch;delete
@@ -50,24 +80,242 @@ func (r *BareMetalNetworkReconciler) Reconcile(req ctrl.Request) (ctrl.Result, e
 		return ctrl.Result{}, err
 	}
 
+	bmeList := &baremetalv1alpha1.BareMetalEndpointList{}
+	err := r.List(ctx, bmeList, client.MatchingFields{"spec.networkRef.group,kind,name": baremetalv1alpha1.GroupVersion.Group + ".BareMetalNetwork." + bmn.Name})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if bmn.DeletionTimestamp.IsZero() == false {
-		// TODO: any deletion protection logic?
-		//  should we wait until all "our" endpoints are deleted?
+		// wait until all endpoints have released their addresses
+		// endpoint changes cause a reconcile so we don't need to requeue
+		var users []string
+		for _, bme := range bmeList.Items {
+			if bme.Status.Address != nil {
+				users = append(users, bme.Name)
+			}
+		}
+
+		if len(users) > 0 {
+			sort.Strings(users)
+
+			message := fmt.Sprintf("network is used by the endpoints %s", strings.Join(users, ", "))
+			if len(users) > 10 {
+				message = fmt.Sprintf("network is used by the endpoints %s and %d more", strings.Join(users[:10], ", "), len(users)-10)
+			}
+
+			cond := bmn.Status.GetCondition(baremetalv1alpha1.BareMetalNetworkConditionTypeInUse)
+			if cond == nil || cond.Status != conditionv1.ConditionStatusTrue || cond.Message != message {
+				nowTime := metav1.NewTime(r.Clock.Now())
+				err := bmn.Status.SetCondition(&conditionv1.StatusCondition{
+					Type:               baremetalv1alpha1.BareMetalNetworkConditionTypeInUse,
+					Status:             conditionv1.ConditionStatusTrue,
+					LastTransitionTime: &nowTime,
+					Reason:             baremetalv1alpha1.BareMetalNetworkEndpointsAllocatedConditionReason,
+					Message:            message,
+				})
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				err = r.Status().Update(ctx, bmn)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				r.Recorder.Eventf(bmn, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalNetworkInUseEventReason, "Waiting for %d endpoints to release their addresses before deleting", len(users))
+			}
+
+			return ctrl.Result{}, nil
+		}
 
 		// Done deleting so remove bme finalizer
 		baremetalapi.RemoveFinalizer(bmn, baremetalv1alpha1.BareMetalNetworkFinalizer)
-		err := r.Update(ctx, bmn)
+		err = r.Update(ctx, bmn)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
+
+		r.leaksLock.Lock()
+		delete(r.leaks, req.NamespacedName)
+		r.leaksLock.Unlock()
 		return ctrl.Result{}, nil
 	}
 
+	alloc, err := ipam.NewAllocator(bmn)
+	if err != nil {
+		log.Error(err, "failed to create allocator for BareMetalNetwork")
+		return ctrl.Result{}, err
+	}
+
+	// make sure every address used by an endpoint is marked as allocated
+	// this seeds the pools from existing endpoints and repairs them if the status was lost
+	used := make(map[string]bool)
+	repaired := 0
+	unquarantined := 0
+	for _, bme := range bmeList.Items {
+		if bme.Status.Address == nil {
+			continue
+		}
+
+		ip := net.ParseIP(bme.Status.Address.IP)
+		if ip == nil || alloc.Contains(ip) == false {
+			continue
+		}
+
+		used[ip.String()] = true
+
+		// deleting endpoints are releasing their address so don't mark it allocated again
+		if bme.Status.Phase == baremetalv1alpha1.BareMetalEndpointStatusPhaseDeleting {
+			continue
+		}
+
+		// an endpoint is still using the address so it can't be quarantined
+		if alloc.Quarantined(ip) {
+			alloc.Unquarantine(ip)
+			unquarantined++
+		}
+
+		if alloc.Has(ip) == false {
+			if err := alloc.Allocate(ip); err == nil {
+				repaired++
+			}
+		}
+	}
+
+	// release addresses that have been allocated without an endpoint using them for too long
+	// this happens when an endpoint fails to save its address after it was allocated
+	now := r.Clock.Now()
+	pendingLeaks := false
+	var released []net.IP
+
+	r.leaksLock.Lock()
+	if r.leaks == nil {
+		r.leaks = make(map[types.NamespacedName]map[string]time.Time)
+	}
+	leaks := make(map[string]time.Time)
+	alloc.ForEach(func(ip net.IP) {
+		key := ip.String()
+		if used[key] || alloc.Quarantined(ip) {
+			return
+		}
+
+		firstSeen, ok := r.leaks[req.NamespacedName][key]
+		if ok == false {
+			firstSeen = now
+		}
+
+		if now.Sub(firstSeen) >= bareMetalNetworkLeakGracePeriod {
+			released = append(released, ip)
+			return
+		}
+
+		leaks[key] = firstSeen
+		pendingLeaks = true
+	})
+	r.leaks[req.NamespacedName] = leaks
+	r.leaksLock.Unlock()
+
+	nowTime := metav1.NewTime(now)
+	for _, ip := range released {
+		alloc.Release(ip, nowTime)
+	}
+
+	// free quarantined addresses once their hold-down has passed
+	unquarantinedExpired, nextQuarantineExpiry := alloc.ReleaseQuarantined(nowTime)
+
+	oldStatus := bmn.Status.DeepCopy()
+	err = alloc.Save(bmn, nowTime)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if reflect.DeepEqual(oldStatus, &bmn.Status) == false {
+		err = r.Status().Update(ctx, bmn)
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			return ctrl.Result{}, err
+		}
+
+		if repaired > 0 {
+			r.Recorder.Eventf(bmn, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalNetworkPoolRepairedEventReason, "Marked %d addresses used by endpoints as allocated", repaired)
+		}
+
+		if len(released) > 0 {
+			r.Recorder.Eventf(bmn, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalNetworkPoolRepairedEventReason, "Released %d addresses that were not used by any endpoint", len(released))
+		}
+
+		if unquarantined > 0 {
+			r.Recorder.Eventf(bmn, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalNetworkPoolRepairedEventReason, "Removed %d addresses used by endpoints from quarantine", unquarantined)
+		}
+
+		if unquarantinedExpired > 0 {
+			r.Recorder.Eventf(bmn, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalNetworkUnquarantinedEventReason, "Released %d quarantined addresses after the hold-down", unquarantinedExpired)
+		}
+	}
+
+	// keep the dns, routes and mtu of endpoints in sync with the network
+	// so instances that are reimaged pick up the changes
+	for i := range bmeList.Items {
+		bme := &bmeList.Items[i]
+		if bme.Status.Address == nil || bme.DeletionTimestamp.IsZero() == false {
+			continue
+		}
+
+		address := bme.Status.Address
+		if equality.Semantic.DeepEqual(address.Nameservers, bmn.Spec.Nameservers) &&
+			equality.Semantic.DeepEqual(address.Search, bmn.Spec.Search) &&
+			equality.Semantic.DeepEqual(address.Routes, bmn.Spec.Routes) &&
+			address.MTU == bmn.Spec.MTU {
+			continue
+		}
+
+		address.Nameservers = bmn.Spec.Nameservers
+		address.Search = bmn.Spec.Search
+		address.Routes = bmn.Spec.Routes
+		address.MTU = bmn.Spec.MTU
+		err = r.Status().Update(ctx, bme)
+		if err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(bme, corev1.EventTypeNormal, "AddressUpdated", "Address settings have been updated from the BareMetalNetwork %s", bmn.Name)
+	}
+
+	// check the unused addresses again once their grace period is over
+	// and the quarantined addresses once their hold-down is over
+	var requeueAfter time.Duration
+	if pendingLeaks {
+		requeueAfter = bareMetalNetworkLeakGracePeriod
+	}
+	if nextQuarantineExpiry > 0 && (requeueAfter == 0 || nextQuarantineExpiry < requeueAfter) {
+		requeueAfter = nextQuarantineExpiry
+	}
+	if requeueAfter > 0 {
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
 func (r *BareMetalNetworkReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&baremetalv1alpha1.BareMetalNetwork{}).
+		// This will cause BME changes to cause a BMN reconcile so the pools stay in sync with the endpoints
+		Watches(&source.Kind{Type: &baremetalv1alpha1.BareMetalEndpoint{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			bme := a.Object.(*baremetalv1alpha1.BareMetalEndpoint)
+			var req []reconcile.Request
+
+			if bme.Spec.NetworkRef.Group == baremetalv1alpha1.GroupVersion.Group && bme.Spec.NetworkRef.Kind == "BareMetalNetwork" {
+				req = append(req, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: bme.Namespace,
+					Name:      bme.Spec.NetworkRef.Name,
+				}})
+			}
+
+			return req
+		})}).
 		Complete(r)
 }
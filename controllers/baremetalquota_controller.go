@@ -0,0 +1,159 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/pkg/quota"
+)
+
+// BareMetalQuotaReconciler reconciles a BareMetalQuota object
+type BareMetalQuotaReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=baremetalquotas,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=baremetalquotas/status,verbs=get;update;patch
+
+func (r *BareMetalQuotaReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("baremetalquota", req.NamespacedName)
+
+	bmq := &baremetalv1alpha1.BareMetalQuota{}
+	if err := r.Client.Get(ctx, req.NamespacedName, bmq); err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			log.Error(err, "failed to retrieve BareMetalQuota resource")
+		}
+		return ctrl.Result{}, err
+	}
+
+	bmiList := &baremetalv1alpha1.BareMetalInstanceList{}
+	err := r.List(ctx, bmiList, client.InNamespace(bmq.Namespace))
+	if err != nil {
+		log.Error(err, "failed to list BareMetalInstances")
+		return ctrl.Result{}, err
+	}
+
+	bmhList := &baremetalv1alpha1.BareMetalHardwareList{}
+	err = r.List(ctx, bmhList, client.InNamespace(bmq.Namespace))
+	if err != nil {
+		log.Error(err, "failed to list BareMetalHardware")
+		return ctrl.Result{}, err
+	}
+
+	cbmhList := &baremetalv1alpha1.ClusterBareMetalHardwareList{}
+	err = r.List(ctx, cbmhList)
+	if err != nil {
+		log.Error(err, "failed to list ClusterBareMetalHardware")
+		return ctrl.Result{}, err
+	}
+
+	instances := make([]*baremetalv1alpha1.BareMetalInstance, 0, len(bmiList.Items))
+	for i := range bmiList.Items {
+		instances = append(instances, &bmiList.Items[i])
+	}
+
+	hardware := make(map[string]*baremetalv1alpha1.BareMetalHardware, len(bmhList.Items))
+	for i := range bmhList.Items {
+		hardware[bmhList.Items[i].Name] = &bmhList.Items[i]
+	}
+
+	clusterHardware := make(map[string]*baremetalv1alpha1.BareMetalHardware, len(cbmhList.Items))
+	for i := range cbmhList.Items {
+		clusterHardware[cbmhList.Items[i].Name] = cbmhList.Items[i].AsBareMetalHardware()
+	}
+
+	used := quota.Calculate(instances, func(bmi *baremetalv1alpha1.BareMetalInstance) *baremetalv1alpha1.BareMetalHardware {
+		if bmi.Status.ClusterHardware {
+			return clusterHardware[bmi.Status.HardwareName]
+		}
+		return hardware[bmi.Status.HardwareName]
+	}).Resources()
+
+	// quantities need a semantic comparison, i.e. 1Gi and 1024Mi are equal
+	if equality.Semantic.DeepEqual(bmq.Status.Used, used) {
+		return ctrl.Result{}, nil
+	}
+
+	bmq.Status.Used = used
+	err = r.Status().Update(ctx, bmq)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// helper method to reconcile all the quotas in the namespace of the object
+func (r *BareMetalQuotaReconciler) namespaceQuotas(a handler.MapObject) []reconcile.Request {
+	return r.quotasInNamespace(a.Meta.GetNamespace())
+}
+
+// helper method to reconcile all the quotas in the namespace of the instance on the cluster hardware
+func (r *BareMetalQuotaReconciler) clusterHardwareQuotas(a handler.MapObject) []reconcile.Request {
+	cbmh := a.Object.(*baremetalv1alpha1.ClusterBareMetalHardware)
+	if cbmh.Status.InstanceRef == nil {
+		return nil
+	}
+
+	return r.quotasInNamespace(cbmh.Status.InstanceRef.Namespace)
+}
+
+// helper method to reconcile all the quotas in the namespace
+func (r *BareMetalQuotaReconciler) quotasInNamespace(namespace string) []reconcile.Request {
+	var req []reconcile.Request
+
+	bmqList := &baremetalv1alpha1.BareMetalQuotaList{}
+	err := r.List(context.Background(), bmqList, client.InNamespace(namespace))
+	if err != nil {
+		r.Log.Error(err, "failed to list BareMetalQuotas", "namespace", namespace)
+		return req
+	}
+
+	for _, bmq := range bmqList.Items {
+		req = append(req, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: bmq.Namespace,
+			Name:      bmq.Name,
+		}})
+	}
+
+	return req
+}
+
+func (r *BareMetalQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&baremetalv1alpha1.BareMetalQuota{}).
+		// This will cause BMI, BMH and CBMH changes to cause a BMQ reconcile so the usage stays up to date
+		Watches(&source.Kind{Type: &baremetalv1alpha1.BareMetalInstance{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.namespaceQuotas)}).
+		Watches(&source.Kind{Type: &baremetalv1alpha1.BareMetalHardware{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.namespaceQuotas)}).
+		Watches(&source.Kind{Type: &baremetalv1alpha1.ClusterBareMetalHardware{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.clusterHardwareQuotas)}).
+		Complete(r)
+}
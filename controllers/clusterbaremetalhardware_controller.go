@@ -0,0 +1,81 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// ClusterBareMetalHardwareReconciler reconciles a ClusterBareMetalHardware object
+// the hardware has the same lifecycle as BareMetalHardware so the reconcile is shared with the BareMetalHardwareReconciler
+type ClusterBareMetalHardwareReconciler struct {
+	BareMetalHardwareReconciler
+}
+
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=clusterbaremetalhardwares,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=clusterbaremetalhardwares/status,verbs=get;update;patch
+
+func (r *ClusterBareMetalHardwareReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("clusterbaremetalhardware", req.NamespacedName)
+
+	cbmh := &baremetalv1alpha1.ClusterBareMetalHardware{}
+	if err := r.Client.Get(ctx, req.NamespacedName, cbmh); err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			log.Error(err, "failed to retrieve ClusterBareMetalHardware resource")
+		}
+		return ctrl.Result{}, err
+	}
+
+	return r.reconcileHardware(ctx, cbmh)
+}
+
+func (r *ClusterBareMetalHardwareReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(&baremetalv1alpha1.ClusterBareMetalHardware{}, "spec.systemUUID", func(rawObj runtime.Object) []string {
+		cbmh := rawObj.(*baremetalv1alpha1.ClusterBareMetalHardware)
+		return []string{string(cbmh.Spec.SystemUUID)}
+	}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&baremetalv1alpha1.ClusterBareMetalHardware{}).
+		// This will cause BMI changes to cause a CBMH reconcile if the instance is on cluster hardware
+		Watches(&source.Kind{Type: &baremetalv1alpha1.BareMetalInstance{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			bmi := a.Object.(*baremetalv1alpha1.BareMetalInstance)
+			var req []reconcile.Request
+
+			if len(bmi.Status.HardwareName) > 0 && bmi.Status.ClusterHardware {
+				req = append(req, reconcile.Request{NamespacedName: types.NamespacedName{
+					Name: bmi.Status.HardwareName,
+				}})
+			}
+
+			return req
+		})}).
+		Complete(r)
+}
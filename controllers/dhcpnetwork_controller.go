@@ -0,0 +1,56 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// DHCPNetworkReconciler reconciles a DHCPNetwork object
+type DHCPNetworkReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// THIS IS JUST A DUMMY FILE REAL CONTROLLER IMPLEMENTATION IS IN "github.com/rmb938/kube-baremetal/controllers/baremetalendpoint"
+
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=dhcpnetworks,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=dhcpnetworks/status,verbs=get;update;patch
+
+func (r *DHCPNetworkReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	_ = context.Background()
+	_ = r.Log.WithValues("dhcpnetwork", req.NamespacedName)
+
+	// your logic here
+	// the network object has no state of it's own, endpoints are addressed by the endpoint controller
+	// and the addresses are handed out by an external dhcp server
+
+	return ctrl.Result{}, nil
+}
+
+func (r *DHCPNetworkReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&baremetalv1alpha1.DHCPNetwork{}).
+		Complete(r)
+}
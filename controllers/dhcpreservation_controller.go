@@ -0,0 +1,314 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	baremetalapi "github.com/rmb938/kube-baremetal/api"
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/pkg/dhcpreservation"
+)
+
+// DHCPReservationReconciler exports dhcp host reservations for the nics of BareMetalHardware and ClusterBareMetalHardware
+// so an external dhcp server can pxe boot them
+type DHCPReservationReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// The boot settings added to every reservation
+	Config dhcpreservation.Config
+
+	// The config map to render the dnsmasq and isc dhcpd configs into, disabled when the name is empty
+	ConfigMap types.NamespacedName
+
+	// The kea control agent to push reservations to, disabled when nil
+	Kea *dhcpreservation.KeaClient
+}
+
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=baremetalhardwares,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=clusterbaremetalhardwares,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
+
+func (r *DHCPReservationReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("hardware", req.NamespacedName)
+
+	// BareMetalHardware is always in a namespace so requests without one are for ClusterBareMetalHardware
+	var hw baremetalv1alpha1.Hardware = &baremetalv1alpha1.BareMetalHardware{}
+	if len(req.Namespace) == 0 {
+		hw = &baremetalv1alpha1.ClusterBareMetalHardware{}
+	}
+
+	if err := r.Client.Get(ctx, req.NamespacedName, hw); err != nil {
+		if apierrors.IsNotFound(err) == false {
+			log.Error(err, "failed to retrieve hardware resource")
+			return ctrl.Result{}, err
+		}
+		// the hardware is gone so only the config map needs to be updated
+		hw = nil
+	}
+
+	if hw != nil && r.Kea != nil {
+		result, err := r.syncKea(ctx, hw)
+		if err != nil || result.Requeue || result.RequeueAfter > 0 {
+			return result, err
+		}
+	}
+
+	if len(r.ConfigMap.Name) > 0 {
+		err := r.syncConfigMap(ctx)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// helper method to push the reservations of the hardware to kea
+func (r *DHCPReservationReconciler) syncKea(ctx context.Context, hw baremetalv1alpha1.Hardware) (ctrl.Result, error) {
+	annotations := hw.GetAnnotations()
+
+	var existingMACs []string
+	if annotation := annotations[baremetalv1alpha1.BareMetalHardwareKeaReservationsAnnotation]; len(annotation) > 0 {
+		existingMACs = strings.Split(annotation, ",")
+	}
+
+	if hw.GetDeletionTimestamp().IsZero() == false {
+		if baremetalapi.HasFinalizer(hw, baremetalv1alpha1.BareMetalHardwareDHCPReservationFinalizer) == false {
+			return ctrl.Result{}, nil
+		}
+
+		// remove all the reservations before letting the hardware be deleted
+		for _, mac := range existingMACs {
+			if err := r.Kea.Delete(ctx, mac); err != nil {
+				r.Recorder.Eventf(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareDHCPReservationFailedEventReason, "Could not delete the kea reservation for %s: %v", mac, err)
+				return ctrl.Result{Requeue: true}, nil
+			}
+		}
+
+		delete(annotations, baremetalv1alpha1.BareMetalHardwareKeaReservationsAnnotation)
+		baremetalapi.RemoveFinalizer(hw, baremetalv1alpha1.BareMetalHardwareDHCPReservationFinalizer)
+		err := r.Update(ctx, hw)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// add our finalizer before creating any reservations so they are always cleaned up
+	if baremetalapi.HasFinalizer(hw, baremetalv1alpha1.BareMetalHardwareDHCPReservationFinalizer) == false {
+		hw.SetFinalizers(append(hw.GetFinalizers(), baremetalv1alpha1.BareMetalHardwareDHCPReservationFinalizer))
+		err := r.Update(ctx, hw)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	reservations := reservationsForHardware(hw)
+	var macs []string
+	reserved := make(map[string]bool)
+	for _, reservation := range reservations {
+		if err := r.Kea.Ensure(ctx, r.Config, reservation); err != nil {
+			r.Recorder.Eventf(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareDHCPReservationFailedEventReason, "Could not create the kea reservation for %s: %v", reservation.MAC, err)
+			return ctrl.Result{Requeue: true}, nil
+		}
+		macs = append(macs, strings.ToLower(reservation.MAC))
+		reserved[strings.ToLower(reservation.MAC)] = true
+	}
+	sort.Strings(macs)
+
+	// remove reservations of nics that don't exist anymore
+	for _, mac := range existingMACs {
+		if reserved[mac] {
+			continue
+		}
+
+		if err := r.Kea.Delete(ctx, mac); err != nil {
+			r.Recorder.Eventf(hw, corev1.EventTypeWarning, baremetalv1alpha1.BareMetalHardwareDHCPReservationFailedEventReason, "Could not delete the kea reservation for %s: %v", mac, err)
+			return ctrl.Result{Requeue: true}, nil
+		}
+	}
+
+	annotation := strings.Join(macs, ",")
+	if annotation != annotations[baremetalv1alpha1.BareMetalHardwareKeaReservationsAnnotation] {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[baremetalv1alpha1.BareMetalHardwareKeaReservationsAnnotation] = annotation
+		if len(annotation) == 0 {
+			delete(annotations, baremetalv1alpha1.BareMetalHardwareKeaReservationsAnnotation)
+		}
+		hw.SetAnnotations(annotations)
+		err := r.Update(ctx, hw)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(hw, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalHardwareDHCPReservationUpdatedEventReason, "Kea reservations have been updated for %d nics", len(macs))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// helper method to render the reservations of all hardware into the config map
+func (r *DHCPReservationReconciler) syncConfigMap(ctx context.Context) error {
+	bmhList := &baremetalv1alpha1.BareMetalHardwareList{}
+	err := r.List(ctx, bmhList)
+	if err != nil {
+		return err
+	}
+
+	var reservations []dhcpreservation.Reservation
+	for i := range bmhList.Items {
+		bmh := &bmhList.Items[i]
+		if bmh.DeletionTimestamp.IsZero() == false {
+			continue
+		}
+		reservations = append(reservations, reservationsForHardware(bmh)...)
+	}
+
+	cbmhList := &baremetalv1alpha1.ClusterBareMetalHardwareList{}
+	err = r.List(ctx, cbmhList)
+	if err != nil {
+		return err
+	}
+
+	for i := range cbmhList.Items {
+		cbmh := &cbmhList.Items[i]
+		if cbmh.DeletionTimestamp.IsZero() == false {
+			continue
+		}
+		reservations = append(reservations, reservationsForHardware(cbmh)...)
+	}
+	dhcpreservation.Sort(reservations)
+
+	data := map[string]string{
+		dhcpreservation.DnsmasqKey: dhcpreservation.RenderDnsmasq(r.Config, reservations),
+		dhcpreservation.DHCPDKey:   dhcpreservation.RenderDHCPD(r.Config, reservations),
+	}
+
+	cm := &corev1.ConfigMap{}
+	err = r.Get(ctx, r.ConfigMap, cm)
+	if err != nil {
+		if apierrors.IsNotFound(err) == false {
+			return err
+		}
+
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: r.ConfigMap.Namespace,
+				Name:      r.ConfigMap.Name,
+			},
+			Data: data,
+		}
+		return r.Create(ctx, cm)
+	}
+
+	if cm.Data[dhcpreservation.DnsmasqKey] == data[dhcpreservation.DnsmasqKey] && cm.Data[dhcpreservation.DHCPDKey] == data[dhcpreservation.DHCPDKey] {
+		return nil
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	for key, value := range data {
+		cm.Data[key] = value
+	}
+	return r.Update(ctx, cm)
+}
+
+// reservationsForHardware returns a reservation for every discovered nic of the hardware
+// nics that request an address from a DHCPNetwork get it as a fixed address
+// cluster hardware has no namespace so its reservations are only named after the hardware
+func reservationsForHardware(hw baremetalv1alpha1.Hardware) []dhcpreservation.Reservation {
+	status := hw.GetHardwareStatus()
+	if status.Hardware == nil {
+		return nil
+	}
+
+	var reservations []dhcpreservation.Reservation
+	for _, discoveredNIC := range status.Hardware.NICS {
+		if len(discoveredNIC.MAC) == 0 {
+			continue
+		}
+
+		name := fmt.Sprintf("%s-%s", hw.GetName(), discoveredNIC.Name)
+		if len(hw.GetNamespace()) > 0 {
+			name = fmt.Sprintf("%s-%s", hw.GetNamespace(), name)
+		}
+
+		reservations = append(reservations, dhcpreservation.Reservation{
+			Name: name,
+			MAC:  discoveredNIC.MAC,
+			IP:   requestedDHCPAddress(hw.GetHardwareSpec(), discoveredNIC.Name),
+		})
+	}
+
+	return reservations
+}
+
+// helper method to find the ipv4 address requested from a DHCPNetwork by the nic
+// bonds only use the address on their first interface
+func requestedDHCPAddress(spec *baremetalv1alpha1.BareMetalHardwareSpec, nicName string) string {
+	for i := range spec.NICS {
+		nic := &spec.NICS[i]
+
+		if nic.Name != nicName && (nic.Bond == nil || len(nic.Bond.Interfaces) == 0 || nic.Bond.Interfaces[0] != nicName) {
+			continue
+		}
+
+		for _, networkRef := range nic.NetworkRefs() {
+			if networkRef.Group != baremetalv1alpha1.GroupVersion.Group || networkRef.Kind != "DHCPNetwork" {
+				continue
+			}
+
+			ip := net.ParseIP(nic.RequestedIP(networkRef))
+			if ip != nil && ip.To4() != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	return ""
+}
+
+func (r *DHCPReservationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("DHCPReservation").
+		For(&baremetalv1alpha1.BareMetalHardware{}).
+		Watches(&source.Kind{Type: &baremetalv1alpha1.ClusterBareMetalHardware{}}, &handler.EnqueueRequestForObject{}).
+		Complete(r)
+}
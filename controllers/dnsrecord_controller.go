@@ -0,0 +1,263 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/pkg/externaldns"
+)
+
+// DNSRecordReconciler publishes dns records for the addresses of BareMetalInstances
+// as external-dns DNSEndpoint objects
+type DNSRecordReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=baremetalinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=baremetalendpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=baremetal.com.rmb938,resources=baremetalnetworks,verbs=get;list;watch
+// +kubebuilder:rbac:groups=externaldns.k8s.io,resources=dnsendpoints,verbs=get;list;watch;create;update;patch;delete
+
+func (r *DNSRecordReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("baremetalinstance", req.NamespacedName)
+
+	bmi := &baremetalv1alpha1.BareMetalInstance{}
+	if err := r.Client.Get(ctx, req.NamespacedName, bmi); err != nil {
+		err = client.IgnoreNotFound(err)
+		if err != nil {
+			log.Error(err, "failed to retrieve BareMetalInstance resource")
+		}
+		// the DNSEndpoint is owned by the instance so it is garbage collected
+		return ctrl.Result{}, err
+	}
+
+	records := externaldns.NewRecords()
+
+	// records are removed once the instance is deleted, the endpoints release their addresses afterwards
+	if bmi.DeletionTimestamp.IsZero() {
+		err := r.instanceRecords(ctx, bmi, records)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	endpoints := records.Endpoints()
+
+	dnsEndpoint := externaldns.NewDNSEndpoint()
+	err := r.Get(ctx, types.NamespacedName{Namespace: bmi.Namespace, Name: bmi.Name}, dnsEndpoint)
+	if err != nil {
+		if apierrors.IsNotFound(err) == false {
+			return ctrl.Result{}, err
+		}
+		dnsEndpoint = nil
+	}
+
+	// nothing to publish so remove the records
+	if len(endpoints) == 0 {
+		if dnsEndpoint == nil || dnsEndpoint.GetDeletionTimestamp() != nil {
+			return ctrl.Result{}, nil
+		}
+
+		err := r.Delete(ctx, dnsEndpoint)
+		if err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceDNSRecordsRemovedEventReason, "DNS records have been removed")
+		return ctrl.Result{}, nil
+	}
+
+	if dnsEndpoint == nil {
+		dnsEndpoint = externaldns.NewDNSEndpoint()
+		dnsEndpoint.SetNamespace(bmi.Namespace)
+		dnsEndpoint.SetName(bmi.Name)
+		dnsEndpoint.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion:         baremetalv1alpha1.GroupVersion.String(),
+				Kind:               "BareMetalInstance",
+				Name:               bmi.Name,
+				UID:                bmi.UID,
+				Controller:         func(b bool) *bool { return &b }(true),
+				BlockOwnerDeletion: func(b bool) *bool { return &b }(false),
+			},
+		})
+		err := externaldns.SetEndpoints(dnsEndpoint, endpoints)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		err = r.Create(ctx, dnsEndpoint)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceDNSRecordsUpdatedEventReason, "DNS records have been published for %s", recordNames(endpoints))
+		return ctrl.Result{}, nil
+	}
+
+	existingEndpoints, err := externaldns.GetEndpoints(dnsEndpoint)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if reflect.DeepEqual(existingEndpoints, endpoints) {
+		return ctrl.Result{}, nil
+	}
+
+	err = externaldns.SetEndpoints(dnsEndpoint, endpoints)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	err = r.Update(ctx, dnsEndpoint)
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Eventf(bmi, corev1.EventTypeNormal, baremetalv1alpha1.BareMetalInstanceDNSRecordsUpdatedEventReason, "DNS records have been published for %s", recordNames(endpoints))
+
+	return ctrl.Result{}, nil
+}
+
+// helper method to collect the records for the addressed endpoints of the instance
+func (r *DNSRecordReconciler) instanceRecords(ctx context.Context, bmi *baremetalv1alpha1.BareMetalInstance, records *externaldns.Records) error {
+	bmeList := &baremetalv1alpha1.BareMetalEndpointList{}
+	err := r.List(ctx, bmeList, client.InNamespace(bmi.Namespace), client.MatchingLabels{baremetalv1alpha1.BareMetalEndpointInstanceLabel: bmi.Name})
+	if err != nil {
+		return err
+	}
+
+	networks := make(map[string]*baremetalv1alpha1.BareMetalNetwork)
+	for _, bme := range bmeList.Items {
+		ownedByUs := false
+		for _, ownerRef := range bme.OwnerReferences {
+			if ownerRef.UID == bmi.UID {
+				ownedByUs = true
+			}
+		}
+		if ownedByUs == false {
+			continue
+		}
+
+		// only publish addresses that are in use, released addresses are removed
+		if bme.DeletionTimestamp.IsZero() == false || bme.Status.Phase != baremetalv1alpha1.BareMetalEndpointStatusPhaseAddressed || bme.Status.Address == nil {
+			continue
+		}
+
+		// only BareMetalNetworks have a dns domain
+		if bme.Spec.NetworkRef.Group != baremetalv1alpha1.GroupVersion.Group || bme.Spec.NetworkRef.Kind != "BareMetalNetwork" {
+			continue
+		}
+
+		ip := net.ParseIP(bme.Status.Address.IP)
+		if ip == nil {
+			continue
+		}
+
+		bmn, ok := networks[bme.Spec.NetworkRef.Name]
+		if ok == false {
+			bmn = &baremetalv1alpha1.BareMetalNetwork{}
+			err := r.Get(ctx, types.NamespacedName{Namespace: bmi.Namespace, Name: bme.Spec.NetworkRef.Name}, bmn)
+			if err != nil {
+				if apierrors.IsNotFound(err) == false {
+					return err
+				}
+				bmn = nil
+			}
+			networks[bme.Spec.NetworkRef.Name] = bmn
+		}
+
+		if bmn == nil || bmn.Spec.DNS == nil {
+			continue
+		}
+
+		records.Add(bmi.Name+"."+strings.TrimSuffix(bmn.Spec.DNS.Domain, "."), ip, bmn.Spec.DNS.TTL)
+	}
+
+	return nil
+}
+
+// helper method to list the forward record names for events
+func recordNames(endpoints []externaldns.Endpoint) string {
+	var names []string
+	for _, endpoint := range endpoints {
+		if endpoint.RecordType == externaldns.RecordTypePTR {
+			continue
+		}
+		names = append(names, endpoint.DNSName)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func (r *DNSRecordReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("DNSRecord").
+		For(&baremetalv1alpha1.BareMetalInstance{}).
+		// This will cause endpoint address changes to update the records of the instance
+		Watches(&source.Kind{Type: &baremetalv1alpha1.BareMetalEndpoint{}}, &handler.EnqueueRequestForOwner{
+			OwnerType:    &baremetalv1alpha1.BareMetalInstance{},
+			IsController: true,
+		}).
+		// This will cause dns changes on the network to update the records of all instances using it
+		Watches(&source.Kind{Type: &baremetalv1alpha1.BareMetalNetwork{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+			bmn := a.Object.(*baremetalv1alpha1.BareMetalNetwork)
+			var req []reconcile.Request
+
+			// the field index is added by the BareMetalEndpointNetwork controller
+			bmeList := &baremetalv1alpha1.BareMetalEndpointList{}
+			err := r.List(context.Background(), bmeList, client.InNamespace(bmn.Namespace), client.MatchingFields{"spec.networkRef.group,kind,name": baremetalv1alpha1.GroupVersion.Group + ".BareMetalNetwork." + bmn.Name})
+			if err != nil {
+				r.Log.Error(err, "failed to list BareMetalEndpoints for BareMetalNetwork", "baremetalnetwork", bmn.Name)
+				return req
+			}
+
+			instances := make(map[string]bool)
+			for _, bme := range bmeList.Items {
+				instanceName, ok := bme.Labels[baremetalv1alpha1.BareMetalEndpointInstanceLabel]
+				if ok == false || instances[instanceName] {
+					continue
+				}
+				instances[instanceName] = true
+
+				req = append(req, reconcile.Request{NamespacedName: types.NamespacedName{
+					Namespace: bmn.Namespace,
+					Name:      instanceName,
+				}})
+			}
+
+			return req
+		})}).
+		Complete(r)
+}
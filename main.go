@@ -18,10 +18,13 @@ package main
 import (
 	"flag"
 	"math/rand"
+	"net"
 	"os"
+	"strings"
 	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/utils/clock"
@@ -33,7 +36,10 @@ import (
 	"github.com/rmb938/kube-baremetal/controllers"
 	"github.com/rmb938/kube-baremetal/controllers/baremetalendpoint"
 	"github.com/rmb938/kube-baremetal/controllers/baremetalinstance"
+	"github.com/rmb938/kube-baremetal/pkg/dhcpreservation"
 	"github.com/rmb938/kube-baremetal/pkg/discovery"
+	"github.com/rmb938/kube-baremetal/pkg/pxe"
+	"github.com/rmb938/kube-baremetal/pkg/scheduler"
 	"github.com/rmb938/kube-baremetal/webhooks"
 	// +kubebuilder:scaffold:imports
 )
@@ -53,9 +59,35 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var endpointNetworkWorkers int
+	var pxeServerIP string
+	var tftpRoot string
+	var dhcpReservationsConfigMap string
+	var dhcpReservationsKeaURL string
+	var dhcpReservationsKeaSubnetID int
+	var dhcpReservationsNextServer string
+	var dhcpReservationsBootFilename string
+	var dnsEndpoints bool
+	var schedulerConfig string
+	var schedulerWorkers int
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&endpointNetworkWorkers, "endpoint-network-workers", 4, "The number of endpoints that can be addressed at the same time.")
+	flag.StringVar(&pxeServerIP, "pxe-server-ip", "",
+		"The ip address of the manager given to pxe clients. When set the proxyDHCP and tftp servers are started to pxe boot hardware.")
+	flag.StringVar(&tftpRoot, "tftp-root", "/discovery_files/tftp", "The directory with the ipxe binaries that are served over tftp.")
+	flag.StringVar(&dhcpReservationsConfigMap, "dhcp-reservations-configmap", "",
+		"The namespace/name of a config map to render dnsmasq and isc dhcpd host reservations for all hardware into.")
+	flag.StringVar(&dhcpReservationsKeaURL, "dhcp-reservations-kea-url", "", "The url of a kea control agent to push host reservations for all hardware to.")
+	flag.IntVar(&dhcpReservationsKeaSubnetID, "dhcp-reservations-kea-subnet-id", 0, "The kea subnet id to add host reservations to, 0 adds global reservations.")
+	flag.StringVar(&dhcpReservationsNextServer, "dhcp-reservations-next-server", "",
+		"The tftp server to set in host reservations, defaults to the pxe server ip.")
+	flag.StringVar(&dhcpReservationsBootFilename, "dhcp-reservations-boot-filename", "undionly.kpxe", "The ipxe binary to set in host reservations.")
+	flag.StringVar(&schedulerConfig, "scheduler-config", "", "The file with the scheduler filter and score plugins, when not set the defaults are used.")
+	flag.IntVar(&schedulerWorkers, "scheduler-workers", 4, "The number of instances that can be scheduled at the same time.")
+	flag.BoolVar(&dnsEndpoints, "dns-endpoints", false,
+		"Publish dns records for instances as external-dns DNSEndpoint objects, requires the external-dns DNSEndpoint crd.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
@@ -95,6 +127,19 @@ func main() {
 		os.Exit(1)
 	}
 	(&webhooks.BareMetalHardwareWebhook{}).SetupWebhookWithManager(mgr)
+	if err = (&controllers.ClusterBareMetalHardwareReconciler{
+		BareMetalHardwareReconciler: controllers.BareMetalHardwareReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ClusterBareMetalHardware"),
+			Scheme:   mgr.GetScheme(),
+			Clock:    clock.RealClock{},
+			Recorder: mgr.GetEventRecorderFor("ClusterBareMetalHardware"),
+		},
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterBareMetalHardware")
+		os.Exit(1)
+	}
+	(&webhooks.ClusterBareMetalHardwareWebhook{}).SetupWebhookWithManager(mgr)
 	if err = (&baremetalinstance.Controller{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("BareMetalInstance"),
@@ -103,12 +148,27 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "BareMetalInstance")
 		os.Exit(1)
 	}
+	schedulerFrameworkConfig := scheduler.DefaultConfig()
+	if len(schedulerConfig) > 0 {
+		schedulerFrameworkConfig, err = scheduler.LoadConfig(schedulerConfig)
+		if err != nil {
+			setupLog.Error(err, "unable to load scheduler config", "scheduler-config", schedulerConfig)
+			os.Exit(1)
+		}
+	}
+	schedulerFramework, err := scheduler.NewFramework(schedulerFrameworkConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to create scheduler framework")
+		os.Exit(1)
+	}
 	if err = (&baremetalinstance.Scheduler{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("BareMetalInstanceScheduler"),
-		Scheme:   mgr.GetScheme(),
-		Clock:    clock.RealClock{},
-		Recorder: mgr.GetEventRecorderFor("BareMetalHardwareScheduler"),
+		Client:    mgr.GetClient(),
+		Log:       ctrl.Log.WithName("controllers").WithName("BareMetalInstanceScheduler"),
+		Scheme:    mgr.GetScheme(),
+		Clock:     clock.RealClock{},
+		Recorder:  mgr.GetEventRecorderFor("BareMetalHardwareScheduler"),
+		Framework: schedulerFramework,
+		Workers:   schedulerWorkers,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BareMetalInstanceScheduler")
 		os.Exit(1)
@@ -123,7 +183,25 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "BareMetalInstanceProvisioner")
 		os.Exit(1)
 	}
+	if err = (&baremetalinstance.Evictor{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("BareMetalInstanceEvictor"),
+		Scheme:   mgr.GetScheme(),
+		Clock:    clock.RealClock{},
+		Recorder: mgr.GetEventRecorderFor("BareMetalInstanceEvictor"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BareMetalInstanceEvictor")
+		os.Exit(1)
+	}
 	(&webhooks.BareMetalInstanceWebhook{}).SetupWebhookWithManager(mgr)
+	if err = (&controllers.BareMetalQuotaReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("BareMetalQuota"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BareMetalQuota")
+		os.Exit(1)
+	}
 	if err = (&baremetalendpoint.Controller{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("BareMetalEndpoint"),
@@ -133,25 +211,99 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&baremetalendpoint.Network{
-		Client:   mgr.GetClient(),
-		Log:      ctrl.Log.WithName("controllers").WithName("BareMetalEndpointNetwork"),
-		Scheme:   mgr.GetScheme(),
-		Clock:    clock.RealClock{},
-		Recorder: mgr.GetEventRecorderFor("BareMetalEndpointNetwork"),
+		Client:                  mgr.GetClient(),
+		Log:                     ctrl.Log.WithName("controllers").WithName("BareMetalEndpointNetwork"),
+		Scheme:                  mgr.GetScheme(),
+		Clock:                   clock.RealClock{},
+		Recorder:                mgr.GetEventRecorderFor("BareMetalEndpointNetwork"),
+		MaxConcurrentReconciles: endpointNetworkWorkers,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BareMetalEndpointNetwork")
 		os.Exit(1)
 	}
 	(&webhooks.BareMetalEndpointWebhook{}).SetupWebhookWithManager(mgr)
 	if err = (&controllers.BareMetalNetworkReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("BareMetalNetwork"),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("BareMetalNetwork"),
+		Scheme:   mgr.GetScheme(),
+		Clock:    clock.RealClock{},
+		Recorder: mgr.GetEventRecorderFor("BareMetalNetwork"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BareMetalNetwork")
 		os.Exit(1)
 	}
 	(&webhooks.BareMetalNetworkWebhook{}).SetupWebhookWithManager(mgr)
+	if err = (&baremetalendpoint.HTTPIPAM{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("BareMetalEndpointHTTPIPAM"),
+		Scheme:   mgr.GetScheme(),
+		Clock:    clock.RealClock{},
+		Recorder: mgr.GetEventRecorderFor("BareMetalEndpointHTTPIPAM"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BareMetalEndpointHTTPIPAM")
+		os.Exit(1)
+	}
+	(&webhooks.HTTPIPAMNetworkWebhook{}).SetupWebhookWithManager(mgr)
+	if err = (&baremetalendpoint.DHCP{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("BareMetalEndpointDHCP"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("BareMetalEndpointDHCP"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BareMetalEndpointDHCP")
+		os.Exit(1)
+	}
+	(&webhooks.DHCPNetworkWebhook{}).SetupWebhookWithManager(mgr)
+	if len(dhcpReservationsConfigMap) > 0 || len(dhcpReservationsKeaURL) > 0 {
+		if len(dhcpReservationsNextServer) == 0 {
+			dhcpReservationsNextServer = pxeServerIP
+		}
+		if net.ParseIP(dhcpReservationsNextServer) == nil {
+			setupLog.Error(nil, "dhcp reservations next server must be an ip address", "dhcp-reservations-next-server", dhcpReservationsNextServer)
+			os.Exit(1)
+		}
+
+		reconciler := &controllers.DHCPReservationReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("DHCPReservation"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("DHCPReservation"),
+			Config: dhcpreservation.Config{
+				NextServer:   dhcpReservationsNextServer,
+				BootFilename: dhcpReservationsBootFilename,
+				BootURL:      "http://" + net.JoinHostPort(dhcpReservationsNextServer, "8081") + "/ipxe/boot",
+			},
+		}
+
+		if len(dhcpReservationsConfigMap) > 0 {
+			parts := strings.SplitN(dhcpReservationsConfigMap, "/", 2)
+			if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+				setupLog.Error(nil, "dhcp reservations config map must be namespace/name", "dhcp-reservations-configmap", dhcpReservationsConfigMap)
+				os.Exit(1)
+			}
+			reconciler.ConfigMap = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+		}
+
+		if len(dhcpReservationsKeaURL) > 0 {
+			reconciler.Kea = dhcpreservation.NewKeaClient(dhcpReservationsKeaURL, dhcpReservationsKeaSubnetID)
+		}
+
+		if err = reconciler.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "DHCPReservation")
+			os.Exit(1)
+		}
+	}
+	if dnsEndpoints {
+		if err = (&controllers.DNSRecordReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("DNSRecord"),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("DNSRecord"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "DNSRecord")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	signalHandler := ctrl.SetupSignalHandler()
@@ -165,6 +317,32 @@ func main() {
 		os.Exit(1)
 	}
 
+	if len(pxeServerIP) > 0 {
+		serverIP := net.ParseIP(pxeServerIP)
+		if serverIP == nil || serverIP.To4() == nil {
+			setupLog.Error(nil, "pxe server ip must be an ipv4 address", "pxe-server-ip", pxeServerIP)
+			os.Exit(1)
+		}
+
+		proxyDHCPServer := pxe.NewProxyDHCPServer(serverIP, "http://"+net.JoinHostPort(serverIP.String(), "8081")+"/ipxe/boot")
+		err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+			return proxyDHCPServer.Run(stop)
+		}))
+		if err != nil {
+			setupLog.Error(err, "unable to add proxyDHCP server to manager")
+			os.Exit(1)
+		}
+
+		tftpServer := pxe.NewTFTPServer(":69", tftpRoot)
+		err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+			return tftpServer.Run(stop)
+		}))
+		if err != nil {
+			setupLog.Error(err, "unable to add tftp server to manager")
+			os.Exit(1)
+		}
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(signalHandler); err != nil {
 		setupLog.Error(err, "problem running manager")
@@ -0,0 +1,181 @@
+package dhcpreservation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// kea command results
+const (
+	keaResultSuccess     = 0
+	keaResultEmpty       = 3
+	keaDefaultTimeout    = 30 * time.Second
+	keaHWAddressIdentity = "hw-address"
+)
+
+// KeaClient manages host reservations using the kea control agent http api
+// the host_cmds hook must be loaded into the dhcp4 server
+type KeaClient struct {
+	url        string
+	subnetID   int
+	httpClient *http.Client
+}
+
+func NewKeaClient(url string, subnetID int) *KeaClient {
+	return &KeaClient{
+		url:      url,
+		subnetID: subnetID,
+		httpClient: &http.Client{
+			Timeout: keaDefaultTimeout,
+		},
+	}
+}
+
+type keaCommand struct {
+	Command   string      `json:"command"`
+	Service   []string    `json:"service"`
+	Arguments interface{} `json:"arguments"`
+}
+
+type keaResponse struct {
+	Result    int             `json:"result"`
+	Text      string          `json:"text"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type keaHostIdentifier struct {
+	SubnetID       int    `json:"subnet-id"`
+	IdentifierType string `json:"identifier-type"`
+	Identifier     string `json:"identifier"`
+}
+
+type keaHost struct {
+	SubnetID     int    `json:"subnet-id"`
+	HWAddress    string `json:"hw-address,omitempty"`
+	IPAddress    string `json:"ip-address,omitempty"`
+	Hostname     string `json:"hostname,omitempty"`
+	NextServer   string `json:"next-server,omitempty"`
+	BootFileName string `json:"boot-file-name,omitempty"`
+}
+
+// Ensure adds the reservation, replacing it if it exists with different settings
+func (c *KeaClient) Ensure(ctx context.Context, config Config, reservation Reservation) error {
+	want := keaHost{
+		SubnetID:     c.subnetID,
+		HWAddress:    strings.ToLower(reservation.MAC),
+		IPAddress:    reservation.IP,
+		Hostname:     invalidNameChars.ReplaceAllString(reservation.Name, "-"),
+		NextServer:   config.NextServer,
+		BootFileName: config.BootFilename,
+	}
+
+	resp, err := c.command(ctx, "reservation-get", c.identifier(reservation.MAC))
+	if err != nil {
+		return err
+	}
+
+	switch resp.Result {
+	case keaResultSuccess:
+		existing := keaHost{}
+		if err := json.Unmarshal(resp.Arguments, &existing); err != nil {
+			return err
+		}
+		if existing.IPAddress == "0.0.0.0" {
+			existing.IPAddress = ""
+		}
+		if existing.IPAddress == want.IPAddress && existing.NextServer == want.NextServer && existing.BootFileName == want.BootFileName && existing.Hostname == want.Hostname {
+			return nil
+		}
+
+		err = c.Delete(ctx, reservation.MAC)
+		if err != nil {
+			return err
+		}
+	case keaResultEmpty:
+		break
+	default:
+		return fmt.Errorf("kea reservation-get failed: %s", resp.Text)
+	}
+
+	resp, err = c.command(ctx, "reservation-add", map[string]interface{}{"reservation": want})
+	if err != nil {
+		return err
+	}
+	if resp.Result != keaResultSuccess {
+		return fmt.Errorf("kea reservation-add failed: %s", resp.Text)
+	}
+
+	return nil
+}
+
+// Delete removes the reservation for the mac, reservations that don't exist are ignored
+func (c *KeaClient) Delete(ctx context.Context, mac string) error {
+	resp, err := c.command(ctx, "reservation-del", c.identifier(mac))
+	if err != nil {
+		return err
+	}
+
+	if resp.Result != keaResultSuccess && resp.Result != keaResultEmpty {
+		return fmt.Errorf("kea reservation-del failed: %s", resp.Text)
+	}
+
+	return nil
+}
+
+func (c *KeaClient) identifier(mac string) keaHostIdentifier {
+	return keaHostIdentifier{
+		SubnetID:       c.subnetID,
+		IdentifierType: keaHWAddressIdentity,
+		Identifier:     strings.ToLower(mac),
+	}
+}
+
+func (c *KeaClient) command(ctx context.Context, command string, arguments interface{}) (*keaResponse, error) {
+	data, err := json.Marshal(&keaCommand{
+		Command:   command,
+		Service:   []string{"dhcp4"},
+		Arguments: arguments,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("kea control agent responded with %d: %s", resp.StatusCode, strings.TrimSpace(string(respData)))
+	}
+
+	// the control agent responds with a list, one item per service
+	var responses []keaResponse
+	if err := json.Unmarshal(respData, &responses); err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("kea control agent returned an empty response for %s", command)
+	}
+
+	return &responses[0], nil
+}
@@ -0,0 +1,226 @@
+package dhcpreservation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// fakeKea is a kea control agent that responds to each command with the configured response
+type fakeKea struct {
+	t *testing.T
+
+	status    int
+	responses map[string]keaResponse
+
+	commands  []string
+	arguments map[string]json.RawMessage
+}
+
+func (k *fakeKea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	command := struct {
+		Command   string          `json:"command"`
+		Service   []string        `json:"service"`
+		Arguments json.RawMessage `json:"arguments"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&command); err != nil {
+		k.t.Errorf("error decoding command: %v", err)
+	}
+	if reflect.DeepEqual(command.Service, []string{"dhcp4"}) == false {
+		k.t.Errorf("expected the dhcp4 service got %v", command.Service)
+	}
+
+	k.commands = append(k.commands, command.Command)
+	k.arguments[command.Command] = command.Arguments
+
+	if k.status != 0 {
+		http.Error(w, "agent failed", k.status)
+		return
+	}
+
+	response, ok := k.responses[command.Command]
+	if ok == false {
+		response = keaResponse{Result: 2, Text: "unexpected command"}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode([]keaResponse{response}); err != nil {
+		k.t.Errorf("error encoding response: %v", err)
+	}
+}
+
+func newFakeKea(t *testing.T) (*fakeKea, *httptest.Server) {
+	kea := &fakeKea{t: t, responses: make(map[string]keaResponse), arguments: make(map[string]json.RawMessage)}
+	return kea, httptest.NewServer(kea)
+}
+
+func TestKeaEnsure(t *testing.T) {
+	reservation := Reservation{Name: "default-hardware-1-eth0", MAC: "DE:AD:BE:EF:00:01", IP: "10.0.0.10"}
+	existing := func(host string) keaResponse {
+		return keaResponse{Result: keaResultSuccess, Arguments: json.RawMessage(host)}
+	}
+
+	tests := []struct {
+		name        string
+		reservation Reservation
+		status      int
+		responses   map[string]keaResponse
+		commands    []string
+		err         bool
+	}{
+		{
+			name:        "added when it doesn't exist",
+			reservation: reservation,
+			responses: map[string]keaResponse{
+				"reservation-get": {Result: keaResultEmpty},
+				"reservation-add": {Result: keaResultSuccess},
+			},
+			commands: []string{"reservation-get", "reservation-add"},
+		},
+		{
+			name:        "unchanged",
+			reservation: reservation,
+			responses: map[string]keaResponse{
+				"reservation-get": existing(`{"subnet-id": 1, "hw-address": "de:ad:be:ef:00:01", "ip-address": "10.0.0.10", "hostname": "default-hardware-1-eth0", "next-server": "10.0.0.2", "boot-file-name": "undionly.kpxe"}`),
+			},
+			commands: []string{"reservation-get"},
+		},
+		{
+			name:        "unchanged without an address",
+			reservation: Reservation{Name: "default-hardware-1-eth0", MAC: "de:ad:be:ef:00:01"},
+			responses: map[string]keaResponse{
+				"reservation-get": existing(`{"subnet-id": 1, "hw-address": "de:ad:be:ef:00:01", "ip-address": "0.0.0.0", "hostname": "default-hardware-1-eth0", "next-server": "10.0.0.2", "boot-file-name": "undionly.kpxe"}`),
+			},
+			commands: []string{"reservation-get"},
+		},
+		{
+			name:        "replaced when it changed",
+			reservation: reservation,
+			responses: map[string]keaResponse{
+				"reservation-get": existing(`{"subnet-id": 1, "hw-address": "de:ad:be:ef:00:01", "ip-address": "10.0.0.11", "hostname": "default-hardware-1-eth0", "next-server": "10.0.0.2", "boot-file-name": "undionly.kpxe"}`),
+				"reservation-del": {Result: keaResultSuccess},
+				"reservation-add": {Result: keaResultSuccess},
+			},
+			commands: []string{"reservation-get", "reservation-del", "reservation-add"},
+		},
+		{
+			name:        "get failed",
+			reservation: reservation,
+			responses: map[string]keaResponse{
+				"reservation-get": {Result: 1, Text: "host_cmds hook not loaded"},
+			},
+			commands: []string{"reservation-get"},
+			err:      true,
+		},
+		{
+			name:        "delete failed",
+			reservation: reservation,
+			responses: map[string]keaResponse{
+				"reservation-get": existing(`{"subnet-id": 1, "hw-address": "de:ad:be:ef:00:01", "ip-address": "10.0.0.11"}`),
+				"reservation-del": {Result: 1, Text: "database error"},
+			},
+			commands: []string{"reservation-get", "reservation-del"},
+			err:      true,
+		},
+		{
+			name:        "add failed",
+			reservation: reservation,
+			responses: map[string]keaResponse{
+				"reservation-get": {Result: keaResultEmpty},
+				"reservation-add": {Result: 1, Text: "address is reserved for another host"},
+			},
+			commands: []string{"reservation-get", "reservation-add"},
+			err:      true,
+		},
+		{
+			name:        "agent error",
+			reservation: reservation,
+			status:      http.StatusInternalServerError,
+			commands:    []string{"reservation-get"},
+			err:         true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kea, server := newFakeKea(t)
+			defer server.Close()
+			kea.status = test.status
+			kea.responses = test.responses
+
+			c := NewKeaClient(server.URL, 1)
+			err := c.Ensure(context.Background(), testConfig, test.reservation)
+			if test.err && err == nil {
+				t.Errorf("expected an error")
+			}
+			if test.err == false && err != nil {
+				t.Errorf("error ensuring reservation: %v", err)
+			}
+
+			if reflect.DeepEqual(kea.commands, test.commands) == false {
+				t.Fatalf("expected commands %v got %v", test.commands, kea.commands)
+			}
+
+			// reservations are looked up and deleted by the lower case mac
+			identifier := keaHostIdentifier{}
+			if err := json.Unmarshal(kea.arguments["reservation-get"], &identifier); err != nil {
+				t.Fatalf("error decoding arguments: %v", err)
+			}
+			expectedIdentifier := keaHostIdentifier{SubnetID: 1, IdentifierType: keaHWAddressIdentity, Identifier: "de:ad:be:ef:00:01"}
+			if identifier != expectedIdentifier {
+				t.Errorf("expected identifier %v got %v", expectedIdentifier, identifier)
+			}
+
+			if added, ok := kea.arguments["reservation-add"]; ok {
+				arguments := struct {
+					Reservation keaHost `json:"reservation"`
+				}{}
+				if err := json.Unmarshal(added, &arguments); err != nil {
+					t.Fatalf("error decoding arguments: %v", err)
+				}
+				expectedHost := keaHost{
+					SubnetID:     1,
+					HWAddress:    "de:ad:be:ef:00:01",
+					IPAddress:    test.reservation.IP,
+					Hostname:     "default-hardware-1-eth0",
+					NextServer:   testConfig.NextServer,
+					BootFileName: testConfig.BootFilename,
+				}
+				if arguments.Reservation != expectedHost {
+					t.Errorf("expected host %v got %v", expectedHost, arguments.Reservation)
+				}
+			}
+		})
+	}
+}
+
+func TestKeaDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		result int
+		err    bool
+	}{
+		{name: "deleted", result: keaResultSuccess},
+		{name: "doesn't exist", result: keaResultEmpty},
+		{name: "failed", result: 1, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kea, server := newFakeKea(t)
+			defer server.Close()
+			kea.responses["reservation-del"] = keaResponse{Result: test.result}
+
+			err := NewKeaClient(server.URL, 1).Delete(context.Background(), "de:ad:be:ef:00:01")
+			if test.err && err == nil {
+				t.Errorf("expected an error")
+			}
+			if test.err == false && err != nil {
+				t.Errorf("error deleting reservation: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package dhcpreservation
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+const (
+	// DnsmasqKey is the config map key with the dnsmasq config
+	DnsmasqKey = "dnsmasq.conf"
+
+	// DHCPDKey is the config map key with the isc dhcpd config
+	DHCPDKey = "dhcpd.conf"
+
+	// the dnsmasq tag set on reserved hosts
+	dnsmasqTag = "kube-baremetal"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9-]`)
+
+// Reservation is a dhcp host reservation for a single nic
+type Reservation struct {
+	// Unique name of the reservation
+	Name string `json:"name"`
+
+	// The mac address of the nic
+	MAC string `json:"mac"`
+
+	// The fixed address to hand out, when empty the dhcp server picks an address from its pool
+	IP string `json:"ip,omitempty"`
+}
+
+// Config are the boot settings that are added to every reservation
+type Config struct {
+	// The tftp server the pxe rom downloads the boot filename from
+	NextServer string
+
+	// The ipxe binary the pxe rom chain loads
+	BootFilename string
+
+	// The boot script url given to ipxe once it is running
+	BootURL string
+}
+
+// Sort sorts the reservations by name so rendered configs are stable
+func Sort(reservations []Reservation) {
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].Name < reservations[j].Name
+	})
+}
+
+// RenderDnsmasq renders the reservations as dnsmasq dhcp-host entries
+func RenderDnsmasq(config Config, reservations []Reservation) string {
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintln(buf, "# Generated by kube-baremetal, do not edit")
+	fmt.Fprintln(buf, "dhcp-userclass=set:ipxe,iPXE")
+	fmt.Fprintf(buf, "dhcp-boot=tag:%s,tag:!ipxe,%s,,%s\n", dnsmasqTag, config.BootFilename, config.NextServer)
+	fmt.Fprintf(buf, "dhcp-boot=tag:%s,tag:ipxe,%s\n", dnsmasqTag, config.BootURL)
+
+	for _, r := range reservations {
+		if len(r.IP) > 0 {
+			fmt.Fprintf(buf, "dhcp-host=%s,set:%s,%s\n", r.MAC, dnsmasqTag, r.IP)
+		} else {
+			fmt.Fprintf(buf, "dhcp-host=%s,set:%s\n", r.MAC, dnsmasqTag)
+		}
+	}
+
+	return buf.String()
+}
+
+// RenderDHCPD renders the reservations as isc dhcpd host declarations
+func RenderDHCPD(config Config, reservations []Reservation) string {
+	buf := &bytes.Buffer{}
+
+	fmt.Fprintln(buf, "# Generated by kube-baremetal, do not edit")
+
+	for _, r := range reservations {
+		fmt.Fprintf(buf, "host %s {\n", invalidNameChars.ReplaceAllString(r.Name, "-"))
+		fmt.Fprintf(buf, "  hardware ethernet %s;\n", r.MAC)
+		if len(r.IP) > 0 {
+			fmt.Fprintf(buf, "  fixed-address %s;\n", r.IP)
+		}
+		fmt.Fprintf(buf, "  next-server %s;\n", config.NextServer)
+		fmt.Fprintln(buf, "  if exists user-class and option user-class = \"iPXE\" {")
+		fmt.Fprintf(buf, "    filename \"%s\";\n", config.BootURL)
+		fmt.Fprintln(buf, "  } else {")
+		fmt.Fprintf(buf, "    filename \"%s\";\n", config.BootFilename)
+		fmt.Fprintln(buf, "  }")
+		fmt.Fprintln(buf, "}")
+	}
+
+	return buf.String()
+}
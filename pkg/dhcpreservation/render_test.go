@@ -0,0 +1,111 @@
+package dhcpreservation
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testConfig = Config{
+	NextServer:   "10.0.0.2",
+	BootFilename: "undionly.kpxe",
+	BootURL:      "http://10.0.0.2:8080/boot.ipxe",
+}
+
+func TestSort(t *testing.T) {
+	reservations := []Reservation{{Name: "default-c"}, {Name: "default-a"}, {Name: "b"}}
+	Sort(reservations)
+
+	expected := []Reservation{{Name: "b"}, {Name: "default-a"}, {Name: "default-c"}}
+	if reflect.DeepEqual(reservations, expected) == false {
+		t.Errorf("expected %v got %v", expected, reservations)
+	}
+}
+
+func TestRenderDnsmasq(t *testing.T) {
+	tests := []struct {
+		name         string
+		reservations []Reservation
+		expected     string
+	}{
+		{
+			name: "no reservations",
+			expected: `# Generated by kube-baremetal, do not edit
+dhcp-userclass=set:ipxe,iPXE
+dhcp-boot=tag:kube-baremetal,tag:!ipxe,undionly.kpxe,,10.0.0.2
+dhcp-boot=tag:kube-baremetal,tag:ipxe,http://10.0.0.2:8080/boot.ipxe
+`,
+		},
+		{
+			name: "reservations",
+			reservations: []Reservation{
+				{Name: "default-hardware-1-eth0", MAC: "de:ad:be:ef:00:01", IP: "10.0.0.10"},
+				{Name: "default-hardware-2-eth0", MAC: "de:ad:be:ef:00:02"},
+			},
+			expected: `# Generated by kube-baremetal, do not edit
+dhcp-userclass=set:ipxe,iPXE
+dhcp-boot=tag:kube-baremetal,tag:!ipxe,undionly.kpxe,,10.0.0.2
+dhcp-boot=tag:kube-baremetal,tag:ipxe,http://10.0.0.2:8080/boot.ipxe
+dhcp-host=de:ad:be:ef:00:01,set:kube-baremetal,10.0.0.10
+dhcp-host=de:ad:be:ef:00:02,set:kube-baremetal
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if rendered := RenderDnsmasq(testConfig, test.reservations); rendered != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, rendered)
+			}
+		})
+	}
+}
+
+func TestRenderDHCPD(t *testing.T) {
+	tests := []struct {
+		name         string
+		reservations []Reservation
+		expected     string
+	}{
+		{
+			name: "no reservations",
+			expected: `# Generated by kube-baremetal, do not edit
+`,
+		},
+		{
+			name: "reservations",
+			reservations: []Reservation{
+				{Name: "default-hardware-1-eth0", MAC: "de:ad:be:ef:00:01", IP: "10.0.0.10"},
+				{Name: "hardware.2_eth0", MAC: "de:ad:be:ef:00:02"},
+			},
+			expected: `# Generated by kube-baremetal, do not edit
+host default-hardware-1-eth0 {
+  hardware ethernet de:ad:be:ef:00:01;
+  fixed-address 10.0.0.10;
+  next-server 10.0.0.2;
+  if exists user-class and option user-class = "iPXE" {
+    filename "http://10.0.0.2:8080/boot.ipxe";
+  } else {
+    filename "undionly.kpxe";
+  }
+}
+host hardware-2-eth0 {
+  hardware ethernet de:ad:be:ef:00:02;
+  next-server 10.0.0.2;
+  if exists user-class and option user-class = "iPXE" {
+    filename "http://10.0.0.2:8080/boot.ipxe";
+  } else {
+    filename "undionly.kpxe";
+  }
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if rendered := RenderDHCPD(testConfig, test.reservations); rendered != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, rendered)
+			}
+		})
+	}
+}
@@ -126,6 +126,32 @@ func (s *server) Run(stop <-chan struct{}) error {
 	return nil
 }
 
+// helper method to find the BareMetalHardware and ClusterBareMetalHardware with the system uuid
+// the webhooks make the system uuid unique across both kinds so there should only ever be one
+func (s *server) findHardware(ctx context.Context, systemUUID types.UID) ([]baremetalv1alpha1.Hardware, error) {
+	var hardware []baremetalv1alpha1.Hardware
+
+	clusterHardwareList := &baremetalv1alpha1.ClusterBareMetalHardwareList{}
+	err := s.Client.List(ctx, clusterHardwareList, client.MatchingFields{"spec.systemUUID": string(systemUUID)})
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusterHardwareList.Items {
+		hardware = append(hardware, &clusterHardwareList.Items[i])
+	}
+
+	hardwareList := &baremetalv1alpha1.BareMetalHardwareList{}
+	err = s.Client.List(ctx, hardwareList, client.MatchingFields{"spec.systemUUID": string(systemUUID)})
+	if err != nil {
+		return nil, err
+	}
+	for i := range hardwareList.Items {
+		hardware = append(hardware, &hardwareList.Items[i])
+	}
+
+	return hardware, nil
+}
+
 func (s *server) ipxeBoot(c *gin.Context) {
 	systemUUID := c.DefaultQuery("systemUUID", "")
 
@@ -134,8 +160,7 @@ func (s *server) ipxeBoot(c *gin.Context) {
 		return
 	}
 
-	hardwareList := &baremetalv1alpha1.BareMetalHardwareList{}
-	err := s.Client.List(context.Background(), hardwareList, client.MatchingFields{"spec.systemUUID": string(systemUUID)})
+	hardware, err := s.findHardware(context.Background(), types.UID(systemUUID))
 	if err != nil {
 		if apiError, ok := err.(apierrors.APIStatus); ok {
 			if apiError.Status().Code == 0 {
@@ -152,11 +177,11 @@ func (s *server) ipxeBoot(c *gin.Context) {
 	}
 
 	// a single hardware exists with the uuid
-	if len(hardwareList.Items) == 1 {
-		bmh := hardwareList.Items[0]
-		if bmh.Status.InstanceRef != nil {
+	if len(hardware) == 1 {
+		instanceRef := hardware[0].GetHardwareStatus().InstanceRef
+		if instanceRef != nil {
 			bmi := &baremetalv1alpha1.BareMetalInstance{}
-			err := s.Client.Get(context.Background(), types.NamespacedName{Namespace: bmh.Status.InstanceRef.Namespace, Name: bmh.Status.InstanceRef.Name}, bmi)
+			err := s.Client.Get(context.Background(), types.NamespacedName{Namespace: instanceRef.Namespace, Name: instanceRef.Name}, bmi)
 			if err != nil {
 				if apierrors.IsNotFound(err) {
 					bmi = nil
@@ -176,7 +201,7 @@ func (s *server) ipxeBoot(c *gin.Context) {
 				}
 			}
 
-			if bmi != nil && bmi.UID == bmh.Status.InstanceRef.UID {
+			if bmi != nil && bmi.UID == instanceRef.UID {
 				if bmi.Status.Phase == baremetalv1alpha1.BareMetalInstanceStatusPhaseRunning {
 					c.String(http.StatusOK, "#!ipxe\necho Booting into the OS\nsleep 10\nexit 0")
 					return
@@ -226,10 +251,9 @@ func (s *server) ready(c *gin.Context) {
 		return
 	}
 
-	var bmh *baremetalv1alpha1.BareMetalHardware
+	var bmh baremetalv1alpha1.Hardware
 
-	hardwareList := &baremetalv1alpha1.BareMetalHardwareList{}
-	err := s.Client.List(context.Background(), hardwareList, client.MatchingFields{"spec.systemUUID": string(input.SystemUUID)})
+	hardware, err := s.findHardware(context.Background(), input.SystemUUID)
 	if err != nil {
 		if apiError, ok := err.(apierrors.APIStatus); ok {
 			if apiError.Status().Code == 0 {
@@ -245,7 +269,7 @@ func (s *server) ready(c *gin.Context) {
 		return
 	}
 
-	switch len(hardwareList.Items) {
+	switch len(hardware) {
 	case 0:
 		// no hardware found so error 404
 		c.Status(http.StatusNotFound)
@@ -253,7 +277,7 @@ func (s *server) ready(c *gin.Context) {
 		return
 	case 1:
 		// one hardware found so we are done
-		bmh = &hardwareList.Items[0]
+		bmh = hardware[0]
 		break
 	default:
 		// multiple hardware found so error
@@ -262,15 +286,17 @@ func (s *server) ready(c *gin.Context) {
 		return
 	}
 
+	instanceRef := bmh.GetHardwareStatus().InstanceRef
+
 	// hardware has no instance set
-	if bmh.Status.InstanceRef == nil {
+	if instanceRef == nil {
 		c.Status(http.StatusNotFound)
 		c.Abort()
 		return
 	}
 
 	bmi := &baremetalv1alpha1.BareMetalInstance{}
-	if err = s.Client.Get(context.Background(), types.NamespacedName{Namespace: bmh.Status.InstanceRef.Namespace, Name: bmh.Status.InstanceRef.Name}, bmi); err != nil {
+	if err = s.Client.Get(context.Background(), types.NamespacedName{Namespace: instanceRef.Namespace, Name: instanceRef.Name}, bmi); err != nil {
 		if apierrors.IsNotFound(err) {
 			c.Status(http.StatusNotFound)
 			c.Abort()
@@ -292,7 +318,7 @@ func (s *server) ready(c *gin.Context) {
 	}
 
 	// hardware instance doesn't match found instance for some reason
-	if bmi.UID != bmh.Status.InstanceRef.UID {
+	if bmi.UID != instanceRef.UID {
 		c.Status(http.StatusNotFound)
 		c.Abort()
 		return
@@ -355,11 +381,7 @@ func (s *server) discover(c *gin.Context) {
 		return
 	}
 
-	// TODO: find the ClusterBareMetalHardware
-	//  if exists don't do anything else
-
-	hardwareList := &baremetalv1alpha1.BareMetalHardwareList{}
-	err := s.Client.List(context.Background(), hardwareList, client.MatchingFields{"spec.systemUUID": string(input.SystemUUID)})
+	hardware, err := s.findHardware(context.Background(), input.SystemUUID)
 	if err != nil {
 		if apiError, ok := err.(apierrors.APIStatus); ok {
 			if apiError.Status().Code == 0 {
@@ -375,7 +397,7 @@ func (s *server) discover(c *gin.Context) {
 		return
 	}
 
-	switch len(hardwareList.Items) {
+	switch len(hardware) {
 	case 0:
 		// no hardware found so continue
 		break
@@ -0,0 +1,162 @@
+package externaldns
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// the external-dns crd source, the types are defined here so we don't depend on external-dns
+var (
+	GroupVersion = schema.GroupVersion{Group: "externaldns.k8s.io", Version: "v1alpha1"}
+
+	DNSEndpointKind = "DNSEndpoint"
+)
+
+const (
+	RecordTypeA    = "A"
+	RecordTypeAAAA = "AAAA"
+	RecordTypePTR  = "PTR"
+)
+
+// Endpoint is a single dns record of a DNSEndpoint
+type Endpoint struct {
+	DNSName    string   `json:"dnsName"`
+	Targets    []string `json:"targets"`
+	RecordType string   `json:"recordType"`
+	RecordTTL  int64    `json:"recordTTL,omitempty"`
+}
+
+// Records collects the forward and reverse records of hostnames
+type Records struct {
+	endpoints map[string]*Endpoint
+}
+
+func NewRecords() *Records {
+	return &Records{
+		endpoints: make(map[string]*Endpoint),
+	}
+}
+
+// Add adds an A or AAAA record for the hostname and a PTR record for the ip
+func (r *Records) Add(hostname string, ip net.IP, ttl int64) {
+	hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+
+	recordType := RecordTypeAAAA
+	if ip.To4() != nil {
+		recordType = RecordTypeA
+	}
+
+	r.add(hostname, recordType, ip.String(), ttl)
+	r.add(ReverseName(ip), RecordTypePTR, hostname, ttl)
+}
+
+func (r *Records) add(name string, recordType string, target string, ttl int64) {
+	key := recordType + "/" + name
+
+	endpoint, ok := r.endpoints[key]
+	if ok == false {
+		endpoint = &Endpoint{
+			DNSName:    name,
+			RecordType: recordType,
+			RecordTTL:  ttl,
+		}
+		r.endpoints[key] = endpoint
+	}
+
+	for _, t := range endpoint.Targets {
+		if t == target {
+			return
+		}
+	}
+	endpoint.Targets = append(endpoint.Targets, target)
+	sort.Strings(endpoint.Targets)
+
+	// records with multiple targets can only have one ttl so use the lowest
+	if ttl > 0 && (endpoint.RecordTTL == 0 || ttl < endpoint.RecordTTL) {
+		endpoint.RecordTTL = ttl
+	}
+}
+
+// Endpoints returns the records sorted by name and type
+func (r *Records) Endpoints() []Endpoint {
+	endpoints := make([]Endpoint, 0, len(r.endpoints))
+	for _, endpoint := range r.endpoints {
+		endpoints = append(endpoints, *endpoint)
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].DNSName != endpoints[j].DNSName {
+			return endpoints[i].DNSName < endpoints[j].DNSName
+		}
+		return endpoints[i].RecordType < endpoints[j].RecordType
+	})
+
+	return endpoints
+}
+
+// ReverseName returns the in-addr.arpa or ip6.arpa name of the ip
+func ReverseName(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", ip4[3], ip4[2], ip4[1], ip4[0])
+	}
+
+	ip6 := ip.To16()
+	nibbles := make([]string, 0, 32)
+	for i := len(ip6) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, fmt.Sprintf("%x", ip6[i]&0x0f), fmt.Sprintf("%x", ip6[i]>>4))
+	}
+
+	return strings.Join(nibbles, ".") + ".ip6.arpa"
+}
+
+// NewDNSEndpoint returns an empty DNSEndpoint object
+func NewDNSEndpoint() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(GroupVersion.WithKind(DNSEndpointKind))
+	return obj
+}
+
+// SetEndpoints sets the records of the DNSEndpoint
+func SetEndpoints(obj *unstructured.Unstructured, endpoints []Endpoint) error {
+	raw := make([]interface{}, 0, len(endpoints))
+	for i := range endpoints {
+		endpoint, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&endpoints[i])
+		if err != nil {
+			return err
+		}
+		raw = append(raw, endpoint)
+	}
+
+	return unstructured.SetNestedSlice(obj.Object, raw, "spec", "endpoints")
+}
+
+// GetEndpoints returns the records of the DNSEndpoint
+func GetEndpoints(obj *unstructured.Unstructured) ([]Endpoint, error) {
+	raw, _, err := unstructured.NestedSlice(obj.Object, "spec", "endpoints")
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]Endpoint, 0, len(raw))
+	for _, item := range raw {
+		rawEndpoint, ok := item.(map[string]interface{})
+		if ok == false {
+			return nil, fmt.Errorf("DNSEndpoint %s has an invalid endpoint", obj.GetName())
+		}
+
+		endpoint := Endpoint{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawEndpoint, &endpoint)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints, nil
+}
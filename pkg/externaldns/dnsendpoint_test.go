@@ -0,0 +1,161 @@
+package externaldns
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	tests := []struct {
+		name    string
+		ip      string
+		reverse string
+	}{
+		{
+			name:    "ipv4",
+			ip:      "192.168.1.10",
+			reverse: "10.1.168.192.in-addr.arpa",
+		},
+		{
+			name:    "ipv4 mapped ipv6",
+			ip:      "::ffff:10.0.0.1",
+			reverse: "1.0.0.10.in-addr.arpa",
+		},
+		{
+			name:    "ipv6",
+			ip:      "2001:db8::1",
+			reverse: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		},
+		{
+			// from RFC 3596 section 2.5
+			name:    "ipv6 with every nibble",
+			ip:      "4321:0:1:2:3:4:567:89ab",
+			reverse: "b.a.9.8.7.6.5.0.4.0.0.0.3.0.0.0.2.0.0.0.1.0.0.0.0.0.0.0.1.2.3.4.ip6.arpa",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if reverse := ReverseName(net.ParseIP(test.ip)); reverse != test.reverse {
+				t.Errorf("expected %s got %s", test.reverse, reverse)
+			}
+		})
+	}
+}
+
+func TestRecordsAdd(t *testing.T) {
+	type record struct {
+		hostname string
+		ip       string
+		ttl      int64
+	}
+
+	tests := []struct {
+		name      string
+		records   []record
+		endpoints []Endpoint
+	}{
+		{
+			name:    "ipv4",
+			records: []record{{hostname: "Host.Example.com.", ip: "10.0.0.1", ttl: 300}},
+			endpoints: []Endpoint{
+				{DNSName: "1.0.0.10.in-addr.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR, RecordTTL: 300},
+				{DNSName: "host.example.com", Targets: []string{"10.0.0.1"}, RecordType: RecordTypeA, RecordTTL: 300},
+			},
+		},
+		{
+			name:    "ipv6",
+			records: []record{{hostname: "host.example.com", ip: "2001:db8::1"}},
+			endpoints: []Endpoint{
+				{DNSName: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR},
+				{DNSName: "host.example.com", Targets: []string{"2001:db8::1"}, RecordType: RecordTypeAAAA},
+			},
+		},
+		{
+			name: "targets are merged and sorted",
+			records: []record{
+				{hostname: "host.example.com", ip: "10.0.0.2"},
+				{hostname: "host.example.com", ip: "10.0.0.1"},
+				{hostname: "HOST.example.com", ip: "10.0.0.2"},
+			},
+			endpoints: []Endpoint{
+				{DNSName: "1.0.0.10.in-addr.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR},
+				{DNSName: "2.0.0.10.in-addr.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR},
+				{DNSName: "host.example.com", Targets: []string{"10.0.0.1", "10.0.0.2"}, RecordType: RecordTypeA},
+			},
+		},
+		{
+			name: "lowest ttl",
+			records: []record{
+				{hostname: "host.example.com", ip: "10.0.0.1", ttl: 600},
+				{hostname: "host.example.com", ip: "10.0.0.2", ttl: 60},
+				{hostname: "host.example.com", ip: "10.0.0.3", ttl: 300},
+			},
+			endpoints: []Endpoint{
+				{DNSName: "1.0.0.10.in-addr.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR, RecordTTL: 600},
+				{DNSName: "2.0.0.10.in-addr.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR, RecordTTL: 60},
+				{DNSName: "3.0.0.10.in-addr.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR, RecordTTL: 300},
+				{DNSName: "host.example.com", Targets: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, RecordType: RecordTypeA, RecordTTL: 60},
+			},
+		},
+		{
+			name: "ttl is ignored when not set",
+			records: []record{
+				{hostname: "host.example.com", ip: "10.0.0.1"},
+				{hostname: "host.example.com", ip: "10.0.0.2", ttl: 300},
+				{hostname: "host.example.com", ip: "10.0.0.3"},
+			},
+			endpoints: []Endpoint{
+				{DNSName: "1.0.0.10.in-addr.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR},
+				{DNSName: "2.0.0.10.in-addr.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR, RecordTTL: 300},
+				{DNSName: "3.0.0.10.in-addr.arpa", Targets: []string{"host.example.com"}, RecordType: RecordTypePTR},
+				{DNSName: "host.example.com", Targets: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, RecordType: RecordTypeA, RecordTTL: 300},
+			},
+		},
+		{
+			name: "address shared by hostnames",
+			records: []record{
+				{hostname: "b.example.com", ip: "10.0.0.1"},
+				{hostname: "a.example.com", ip: "10.0.0.1"},
+			},
+			endpoints: []Endpoint{
+				{DNSName: "1.0.0.10.in-addr.arpa", Targets: []string{"a.example.com", "b.example.com"}, RecordType: RecordTypePTR},
+				{DNSName: "a.example.com", Targets: []string{"10.0.0.1"}, RecordType: RecordTypeA},
+				{DNSName: "b.example.com", Targets: []string{"10.0.0.1"}, RecordType: RecordTypeA},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records := NewRecords()
+			for _, r := range test.records {
+				records.Add(r.hostname, net.ParseIP(r.ip), r.ttl)
+			}
+
+			if endpoints := records.Endpoints(); reflect.DeepEqual(endpoints, test.endpoints) == false {
+				t.Errorf("expected endpoints %v got %v", test.endpoints, endpoints)
+			}
+		})
+	}
+}
+
+func TestSetGetEndpoints(t *testing.T) {
+	records := NewRecords()
+	records.Add("host.example.com", net.ParseIP("10.0.0.1"), 300)
+	records.Add("host.example.com", net.ParseIP("2001:db8::1"), 300)
+
+	obj := NewDNSEndpoint()
+	if err := SetEndpoints(obj, records.Endpoints()); err != nil {
+		t.Fatalf("error setting endpoints: %v", err)
+	}
+
+	endpoints, err := GetEndpoints(obj)
+	if err != nil {
+		t.Fatalf("error getting endpoints: %v", err)
+	}
+	if reflect.DeepEqual(endpoints, records.Endpoints()) == false {
+		t.Errorf("expected endpoints %v got %v", records.Endpoints(), endpoints)
+	}
+}
@@ -0,0 +1,171 @@
+package httpipam
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is how long to wait for the external ipam when the network does not set a timeout
+const DefaultTimeout = 30 * time.Second
+
+// Network identifies the network object the request is for
+type Network struct {
+	Namespace  string            `json:"namespace"`
+	Name       string            `json:"name"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// Endpoint identifies the endpoint the request is for
+// the uid is stable for the lifetime of the endpoint so it should be used as the allocation key
+type Endpoint struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	UID         string `json:"uid"`
+	MAC         string `json:"mac"`
+	Primary     bool   `json:"primary"`
+	RequestedIP string `json:"requestedIP,omitempty"`
+}
+
+type AllocateRequest struct {
+	Network  Network  `json:"network"`
+	Endpoint Endpoint `json:"endpoint"`
+}
+
+type Route struct {
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway"`
+	Metric      int    `json:"metric,omitempty"`
+}
+
+type AllocateResponse struct {
+	IP          string   `json:"ip"`
+	CIDR        string   `json:"cidr"`
+	Gateway     string   `json:"gateway"`
+	Nameservers []string `json:"nameservers"`
+	Search      []string `json:"search,omitempty"`
+	Routes      []Route  `json:"routes,omitempty"`
+	MTU         int      `json:"mtu,omitempty"`
+}
+
+type ReleaseRequest struct {
+	Network  Network  `json:"network"`
+	Endpoint Endpoint `json:"endpoint"`
+	IP       string   `json:"ip"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// Error is returned when the external ipam responds with a non 2xx status code
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("external ipam responded with %d: %s", e.StatusCode, e.Message)
+}
+
+// IsConflict returns if the error is because the requested address is already allocated
+func IsConflict(err error) bool {
+	httpErr, ok := err.(*Error)
+	return ok && httpErr.StatusCode == http.StatusConflict
+}
+
+// Client talks to an external ipam using the documented http contract
+type Client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func NewClient(url string, token string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &Client{
+		url:   strings.TrimSuffix(url, "/"),
+		token: token,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// Allocate asks the external ipam for an address
+// it must return the same address when called multiple times for the same endpoint uid
+func (c *Client) Allocate(ctx context.Context, req *AllocateRequest) (*AllocateResponse, error) {
+	resp := &AllocateResponse{}
+	err := c.post(ctx, "/allocate", req, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Release tells the external ipam that the address is no longer used
+// addresses that are already released must not cause an error
+func (c *Client) Release(ctx context.Context, req *ReleaseRequest) error {
+	err := c.post(ctx, "/release", req, nil)
+	if httpErr, ok := err.(*Error); ok && httpErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (c *Client) post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errResp := &errorResponse{}
+		if err := json.Unmarshal(respData, errResp); err != nil || len(errResp.Message) == 0 {
+			errResp.Message = http.StatusText(resp.StatusCode)
+		}
+
+		return &Error{
+			StatusCode: resp.StatusCode,
+			Message:    errResp.Message,
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(respData, out)
+}
@@ -0,0 +1,207 @@
+package httpipam
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// fakeIPAM responds to every request with the configured status and body and records the last request
+type fakeIPAM struct {
+	t *testing.T
+
+	status int
+	body   string
+
+	path          string
+	authorization string
+	request       map[string]interface{}
+}
+
+func (f *fakeIPAM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+		f.t.Errorf("unexpected %s request with content type %s", r.Method, r.Header.Get("Content-Type"))
+	}
+
+	f.path = r.URL.Path
+	f.authorization = r.Header.Get("Authorization")
+	f.request = make(map[string]interface{})
+	if err := json.NewDecoder(r.Body).Decode(&f.request); err != nil {
+		f.t.Errorf("error decoding request: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.status)
+	w.Write([]byte(f.body))
+}
+
+func newFakeIPAM(t *testing.T, status int, body string) (*fakeIPAM, *httptest.Server) {
+	ipam := &fakeIPAM{t: t, status: status, body: body}
+	return ipam, httptest.NewServer(ipam)
+}
+
+func TestClientAllocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		status   int
+		body     string
+		response *AllocateResponse
+		err      *Error
+		conflict bool
+	}{
+		{
+			name:   "allocated",
+			token:  "secret",
+			status: http.StatusOK,
+			body:   `{"ip": "10.0.0.5", "cidr": "10.0.0.0/24", "gateway": "10.0.0.1", "nameservers": ["10.0.0.2"], "routes": [{"destination": "10.1.0.0/16", "gateway": "10.0.0.254"}], "mtu": 9000}`,
+			response: &AllocateResponse{
+				IP:          "10.0.0.5",
+				CIDR:        "10.0.0.0/24",
+				Gateway:     "10.0.0.1",
+				Nameservers: []string{"10.0.0.2"},
+				Routes:      []Route{{Destination: "10.1.0.0/16", Gateway: "10.0.0.254"}},
+				MTU:         9000,
+			},
+		},
+		{
+			name:   "without a token",
+			status: http.StatusCreated,
+			body:   `{"ip": "10.0.0.5", "cidr": "10.0.0.0/24"}`,
+			response: &AllocateResponse{
+				IP:   "10.0.0.5",
+				CIDR: "10.0.0.0/24",
+			},
+		},
+		{
+			name:     "requested address is taken",
+			status:   http.StatusConflict,
+			body:     `{"message": "10.0.0.5 is allocated to another endpoint"}`,
+			err:      &Error{StatusCode: http.StatusConflict, Message: "10.0.0.5 is allocated to another endpoint"},
+			conflict: true,
+		},
+		{
+			name:   "error without a message",
+			status: http.StatusServiceUnavailable,
+			body:   `{}`,
+			err:    &Error{StatusCode: http.StatusServiceUnavailable, Message: "Service Unavailable"},
+		},
+		{
+			name:   "error that isn't json",
+			status: http.StatusInternalServerError,
+			body:   `database is down`,
+			err:    &Error{StatusCode: http.StatusInternalServerError, Message: "Internal Server Error"},
+		},
+		{
+			name:   "not found is an error",
+			status: http.StatusNotFound,
+			body:   `{"message": "unknown network"}`,
+			err:    &Error{StatusCode: http.StatusNotFound, Message: "unknown network"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, server := newFakeIPAM(t, test.status, test.body)
+			defer server.Close()
+
+			c := NewClient(server.URL+"/", test.token, 0)
+			resp, err := c.Allocate(context.Background(), &AllocateRequest{
+				Network:  Network{Namespace: "default", Name: "network", Parameters: map[string]string{"vlan": "10"}},
+				Endpoint: Endpoint{Namespace: "default", Name: "endpoint", UID: "1234", MAC: "de:ad:be:ef:00:01", Primary: true},
+			})
+
+			if fake.path != "/allocate" {
+				t.Errorf("expected a request to /allocate got %s", fake.path)
+			}
+			expectedAuthorization := ""
+			if len(test.token) > 0 {
+				expectedAuthorization = "Bearer " + test.token
+			}
+			if fake.authorization != expectedAuthorization {
+				t.Errorf("expected authorization %q got %q", expectedAuthorization, fake.authorization)
+			}
+			if endpoint, ok := fake.request["endpoint"].(map[string]interface{}); ok == false || endpoint["uid"] != "1234" {
+				t.Errorf("expected the endpoint to be sent got %v", fake.request)
+			}
+
+			if IsConflict(err) != test.conflict {
+				t.Errorf("expected conflict %v got %v", test.conflict, err)
+			}
+			if test.err != nil {
+				if reflect.DeepEqual(err, test.err) == false {
+					t.Errorf("expected error %v got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error allocating: %v", err)
+			}
+			if reflect.DeepEqual(resp, test.response) == false {
+				t.Errorf("expected response %v got %v", test.response, resp)
+			}
+		})
+	}
+}
+
+func TestClientRelease(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		err    bool
+	}{
+		{
+			name:   "released",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "already released",
+			status: http.StatusNotFound,
+			body:   `{"message": "10.0.0.5 is not allocated"}`,
+		},
+		{
+			name:   "error",
+			status: http.StatusBadGateway,
+			body:   `{"message": "upstream failed"}`,
+			err:    true,
+		},
+		{
+			name:   "conflict is an error",
+			status: http.StatusConflict,
+			body:   `{"message": "10.0.0.5 is allocated to another endpoint"}`,
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, server := newFakeIPAM(t, test.status, test.body)
+			defer server.Close()
+
+			c := NewClient(server.URL, "secret", 0)
+			err := c.Release(context.Background(), &ReleaseRequest{
+				Network:  Network{Namespace: "default", Name: "network"},
+				Endpoint: Endpoint{Namespace: "default", Name: "endpoint", UID: "1234"},
+				IP:       "10.0.0.5",
+			})
+
+			if fake.path != "/release" || fake.request["ip"] != "10.0.0.5" {
+				t.Errorf("expected the address to be released got %s %v", fake.path, fake.request)
+			}
+			if fake.authorization != "Bearer secret" {
+				t.Errorf("expected the bearer token got %q", fake.authorization)
+			}
+
+			if test.err && err == nil {
+				t.Errorf("expected an error")
+			}
+			if test.err == false && err != nil {
+				t.Errorf("error releasing: %v", err)
+			}
+		})
+	}
+}
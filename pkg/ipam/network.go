@@ -0,0 +1,344 @@
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	conditionv1 "github.com/rmb938/kube-baremetal/apis/condition/v1"
+)
+
+// Allocator allocates addresses from the pools of a BareMetalNetwork
+// the allocation state is loaded from and saved to the network status
+type Allocator struct {
+	ranges      []*Range
+	initialized bool
+
+	// released addresses stay allocated until the hold-down has passed
+	// so they are not handed out while stale arp and dns entries may still point at the old host
+	holdDown   time.Duration
+	quarantine map[string]metav1.Time
+}
+
+// NewAllocator creates an allocator for the network using the allocation state in the network status
+func NewAllocator(bmn *baremetalv1alpha1.BareMetalNetwork) (*Allocator, error) {
+	cidrIP, network, err := net.ParseCIDR(bmn.Spec.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	networkIP := cidrIP.Mask(network.Mask)
+
+	a := &Allocator{
+		initialized: len(bmn.Status.Pools) > 0,
+		holdDown:    time.Duration(bmn.Spec.ReleaseHoldDownSeconds) * time.Second,
+		quarantine:  make(map[string]metav1.Time),
+	}
+
+	matched := make([]bool, len(bmn.Status.Pools))
+	for _, specRange := range bmn.Spec.AllRanges() {
+		start := net.ParseIP(specRange.Start)
+		end := net.ParseIP(specRange.End)
+
+		var pool *baremetalv1alpha1.BareMetalNetworkStatusPool
+		for i := range bmn.Status.Pools {
+			p := &bmn.Status.Pools[i]
+			if start.Equal(net.ParseIP(p.Start)) && end.Equal(net.ParseIP(p.End)) {
+				pool = p
+				matched[i] = true
+				break
+			}
+		}
+
+		var r *Range
+		if pool != nil {
+			r, err = NewRange(start, end, pool.Bitmap, pool.Next)
+		} else {
+			r, err = NewRange(start, end, nil, 0)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %s-%s: %v", specRange.Start, specRange.End, err)
+		}
+
+		// ignore network and gateway IPs
+		// for ipv6 the network ip is the subnet-router anycast address so it is also ignored
+		r.Exclude(networkIP, networkIP)
+		gateway := net.ParseIP(bmn.Spec.Gateway)
+		r.Exclude(gateway, gateway)
+
+		// ipv6 does not have broadcast addresses so only ipv4 networks need it
+		if networkIP.To4() != nil {
+			broadcast := net.IP(make([]byte, len(network.Mask)))
+			for i := range network.Mask {
+				broadcast[i] = networkIP[i] | ^network.Mask[i]
+			}
+			r.Exclude(broadcast, broadcast)
+		}
+
+		// ignore nameservers (they might be in the range)
+		for _, ns := range bmn.Spec.Nameservers {
+			nsIP := net.ParseIP(ns)
+			r.Exclude(nsIP, nsIP)
+		}
+
+		for _, exclusion := range bmn.Spec.Exclusions {
+			exclusionStart := net.ParseIP(exclusion.Start)
+			exclusionEnd := exclusionStart
+			if len(exclusion.End) > 0 {
+				exclusionEnd = net.ParseIP(exclusion.End)
+			}
+			r.Exclude(exclusionStart, exclusionEnd)
+		}
+
+		a.ranges = append(a.ranges, r)
+	}
+
+	// copy allocations from pools that don't match a range anymore, i.e. when a range was extended
+	for i, pool := range bmn.Status.Pools {
+		if matched[i] {
+			continue
+		}
+
+		oldRange, err := NewRange(net.ParseIP(pool.Start), net.ParseIP(pool.End), pool.Bitmap, 0)
+		if err != nil {
+			continue
+		}
+
+		oldRange.ForEach(func(ip net.IP) {
+			if r := a.rangeFor(ip); r != nil {
+				r.mark(ip)
+			}
+		})
+	}
+
+	// only keep quarantined addresses that are still held in the pools
+	for _, q := range bmn.Status.Quarantine {
+		ip := net.ParseIP(q.IP)
+		if ip == nil || a.Has(ip) == false {
+			continue
+		}
+
+		a.quarantine[ip.String()] = q.ReleaseTime
+	}
+
+	return a, nil
+}
+
+// Initialized returns if the allocation state was loaded from the network status
+// when it is false the network controller has not yet seeded the pools from existing endpoints
+func (a *Allocator) Initialized() bool {
+	return a.initialized
+}
+
+// Contains returns if the ip is inside of one of the pools
+func (a *Allocator) Contains(ip net.IP) bool {
+	return a.rangeFor(ip) != nil
+}
+
+// Excluded returns if the ip is excluded from allocation
+func (a *Allocator) Excluded(ip net.IP) bool {
+	r := a.rangeFor(ip)
+	if r == nil {
+		return false
+	}
+
+	return r.Excluded(ip)
+}
+
+// Has returns if the ip is allocated
+func (a *Allocator) Has(ip net.IP) bool {
+	r := a.rangeFor(ip)
+	if r == nil {
+		return false
+	}
+
+	return r.Has(ip)
+}
+
+// Quarantined returns if the ip was released and is waiting for the hold-down to pass
+func (a *Allocator) Quarantined(ip net.IP) bool {
+	_, ok := a.quarantine[ip.String()]
+	return ok
+}
+
+// Allocate marks the ip as allocated
+func (a *Allocator) Allocate(ip net.IP) error {
+	r := a.rangeFor(ip)
+	if r == nil {
+		return ErrNotInRange
+	}
+
+	if a.Quarantined(ip) {
+		return ErrQuarantined
+	}
+
+	return r.Allocate(ip)
+}
+
+// AllocateNext allocates the next free ip from the pools
+func (a *Allocator) AllocateNext() (net.IP, error) {
+	for _, r := range a.ranges {
+		ip, err := r.AllocateNext()
+		if err == ErrFull {
+			continue
+		}
+
+		return ip, err
+	}
+
+	return nil, ErrFull
+}
+
+// Release marks the ip as free
+// when the network has a hold-down the ip is quarantined instead and freed by ReleaseQuarantined
+func (a *Allocator) Release(ip net.IP, now metav1.Time) {
+	r := a.rangeFor(ip)
+	if r == nil || r.Has(ip) == false {
+		return
+	}
+
+	if a.holdDown <= 0 {
+		r.Release(ip)
+		return
+	}
+
+	if a.Quarantined(ip) == false {
+		a.quarantine[ip.String()] = now
+	}
+}
+
+// Unquarantine keeps a quarantined ip allocated, i.e. when it turns out an endpoint is still using it
+func (a *Allocator) Unquarantine(ip net.IP) {
+	delete(a.quarantine, ip.String())
+}
+
+// ReleaseQuarantined frees the quarantined ips that have passed the hold-down
+// it returns the number of freed ips and how long until the next quarantined ip can be freed, zero if there are none left
+func (a *Allocator) ReleaseQuarantined(now metav1.Time) (int, time.Duration) {
+	released := 0
+	var next time.Duration
+
+	for key, releaseTime := range a.quarantine {
+		remaining := releaseTime.Add(a.holdDown).Sub(now.Time)
+		if remaining > 0 {
+			if next == 0 || remaining < next {
+				next = remaining
+			}
+			continue
+		}
+
+		ip := net.ParseIP(key)
+		if r := a.rangeFor(ip); r != nil {
+			r.Release(ip)
+		}
+		delete(a.quarantine, key)
+		released++
+	}
+
+	return released, next
+}
+
+// ForEach calls f for every allocated ip
+func (a *Allocator) ForEach(f func(ip net.IP)) {
+	for _, r := range a.ranges {
+		r.ForEach(f)
+	}
+}
+
+// Total returns the number of addresses that can be allocated
+func (a *Allocator) Total() int64 {
+	var total int64
+	for _, r := range a.ranges {
+		total += r.Available()
+	}
+
+	return total
+}
+
+// Allocated returns the number of addresses that are allocated, not counting quarantined addresses
+func (a *Allocator) Allocated() int64 {
+	var allocated int64
+	for _, r := range a.ranges {
+		allocated += r.Used()
+	}
+
+	return allocated - int64(len(a.quarantine))
+}
+
+// Free returns the number of addresses that are free
+func (a *Allocator) Free() int64 {
+	var free int64
+	for _, r := range a.ranges {
+		free += r.Free()
+	}
+
+	return free
+}
+
+// Save writes the allocation state, usage counts and pool exhausted condition to the network status
+func (a *Allocator) Save(bmn *baremetalv1alpha1.BareMetalNetwork, now metav1.Time) error {
+	bmn.Status.Pools = nil
+	for _, r := range a.ranges {
+		bitmap := make([]byte, len(r.Bitmap()))
+		copy(bitmap, r.Bitmap())
+
+		bmn.Status.Pools = append(bmn.Status.Pools, baremetalv1alpha1.BareMetalNetworkStatusPool{
+			Start:  r.Start().String(),
+			End:    r.End().String(),
+			Bitmap: bitmap,
+			Next:   r.Next(),
+		})
+	}
+
+	bmn.Status.Quarantine = nil
+	for key, releaseTime := range a.quarantine {
+		bmn.Status.Quarantine = append(bmn.Status.Quarantine, baremetalv1alpha1.BareMetalNetworkStatusQuarantine{
+			IP:          key,
+			ReleaseTime: releaseTime,
+		})
+	}
+	sort.Slice(bmn.Status.Quarantine, func(i, j int) bool {
+		return Compare(net.ParseIP(bmn.Status.Quarantine[i].IP), net.ParseIP(bmn.Status.Quarantine[j].IP)) < 0
+	})
+
+	bmn.Status.Total = a.Total()
+	bmn.Status.Allocated = a.Allocated()
+	bmn.Status.Quarantined = int64(len(a.quarantine))
+	bmn.Status.Free = a.Free()
+
+	if bmn.Status.Free == 0 {
+		message := "there are no free addresses in the pools"
+		if bmn.Status.Quarantined > 0 {
+			message = fmt.Sprintf("there are no free addresses in the pools, %d addresses are quarantined", bmn.Status.Quarantined)
+		}
+
+		return bmn.Status.SetCondition(&conditionv1.StatusCondition{
+			Type:               baremetalv1alpha1.BareMetalNetworkConditionTypePoolExhausted,
+			Status:             conditionv1.ConditionStatusTrue,
+			LastTransitionTime: &now,
+			Reason:             baremetalv1alpha1.BareMetalNetworkPoolExhaustedConditionReason,
+			Message:            message,
+		})
+	}
+
+	return bmn.Status.SetCondition(&conditionv1.StatusCondition{
+		Type:               baremetalv1alpha1.BareMetalNetworkConditionTypePoolExhausted,
+		Status:             conditionv1.ConditionStatusFalse,
+		LastTransitionTime: &now,
+		Reason:             baremetalv1alpha1.BareMetalNetworkPoolAvailableConditionReason,
+		Message:            fmt.Sprintf("there are %d free addresses in the pools", bmn.Status.Free),
+	})
+}
+
+func (a *Allocator) rangeFor(ip net.IP) *Range {
+	for _, r := range a.ranges {
+		if r.Contains(ip) {
+			return r
+		}
+	}
+
+	return nil
+}
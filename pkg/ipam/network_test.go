@@ -0,0 +1,472 @@
+package ipam
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	conditionv1 "github.com/rmb938/kube-baremetal/apis/condition/v1"
+)
+
+func testNetwork(cidr, gateway string, ranges ...string) *baremetalv1alpha1.BareMetalNetwork {
+	bmn := &baremetalv1alpha1.BareMetalNetwork{
+		Spec: baremetalv1alpha1.BareMetalNetworkSpec{
+			CIDR:    cidr,
+			Gateway: gateway,
+		},
+	}
+
+	for i := 0; i+1 < len(ranges); i += 2 {
+		bmn.Spec.Ranges = append(bmn.Spec.Ranges, baremetalv1alpha1.BareMetalNetworkRange{
+			Start: ranges[i],
+			End:   ranges[i+1],
+		})
+	}
+
+	return bmn
+}
+
+// helper method to allocate every free address of the allocator
+func allocateAll(t *testing.T, alloc *Allocator) []string {
+	var ips []string
+	for {
+		ip, err := alloc.AllocateNext()
+		if err == ErrFull {
+			return ips
+		}
+		if err != nil {
+			t.Fatalf("error allocating: %v", err)
+		}
+		ips = append(ips, ip.String())
+	}
+}
+
+func TestNewAllocatorExclusions(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  func() *baremetalv1alpha1.BareMetalNetwork
+		expected []string
+	}{
+		{
+			name: "ipv4 network gateway and broadcast",
+			network: func() *baremetalv1alpha1.BareMetalNetwork {
+				return testNetwork("10.0.0.0/29", "10.0.0.1", "10.0.0.0", "10.0.0.7")
+			},
+			expected: []string{"10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"},
+		},
+		{
+			name: "ipv6 has no broadcast",
+			network: func() *baremetalv1alpha1.BareMetalNetwork {
+				return testNetwork("fd00::/125", "fd00::1", "fd00::", "fd00::7")
+			},
+			expected: []string{"fd00::2", "fd00::3", "fd00::4", "fd00::5", "fd00::6", "fd00::7"},
+		},
+		{
+			name: "nameservers",
+			network: func() *baremetalv1alpha1.BareMetalNetwork {
+				bmn := testNetwork("10.0.0.0/24", "10.0.0.1", "10.0.0.10", "10.0.0.14")
+				bmn.Spec.Nameservers = []string{"10.0.0.11", "8.8.8.8"}
+				return bmn
+			},
+			expected: []string{"10.0.0.10", "10.0.0.12", "10.0.0.13", "10.0.0.14"},
+		},
+		{
+			name: "exclusions",
+			network: func() *baremetalv1alpha1.BareMetalNetwork {
+				bmn := testNetwork("10.0.0.0/24", "10.0.0.1", "10.0.0.10", "10.0.0.20", "10.0.0.30", "10.0.0.32")
+				bmn.Spec.Exclusions = []baremetalv1alpha1.BareMetalNetworkExclusion{
+					{Start: "10.0.0.5", End: "10.0.0.18"},
+					{Start: "10.0.0.31"},
+				}
+				return bmn
+			},
+			expected: []string{"10.0.0.19", "10.0.0.20", "10.0.0.30", "10.0.0.32"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			alloc, err := NewAllocator(test.network())
+			if err != nil {
+				t.Fatalf("error creating allocator: %v", err)
+			}
+
+			if alloc.Total() != int64(len(test.expected)) {
+				t.Errorf("expected %d total addresses got %d", len(test.expected), alloc.Total())
+			}
+
+			ips := allocateAll(t, alloc)
+			if len(ips) != len(test.expected) {
+				t.Fatalf("expected %v got %v", test.expected, ips)
+			}
+			for i := range test.expected {
+				if ips[i] != test.expected[i] {
+					t.Fatalf("expected %v got %v", test.expected, ips)
+				}
+			}
+		})
+	}
+}
+
+func TestNewAllocatorInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		network *baremetalv1alpha1.BareMetalNetwork
+	}{
+		{name: "invalid cidr", network: testNetwork("10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.10")},
+		{name: "reversed range", network: testNetwork("10.0.0.0/24", "10.0.0.1", "10.0.0.10", "10.0.0.2")},
+		{name: "range too big", network: testNetwork("fd00::/64", "fd00::1", "fd00::2", "fd00::1:2")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewAllocator(test.network); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestAllocatorFull(t *testing.T) {
+	bmn := testNetwork("10.0.0.0/24", "10.0.0.1", "10.0.0.10", "10.0.0.11", "10.0.0.20", "10.0.0.20")
+	alloc, err := NewAllocator(bmn)
+	if err != nil {
+		t.Fatalf("error creating allocator: %v", err)
+	}
+
+	if ips := allocateAll(t, alloc); len(ips) != 3 {
+		t.Fatalf("expected 3 addresses got %v", ips)
+	}
+	if err := alloc.Allocate(net.ParseIP("10.0.0.20")); err != ErrAllocated {
+		t.Errorf("expected %v got %v", ErrAllocated, err)
+	}
+	if err := alloc.Allocate(net.ParseIP("10.0.0.12")); err != ErrNotInRange {
+		t.Errorf("expected %v got %v", ErrNotInRange, err)
+	}
+
+	now := metav1.NewTime(time.Unix(1000, 0))
+	if err := alloc.Save(bmn, now); err != nil {
+		t.Fatalf("error saving allocator: %v", err)
+	}
+
+	if bmn.Status.Total != 3 || bmn.Status.Allocated != 3 || bmn.Status.Free != 0 {
+		t.Errorf("expected 3 total 3 allocated 0 free got %d %d %d", bmn.Status.Total, bmn.Status.Allocated, bmn.Status.Free)
+	}
+	cond := bmn.Status.GetCondition(baremetalv1alpha1.BareMetalNetworkConditionTypePoolExhausted)
+	if cond == nil || cond.Status != conditionv1.ConditionStatusTrue {
+		t.Errorf("expected the pool exhausted condition to be true got %v", cond)
+	}
+
+	alloc.Release(net.ParseIP("10.0.0.11"), now)
+	if err := alloc.Save(bmn, now); err != nil {
+		t.Fatalf("error saving allocator: %v", err)
+	}
+	cond = bmn.Status.GetCondition(baremetalv1alpha1.BareMetalNetworkConditionTypePoolExhausted)
+	if cond == nil || cond.Status != conditionv1.ConditionStatusFalse {
+		t.Errorf("expected the pool exhausted condition to be false got %v", cond)
+	}
+}
+
+func TestAllocatorSaveRoundTrip(t *testing.T) {
+	bmn := testNetwork("fd00::/64", "fd00::1", "fd00::10", "fd00::1f", "fd00::100", "fd00::10f")
+
+	alloc, err := NewAllocator(bmn)
+	if err != nil {
+		t.Fatalf("error creating allocator: %v", err)
+	}
+	if alloc.Initialized() {
+		t.Errorf("expected a network without pools to not be initialized")
+	}
+
+	for i := 0; i < 20; i++ {
+		if _, err := alloc.AllocateNext(); err != nil {
+			t.Fatalf("error allocating: %v", err)
+		}
+	}
+	if err := alloc.Allocate(net.ParseIP("fd00::10a")); err != nil {
+		t.Fatalf("error allocating: %v", err)
+	}
+
+	now := metav1.NewTime(time.Unix(1000, 0))
+	if err := alloc.Save(bmn, now); err != nil {
+		t.Fatalf("error saving allocator: %v", err)
+	}
+
+	loaded, err := NewAllocator(bmn)
+	if err != nil {
+		t.Fatalf("error loading allocator: %v", err)
+	}
+	if loaded.Initialized() == false {
+		t.Errorf("expected a saved network to be initialized")
+	}
+
+	var expected, actual []string
+	alloc.ForEach(func(ip net.IP) {
+		expected = append(expected, ip.String())
+	})
+	loaded.ForEach(func(ip net.IP) {
+		actual = append(actual, ip.String())
+	})
+	if len(expected) != 21 || len(actual) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("expected %v got %v", expected, actual)
+		}
+	}
+
+	// the search continues from where the saved allocator stopped
+	next, err := alloc.AllocateNext()
+	if err != nil {
+		t.Fatalf("error allocating: %v", err)
+	}
+	loadedNext, err := loaded.AllocateNext()
+	if err != nil {
+		t.Fatalf("error allocating: %v", err)
+	}
+	if next.Equal(loadedNext) == false {
+		t.Errorf("expected the next address to be %s got %s", next, loadedNext)
+	}
+}
+
+func TestAllocatorGrowRange(t *testing.T) {
+	bmn := testNetwork("10.0.0.0/24", "10.0.0.1", "10.0.0.10", "10.0.0.19")
+
+	alloc, err := NewAllocator(bmn)
+	if err != nil {
+		t.Fatalf("error creating allocator: %v", err)
+	}
+	allocated := []string{"10.0.0.10", "10.0.0.15", "10.0.0.19"}
+	for _, ip := range allocated {
+		if err := alloc.Allocate(net.ParseIP(ip)); err != nil {
+			t.Fatalf("error allocating %s: %v", ip, err)
+		}
+	}
+
+	now := metav1.NewTime(time.Unix(1000, 0))
+	if err := alloc.Save(bmn, now); err != nil {
+		t.Fatalf("error saving allocator: %v", err)
+	}
+
+	// grow the range on both sides and exclude an address that is already allocated
+	bmn.Spec.Ranges[0] = baremetalv1alpha1.BareMetalNetworkRange{Start: "10.0.0.2", End: "10.0.0.50"}
+	bmn.Spec.Exclusions = []baremetalv1alpha1.BareMetalNetworkExclusion{{Start: "10.0.0.15"}}
+
+	grown, err := NewAllocator(bmn)
+	if err != nil {
+		t.Fatalf("error creating allocator: %v", err)
+	}
+
+	for _, ip := range allocated {
+		if grown.Has(net.ParseIP(ip)) == false {
+			t.Errorf("expected %s to still be allocated", ip)
+		}
+	}
+	if grown.Allocated() != int64(len(allocated)) {
+		t.Errorf("expected %d allocated addresses got %d", len(allocated), grown.Allocated())
+	}
+	// 49 addresses in the range with one of them excluded and two allocated
+	if grown.Free() != 46 {
+		t.Errorf("expected 46 free addresses got %d", grown.Free())
+	}
+
+	ips := allocateAll(t, grown)
+	if len(ips) != 46 {
+		t.Fatalf("expected 46 addresses got %d", len(ips))
+	}
+	for _, ip := range ips {
+		for _, existing := range allocated {
+			if ip == existing {
+				t.Fatalf("address %s was allocated twice", ip)
+			}
+		}
+	}
+
+	// shrinking the range drops the allocations outside of it
+	if err := grown.Save(bmn, now); err != nil {
+		t.Fatalf("error saving allocator: %v", err)
+	}
+	bmn.Spec.Ranges[0] = baremetalv1alpha1.BareMetalNetworkRange{Start: "10.0.0.2", End: "10.0.0.9"}
+	shrunk, err := NewAllocator(bmn)
+	if err != nil {
+		t.Fatalf("error creating allocator: %v", err)
+	}
+	if shrunk.Allocated() != 8 || shrunk.Has(net.ParseIP("10.0.0.10")) {
+		t.Errorf("expected only the 8 addresses in the range to be allocated got %d", shrunk.Allocated())
+	}
+}
+
+func TestAllocatorQuarantine(t *testing.T) {
+	released := metav1.NewTime(time.Unix(1000, 0))
+
+	tests := []struct {
+		name        string
+		holdDown    int64
+		now         time.Duration
+		quarantined bool
+		freed       int
+		next        time.Duration
+	}{
+		{name: "no hold-down", holdDown: 0},
+		{name: "waiting", holdDown: 60, now: 10 * time.Second, quarantined: true, next: 50 * time.Second},
+		{name: "expired", holdDown: 60, now: 60 * time.Second, freed: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bmn := testNetwork("10.0.0.0/24", "10.0.0.1", "10.0.0.10", "10.0.0.11")
+			bmn.Spec.ReleaseHoldDownSeconds = test.holdDown
+			ip := net.ParseIP("10.0.0.10")
+
+			alloc, err := NewAllocator(bmn)
+			if err != nil {
+				t.Fatalf("error creating allocator: %v", err)
+			}
+			if err := alloc.Allocate(ip); err != nil {
+				t.Fatalf("error allocating: %v", err)
+			}
+			alloc.Release(ip, released)
+
+			// the quarantine is kept in the network status
+			if err := alloc.Save(bmn, released); err != nil {
+				t.Fatalf("error saving allocator: %v", err)
+			}
+			alloc, err = NewAllocator(bmn)
+			if err != nil {
+				t.Fatalf("error loading allocator: %v", err)
+			}
+
+			if test.holdDown > 0 {
+				if alloc.Quarantined(ip) == false || alloc.Has(ip) == false {
+					t.Fatalf("expected %s to be quarantined after the release", ip)
+				}
+				if err := alloc.Allocate(ip); err != ErrQuarantined {
+					t.Errorf("expected %v got %v", ErrQuarantined, err)
+				}
+				if next, _ := alloc.AllocateNext(); next.Equal(ip) {
+					t.Errorf("expected the quarantined address to be skipped")
+				}
+			}
+
+			freed, next := alloc.ReleaseQuarantined(metav1.NewTime(released.Add(test.now)))
+			if freed != test.freed {
+				t.Errorf("expected %d freed addresses got %d", test.freed, freed)
+			}
+			if next != test.next {
+				t.Errorf("expected the next expiry in %v got %v", test.next, next)
+			}
+			if alloc.Quarantined(ip) != test.quarantined {
+				t.Errorf("expected quarantined to be %v", test.quarantined)
+			}
+			if alloc.Has(ip) != test.quarantined {
+				t.Errorf("expected allocated to be %v", test.quarantined)
+			}
+		})
+	}
+}
+
+func TestAllocatorQuarantineDroppedWhenFree(t *testing.T) {
+	bmn := testNetwork("10.0.0.0/24", "10.0.0.1", "10.0.0.10", "10.0.0.11")
+	bmn.Status.Quarantine = []baremetalv1alpha1.BareMetalNetworkStatusQuarantine{
+		{IP: "10.0.0.10", ReleaseTime: metav1.NewTime(time.Unix(1000, 0))},
+		{IP: "invalid", ReleaseTime: metav1.NewTime(time.Unix(1000, 0))},
+	}
+
+	alloc, err := NewAllocator(bmn)
+	if err != nil {
+		t.Fatalf("error creating allocator: %v", err)
+	}
+	if alloc.Quarantined(net.ParseIP("10.0.0.10")) {
+		t.Errorf("expected a quarantined address that isn't allocated to be dropped")
+	}
+
+	alloc.Unquarantine(net.ParseIP("10.0.0.10"))
+	if err := alloc.Allocate(net.ParseIP("10.0.0.10")); err != nil {
+		t.Errorf("error allocating: %v", err)
+	}
+}
+
+func TestAllocatorLegacyRange(t *testing.T) {
+	bmn := testNetwork("10.0.0.0/24", "10.0.0.1")
+	bmn.Spec.Start = "10.0.0.10"
+	bmn.Spec.End = "10.0.0.19"
+
+	alloc, err := NewAllocator(bmn)
+	if err != nil {
+		t.Fatalf("error creating allocator: %v", err)
+	}
+	if alloc.Free() != 10 {
+		t.Fatalf("expected 10 free addresses got %d", alloc.Free())
+	}
+	allocated := []string{"10.0.0.10", "10.0.0.11", "10.0.0.12"}
+	for _, ip := range allocated {
+		if err := alloc.Allocate(net.ParseIP(ip)); err != nil {
+			t.Fatalf("error allocating %s: %v", ip, err)
+		}
+	}
+
+	now := metav1.NewTime(time.Unix(1000, 0))
+	if err := alloc.Save(bmn, now); err != nil {
+		t.Fatalf("error saving allocator: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		start  string
+		end    string
+		ranges []baremetalv1alpha1.BareMetalNetworkRange
+		free   int64
+	}{
+		{
+			name:  "legacy only",
+			start: "10.0.0.10",
+			end:   "10.0.0.19",
+			free:  7,
+		},
+		{
+			name:   "moved into ranges",
+			ranges: []baremetalv1alpha1.BareMetalNetworkRange{{Start: "10.0.0.10", End: "10.0.0.19"}},
+			free:   7,
+		},
+		{
+			name:   "legacy also in ranges",
+			start:  "10.0.0.10",
+			end:    "10.0.0.19",
+			ranges: []baremetalv1alpha1.BareMetalNetworkRange{{Start: "10.0.0.10", End: "10.0.0.19"}},
+			free:   7,
+		},
+		{
+			name:   "legacy and another range",
+			start:  "10.0.0.10",
+			end:    "10.0.0.19",
+			ranges: []baremetalv1alpha1.BareMetalNetworkRange{{Start: "10.0.0.100", End: "10.0.0.109"}},
+			free:   17,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			network := bmn.DeepCopy()
+			network.Spec.Start = test.start
+			network.Spec.End = test.end
+			network.Spec.Ranges = test.ranges
+
+			loaded, err := NewAllocator(network)
+			if err != nil {
+				t.Fatalf("error loading allocator: %v", err)
+			}
+			for _, ip := range allocated {
+				if loaded.Has(net.ParseIP(ip)) == false {
+					t.Errorf("expected %s to still be allocated", ip)
+				}
+			}
+			if loaded.Free() != test.free {
+				t.Errorf("expected %d free addresses got %d", test.free, loaded.Free())
+			}
+		})
+	}
+}
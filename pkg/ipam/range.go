@@ -0,0 +1,322 @@
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// MaxRangeSize is the maximum number of addresses a single range can contain
+// the bitmap is stored in the network status so it needs to stay small
+const MaxRangeSize = 65536
+
+var (
+	ErrFull         = errors.New("range is full")
+	ErrAllocated    = errors.New("address is already allocated")
+	ErrNotInRange   = errors.New("address is not in range")
+	ErrExcluded     = errors.New("address is excluded from allocation")
+	ErrQuarantined  = errors.New("address was recently released and is quarantined")
+	ErrRangeTooBig  = fmt.Errorf("range contains more than %d addresses", MaxRangeSize)
+	ErrInvalidRange = errors.New("range start must be less than or equal to the range end")
+)
+
+// Range is a contiguous range of ip addresses backed by an allocation bitmap
+type Range struct {
+	start    *big.Int
+	size     int64
+	ipv4     bool
+	bitmap   []byte
+	excluded []byte
+	next     int64
+}
+
+// NewRange creates a range from start to end (inclusive) using the given bitmap
+// the bitmap may be nil or shorter than the range which means the rest of the range is free
+func NewRange(start, end net.IP, bitmap []byte, next int64) (*Range, error) {
+	size, err := RangeSize(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Range{
+		start:    ipToInt(start),
+		size:     size,
+		ipv4:     start.To4() != nil,
+		bitmap:   make([]byte, (size+7)/8),
+		excluded: make([]byte, (size+7)/8),
+		next:     next,
+	}
+	copy(r.bitmap, bitmap)
+
+	// clear any bits past the end of the range
+	for offset := size; offset < int64(len(r.bitmap))*8; offset++ {
+		r.bitmap[offset/8] &^= 1 << uint(offset%8)
+	}
+
+	if r.next < 0 || r.next >= size {
+		r.next = 0
+	}
+
+	return r, nil
+}
+
+// RangeSize returns the number of addresses from start to end (inclusive)
+func RangeSize(start, end net.IP) (int64, error) {
+	if start == nil || end == nil || (start.To4() == nil) != (end.To4() == nil) {
+		return 0, ErrInvalidRange
+	}
+
+	diff := new(big.Int).Sub(ipToInt(end), ipToInt(start))
+	if diff.Sign() < 0 {
+		return 0, ErrInvalidRange
+	}
+
+	if diff.Cmp(big.NewInt(MaxRangeSize-1)) > 0 {
+		return 0, ErrRangeTooBig
+	}
+
+	return diff.Int64() + 1, nil
+}
+
+// Size returns the number of addresses in the range
+func (r *Range) Size() int64 {
+	return r.size
+}
+
+// Available returns the number of addresses in the range that are not excluded
+func (r *Range) Available() int64 {
+	return r.size - popCount(r.excluded)
+}
+
+// Free returns the number of addresses in the range that can still be allocated
+func (r *Range) Free() int64 {
+	var used int64
+	for i := range r.bitmap {
+		used += popCount([]byte{r.bitmap[i] &^ r.excluded[i]})
+	}
+
+	return r.Available() - used
+}
+
+// Exclude prevents the addresses from start to end (inclusive) from being allocated
+// the addresses may be partially or completely outside of the range
+func (r *Range) Exclude(start, end net.IP) {
+	if start == nil || end == nil || (start.To4() != nil) != r.ipv4 || (end.To4() != nil) != r.ipv4 {
+		return
+	}
+
+	first := new(big.Int).Sub(ipToInt(start), r.start)
+	last := new(big.Int).Sub(ipToInt(end), r.start)
+
+	// the exclusion is completely outside of the range
+	if last.Sign() < 0 || first.Cmp(big.NewInt(r.size)) >= 0 || first.Cmp(last) > 0 {
+		return
+	}
+
+	firstOffset := int64(0)
+	if first.Sign() > 0 {
+		firstOffset = first.Int64()
+	}
+
+	lastOffset := r.size - 1
+	if last.Cmp(big.NewInt(lastOffset)) < 0 {
+		lastOffset = last.Int64()
+	}
+
+	for offset := firstOffset; offset <= lastOffset; offset++ {
+		r.excluded[offset/8] |= 1 << uint(offset%8)
+	}
+}
+
+// Excluded returns if the ip is excluded from allocation
+func (r *Range) Excluded(ip net.IP) bool {
+	offset, ok := r.offset(ip)
+	if !ok {
+		return false
+	}
+
+	return r.excluded[offset/8]&(1<<uint(offset%8)) != 0
+}
+
+// Start returns the first address of the range
+func (r *Range) Start() net.IP {
+	return r.ip(0)
+}
+
+// End returns the last address of the range
+func (r *Range) End() net.IP {
+	return r.ip(r.size - 1)
+}
+
+// Bitmap returns the allocation bitmap of the range
+func (r *Range) Bitmap() []byte {
+	return r.bitmap
+}
+
+// Next returns the offset the next allocation search starts from
+func (r *Range) Next() int64 {
+	return r.next
+}
+
+// Contains returns if the ip is inside of the range
+func (r *Range) Contains(ip net.IP) bool {
+	_, ok := r.offset(ip)
+	return ok
+}
+
+// Has returns if the ip is allocated
+func (r *Range) Has(ip net.IP) bool {
+	offset, ok := r.offset(ip)
+	if !ok {
+		return false
+	}
+
+	return r.isSet(offset)
+}
+
+// Allocate marks the ip as allocated
+func (r *Range) Allocate(ip net.IP) error {
+	offset, ok := r.offset(ip)
+	if !ok {
+		return ErrNotInRange
+	}
+
+	if r.isSet(offset) {
+		return ErrAllocated
+	}
+
+	if r.excluded[offset/8]&(1<<uint(offset%8)) != 0 {
+		return ErrExcluded
+	}
+
+	r.set(offset)
+	return nil
+}
+
+// AllocateNext allocates the next free ip in the range
+// the search continues from where the last search finished so allocation is amortised O(1)
+func (r *Range) AllocateNext() (net.IP, error) {
+	for i := int64(0); i < r.size; i++ {
+		offset := (r.next + i) % r.size
+
+		// skip over fully allocated or excluded bytes
+		if offset%8 == 0 && r.bitmap[offset/8]|r.excluded[offset/8] == 0xff && i+8 <= r.size {
+			i += 7
+			continue
+		}
+
+		if r.isSet(offset) || r.excluded[offset/8]&(1<<uint(offset%8)) != 0 {
+			continue
+		}
+
+		r.set(offset)
+		r.next = (offset + 1) % r.size
+		return r.ip(offset), nil
+	}
+
+	return nil, ErrFull
+}
+
+// mark marks the ip as allocated even if it is excluded
+func (r *Range) mark(ip net.IP) {
+	offset, ok := r.offset(ip)
+	if !ok {
+		return
+	}
+
+	r.set(offset)
+}
+
+// Release marks the ip as free
+func (r *Range) Release(ip net.IP) {
+	offset, ok := r.offset(ip)
+	if !ok {
+		return
+	}
+
+	r.bitmap[offset/8] &^= 1 << uint(offset%8)
+}
+
+// Used returns the number of allocated ips
+func (r *Range) Used() int64 {
+	return popCount(r.bitmap)
+}
+
+// ForEach calls f for every allocated ip in the range
+func (r *Range) ForEach(f func(ip net.IP)) {
+	for offset := int64(0); offset < r.size; offset++ {
+		if r.isSet(offset) {
+			f(r.ip(offset))
+		}
+	}
+}
+
+func (r *Range) isSet(offset int64) bool {
+	return r.bitmap[offset/8]&(1<<uint(offset%8)) != 0
+}
+
+func (r *Range) set(offset int64) {
+	r.bitmap[offset/8] |= 1 << uint(offset%8)
+}
+
+func (r *Range) offset(ip net.IP) (int64, bool) {
+	if ip == nil || (ip.To4() != nil) != r.ipv4 {
+		return 0, false
+	}
+
+	diff := new(big.Int).Sub(ipToInt(ip), r.start)
+	if diff.Sign() < 0 || diff.Cmp(big.NewInt(r.size)) >= 0 {
+		return 0, false
+	}
+
+	return diff.Int64(), true
+}
+
+func (r *Range) ip(offset int64) net.IP {
+	return intToIP(new(big.Int).Add(r.start, big.NewInt(offset)), r.ipv4)
+}
+
+func popCount(bitmap []byte) int64 {
+	var count int64
+	for _, b := range bitmap {
+		for ; b > 0; b &= b - 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// Compare returns an integer comparing two ip addresses of the same version
+// the result will be 0 if a == b, -1 if a < b, and +1 if a > b
+func Compare(a, b net.IP) int {
+	return ipToInt(a).Cmp(ipToInt(b))
+}
+
+// Overlaps returns if the range aStart-aEnd overlaps the range bStart-bEnd
+func Overlaps(aStart, aEnd, bStart, bEnd net.IP) bool {
+	if (aStart.To4() != nil) != (bStart.To4() != nil) {
+		return false
+	}
+
+	return Compare(aStart, bEnd) <= 0 && Compare(bStart, aEnd) <= 0
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	if ip4 := ip.To4(); ip4 != nil {
+		return new(big.Int).SetBytes(ip4)
+	}
+	return new(big.Int).SetBytes(ip.To16())
+}
+
+func intToIP(i *big.Int, ipv4 bool) net.IP {
+	length := net.IPv6len
+	if ipv4 {
+		length = net.IPv4len
+	}
+
+	b := i.Bytes()
+	ip := make(net.IP, length)
+	copy(ip[length-len(b):], b)
+	return ip
+}
@@ -0,0 +1,254 @@
+package ipam
+
+import (
+	"net"
+	"testing"
+)
+
+func TestRangeSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		size  int64
+		err   error
+	}{
+		{name: "ipv4 single", start: "10.0.0.1", end: "10.0.0.1", size: 1},
+		{name: "ipv4", start: "10.0.0.10", end: "10.0.1.9", size: 256},
+		{name: "ipv6", start: "fd00::10", end: "fd00::1:f", size: 65536},
+		{name: "reversed", start: "10.0.0.10", end: "10.0.0.1", err: ErrInvalidRange},
+		{name: "mixed versions", start: "10.0.0.1", end: "fd00::1", err: ErrInvalidRange},
+		{name: "invalid start", start: "", end: "10.0.0.1", err: ErrInvalidRange},
+		{name: "ipv4 too big", start: "10.0.0.0", end: "10.1.0.0", err: ErrRangeTooBig},
+		{name: "ipv6 too big", start: "fd00::", end: "fd01::", err: ErrRangeTooBig},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			size, err := RangeSize(net.ParseIP(test.start), net.ParseIP(test.end))
+			if err != test.err {
+				t.Fatalf("expected error %v got %v", test.err, err)
+			}
+			if size != test.size {
+				t.Errorf("expected size %d got %d", test.size, size)
+			}
+		})
+	}
+}
+
+func TestRangeAllocateNext(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		next      int64
+		allocated []string
+		excluded  [][2]string
+		expected  []string
+	}{
+		{
+			name:     "ipv4",
+			start:    "10.0.0.1",
+			end:      "10.0.0.3",
+			expected: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"},
+		},
+		{
+			name:     "ipv6",
+			start:    "fd00::fffe",
+			end:      "fd00::1:1",
+			expected: []string{"fd00::fffe", "fd00::ffff", "fd00::1:0", "fd00::1:1"},
+		},
+		{
+			name:      "skips allocated",
+			start:     "10.0.0.1",
+			end:       "10.0.0.4",
+			allocated: []string{"10.0.0.2", "10.0.0.3"},
+			expected:  []string{"10.0.0.1", "10.0.0.4"},
+		},
+		{
+			name:      "wraps around from next",
+			start:     "10.0.0.1",
+			end:       "10.0.0.4",
+			next:      2,
+			allocated: []string{"10.0.0.4"},
+			expected:  []string{"10.0.0.3", "10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:     "ipv6 wraps around from next",
+			start:    "fd00::1",
+			end:      "fd00::3",
+			next:     2,
+			expected: []string{"fd00::3", "fd00::1", "fd00::2"},
+		},
+		{
+			name:     "skips exclusions",
+			start:    "10.0.0.1",
+			end:      "10.0.0.20",
+			excluded: [][2]string{{"10.0.0.0", "10.0.0.8"}, {"10.0.0.10", "10.0.0.19"}},
+			expected: []string{"10.0.0.9", "10.0.0.20"},
+		},
+		{
+			name:     "skips full bytes",
+			start:    "10.0.0.0",
+			end:      "10.0.0.31",
+			excluded: [][2]string{{"10.0.0.0", "10.0.0.23"}, {"10.0.0.25", "10.0.0.30"}},
+			expected: []string{"10.0.0.24", "10.0.0.31"},
+		},
+		{
+			name:     "ignores exclusions of other versions",
+			start:    "10.0.0.1",
+			end:      "10.0.0.2",
+			excluded: [][2]string{{"fd00::", "fd00::ffff"}},
+			expected: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:      "full",
+			start:     "10.0.0.1",
+			end:       "10.0.0.2",
+			allocated: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:     "fully excluded",
+			start:    "fd00::1",
+			end:      "fd00::10",
+			excluded: [][2]string{{"fd00::", "fd00::ffff"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewRange(net.ParseIP(test.start), net.ParseIP(test.end), nil, test.next)
+			if err != nil {
+				t.Fatalf("error creating range: %v", err)
+			}
+
+			for _, ip := range test.allocated {
+				if err := r.Allocate(net.ParseIP(ip)); err != nil {
+					t.Fatalf("error allocating %s: %v", ip, err)
+				}
+			}
+			for _, exclusion := range test.excluded {
+				r.Exclude(net.ParseIP(exclusion[0]), net.ParseIP(exclusion[1]))
+			}
+
+			for _, expected := range test.expected {
+				ip, err := r.AllocateNext()
+				if err != nil {
+					t.Fatalf("expected %s got error %v", expected, err)
+				}
+				if ip.Equal(net.ParseIP(expected)) == false {
+					t.Fatalf("expected %s got %s", expected, ip)
+				}
+				if r.Has(ip) == false {
+					t.Fatalf("expected %s to be allocated", ip)
+				}
+			}
+
+			if ip, err := r.AllocateNext(); err != ErrFull {
+				t.Fatalf("expected the range to be full got %s %v", ip, err)
+			}
+			if r.Free() != 0 {
+				t.Errorf("expected no free addresses got %d", r.Free())
+			}
+		})
+	}
+}
+
+func TestRangeAllocate(t *testing.T) {
+	r, err := NewRange(net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.10"), nil, 0)
+	if err != nil {
+		t.Fatalf("error creating range: %v", err)
+	}
+	r.Exclude(net.ParseIP("10.0.0.5"), net.ParseIP("10.0.0.5"))
+
+	tests := []struct {
+		name string
+		ip   string
+		err  error
+	}{
+		{name: "free", ip: "10.0.0.1"},
+		{name: "allocated", ip: "10.0.0.1", err: ErrAllocated},
+		{name: "excluded", ip: "10.0.0.5", err: ErrExcluded},
+		{name: "before range", ip: "10.0.0.0", err: ErrNotInRange},
+		{name: "after range", ip: "10.0.0.11", err: ErrNotInRange},
+		{name: "ipv4 mapped", ip: "::ffff:a00:2"},
+		{name: "ipv6", ip: "fd00::1", err: ErrNotInRange},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := r.Allocate(net.ParseIP(test.ip)); err != test.err {
+				t.Errorf("expected error %v got %v", test.err, err)
+			}
+		})
+	}
+
+	if r.Used() != 2 {
+		t.Errorf("expected 2 used addresses got %d", r.Used())
+	}
+	if r.Available() != 9 {
+		t.Errorf("expected 9 available addresses got %d", r.Available())
+	}
+
+	r.Release(net.ParseIP("10.0.0.1"))
+	if r.Has(net.ParseIP("10.0.0.1")) {
+		t.Errorf("expected 10.0.0.1 to be released")
+	}
+	if r.Free() != 8 {
+		t.Errorf("expected 8 free addresses got %d", r.Free())
+	}
+}
+
+func TestNewRangeBitmap(t *testing.T) {
+	// bits past the end of the range are cleared and a short bitmap leaves the rest of the range free
+	r, err := NewRange(net.ParseIP("10.0.0.0"), net.ParseIP("10.0.0.11"), []byte{0x01, 0xff}, 100)
+	if err != nil {
+		t.Fatalf("error creating range: %v", err)
+	}
+
+	if r.Used() != 5 {
+		t.Errorf("expected 5 used addresses got %d", r.Used())
+	}
+	if r.Next() != 0 {
+		t.Errorf("expected an out of range next to be reset got %d", r.Next())
+	}
+
+	var allocated []string
+	r.ForEach(func(ip net.IP) {
+		allocated = append(allocated, ip.String())
+	})
+	expected := []string{"10.0.0.0", "10.0.0.8", "10.0.0.9", "10.0.0.10", "10.0.0.11"}
+	if len(allocated) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, allocated)
+	}
+	for i := range expected {
+		if allocated[i] != expected[i] {
+			t.Fatalf("expected %v got %v", expected, allocated)
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        [2]string
+		b        [2]string
+		overlaps bool
+	}{
+		{name: "same", a: [2]string{"10.0.0.1", "10.0.0.10"}, b: [2]string{"10.0.0.1", "10.0.0.10"}, overlaps: true},
+		{name: "inside", a: [2]string{"10.0.0.1", "10.0.0.10"}, b: [2]string{"10.0.0.5", "10.0.0.6"}, overlaps: true},
+		{name: "touching", a: [2]string{"10.0.0.1", "10.0.0.10"}, b: [2]string{"10.0.0.10", "10.0.0.20"}, overlaps: true},
+		{name: "before", a: [2]string{"10.0.0.1", "10.0.0.10"}, b: [2]string{"10.0.0.11", "10.0.0.20"}},
+		{name: "ipv6", a: [2]string{"fd00::1", "fd00::10"}, b: [2]string{"fd00::f", "fd00::20"}, overlaps: true},
+		{name: "other versions", a: [2]string{"0.0.0.1", "0.0.0.10"}, b: [2]string{"::1", "::10"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			overlaps := Overlaps(net.ParseIP(test.a[0]), net.ParseIP(test.a[1]), net.ParseIP(test.b[0]), net.ParseIP(test.b[1]))
+			if overlaps != test.overlaps {
+				t.Errorf("expected %v got %v", test.overlaps, overlaps)
+			}
+		})
+	}
+}
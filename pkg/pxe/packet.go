@@ -0,0 +1,188 @@
+package pxe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+const (
+	opRequest byte = 1
+	opReply   byte = 2
+
+	msgTypeDiscover byte = 1
+	msgTypeOffer    byte = 2
+	msgTypeRequest  byte = 3
+	msgTypeAck      byte = 5
+	msgTypeInform   byte = 8
+
+	optionPad                byte = 0
+	optionVendorSpecific     byte = 43
+	optionOverload           byte = 52
+	optionMessageType        byte = 53
+	optionServerIdentifier   byte = 54
+	optionVendorClass        byte = 60
+	optionUserClass          byte = 77
+	optionClientArchitecture byte = 93
+	optionClientMachineID    byte = 97
+	optionEnd                byte = 255
+
+	// offset of the options after the fixed header and magic cookie
+	optionsOffset = 240
+)
+
+var (
+	magicCookie = []byte{99, 130, 83, 99}
+
+	errPacketTooShort = errors.New("dhcp packet is too short")
+	errNoMagicCookie  = errors.New("dhcp packet is missing the magic cookie")
+)
+
+// packet is the minimal subset of a dhcpv4 packet (RFC 2131) needed to answer pxe clients
+type packet struct {
+	op      byte
+	xid     []byte
+	flags   []byte
+	ciaddr  net.IP
+	yiaddr  net.IP
+	siaddr  net.IP
+	giaddr  net.IP
+	chaddr  net.HardwareAddr
+	file    string
+	options map[byte][]byte
+}
+
+func parsePacket(data []byte) (*packet, error) {
+	if len(data) < optionsOffset {
+		return nil, errPacketTooShort
+	}
+
+	if bytes.Equal(data[236:240], magicCookie) == false {
+		return nil, errNoMagicCookie
+	}
+
+	hlen := int(data[2])
+	if hlen > 16 {
+		hlen = 16
+	}
+
+	p := &packet{
+		op:      data[0],
+		xid:     append([]byte{}, data[4:8]...),
+		flags:   append([]byte{}, data[10:12]...),
+		ciaddr:  net.IP(append([]byte{}, data[12:16]...)),
+		yiaddr:  net.IP(append([]byte{}, data[16:20]...)),
+		siaddr:  net.IP(append([]byte{}, data[20:24]...)),
+		giaddr:  net.IP(append([]byte{}, data[24:28]...)),
+		chaddr:  net.HardwareAddr(append([]byte{}, data[28:28+hlen]...)),
+		options: make(map[byte][]byte),
+	}
+
+	parseOptions(p.options, data[optionsOffset:])
+
+	// the file and sname fields hold more options when they are overloaded (RFC 2132)
+	if overload := p.options[optionOverload]; len(overload) == 1 {
+		if overload[0]&1 != 0 {
+			parseOptions(p.options, data[108:236])
+		}
+		if overload[0]&2 != 0 {
+			parseOptions(p.options, data[44:108])
+		}
+	}
+
+	return p, nil
+}
+
+// helper method to parse the options into the map, parsing stops at the end option or a truncated option
+func parseOptions(parsed map[byte][]byte, options []byte) {
+	for len(options) > 0 {
+		code := options[0]
+		if code == optionEnd {
+			break
+		}
+		if code == optionPad {
+			options = options[1:]
+			continue
+		}
+		if len(options) < 2 || len(options) < 2+int(options[1]) {
+			break
+		}
+
+		length := int(options[1])
+		// options that are longer then 255 bytes are split (RFC 3396)
+		parsed[code] = append(parsed[code], options[2:2+length]...)
+		options = options[2+length:]
+	}
+}
+
+func (p *packet) messageType() byte {
+	if mt := p.options[optionMessageType]; len(mt) == 1 {
+		return mt[0]
+	}
+
+	return 0
+}
+
+// clientArchitecture returns the client system architecture type (RFC 4578)
+func (p *packet) clientArchitecture() (uint16, bool) {
+	arch := p.options[optionClientArchitecture]
+	if len(arch) < 2 {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint16(arch[:2]), true
+}
+
+func (p *packet) marshal() []byte {
+	data := make([]byte, optionsOffset)
+	data[0] = p.op
+	data[1] = 1 // ethernet
+	data[2] = byte(len(p.chaddr))
+	copy(data[4:8], p.xid)
+	copy(data[10:12], p.flags)
+	copy(data[12:16], p.ciaddr.To4())
+	copy(data[16:20], p.yiaddr.To4())
+	copy(data[20:24], p.siaddr.To4())
+	copy(data[24:28], p.giaddr.To4())
+	copy(data[28:44], p.chaddr)
+	copy(data[108:236], p.file)
+	copy(data[236:240], magicCookie)
+
+	// the message type must be the first option for some pxe roms
+	if mt, ok := p.options[optionMessageType]; ok {
+		data = appendOption(data, optionMessageType, mt)
+	}
+	for code := 1; code < int(optionEnd); code++ {
+		value, ok := p.options[byte(code)]
+		if ok == false || byte(code) == optionMessageType {
+			continue
+		}
+		data = appendOption(data, byte(code), value)
+	}
+	data = append(data, optionEnd)
+
+	// some clients drop packets that are smaller then a bootp packet
+	for len(data) < 300 {
+		data = append(data, optionPad)
+	}
+
+	return data
+}
+
+func appendOption(data []byte, code byte, value []byte) []byte {
+	for {
+		chunk := value
+		if len(chunk) > 255 {
+			chunk = chunk[:255]
+		}
+
+		data = append(data, code, byte(len(chunk)))
+		data = append(data, chunk...)
+
+		value = value[len(chunk):]
+		if len(value) == 0 {
+			return data
+		}
+	}
+}
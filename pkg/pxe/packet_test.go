@@ -0,0 +1,240 @@
+package pxe
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+// helper method to build a request with the raw options after the magic cookie
+func rawPacket(options ...byte) []byte {
+	data := make([]byte, optionsOffset)
+	data[0] = opRequest
+	data[1] = 1
+	data[2] = 6
+	copy(data[4:8], []byte{1, 2, 3, 4})
+	copy(data[28:34], []byte{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01})
+	copy(data[236:240], magicCookie)
+
+	return append(data, options...)
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	long := bytes.Repeat([]byte{'a'}, 600)
+
+	p := &packet{
+		op:     opReply,
+		xid:    []byte{1, 2, 3, 4},
+		flags:  []byte{0x80, 0},
+		ciaddr: net.IPv4(10, 0, 0, 1),
+		yiaddr: net.IPv4(10, 0, 0, 2),
+		siaddr: net.IPv4(10, 0, 0, 3),
+		giaddr: net.IPv4(10, 0, 0, 4),
+		chaddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01},
+		file:   "undionly.kpxe",
+		options: map[byte][]byte{
+			optionServerIdentifier: net.IPv4(10, 0, 0, 3).To4(),
+			optionVendorClass:      []byte("PXEClient"),
+			optionMessageType:      {msgTypeOffer},
+			optionVendorSpecific:   long,
+			optionUserClass:        {},
+		},
+	}
+
+	data := p.marshal()
+	if len(data) < 300 {
+		t.Errorf("expected the packet to be padded to 300 bytes got %d", len(data))
+	}
+	if data[optionsOffset] != optionMessageType {
+		t.Errorf("expected the message type to be the first option got %d", data[optionsOffset])
+	}
+
+	parsed, err := parsePacket(data)
+	if err != nil {
+		t.Fatalf("error parsing packet: %v", err)
+	}
+
+	if parsed.op != p.op || bytes.Equal(parsed.xid, p.xid) == false || bytes.Equal(parsed.flags, p.flags) == false {
+		t.Errorf("expected op %d xid %v flags %v got %d %v %v", p.op, p.xid, p.flags, parsed.op, parsed.xid, parsed.flags)
+	}
+	for _, ip := range [][2]net.IP{{parsed.ciaddr, p.ciaddr}, {parsed.yiaddr, p.yiaddr}, {parsed.siaddr, p.siaddr}, {parsed.giaddr, p.giaddr}} {
+		if ip[0].Equal(ip[1]) == false {
+			t.Errorf("expected address %s got %s", ip[1], ip[0])
+		}
+	}
+	if parsed.chaddr.String() != p.chaddr.String() {
+		t.Errorf("expected chaddr %s got %s", p.chaddr, parsed.chaddr)
+	}
+	if parsed.messageType() != msgTypeOffer {
+		t.Errorf("expected message type %d got %d", msgTypeOffer, parsed.messageType())
+	}
+
+	if len(parsed.options) != len(p.options) {
+		t.Errorf("expected %d options got %d", len(p.options), len(parsed.options))
+	}
+	for code, value := range p.options {
+		if bytes.Equal(parsed.options[code], value) == false {
+			t.Errorf("expected option %d to be %v got %v", code, value, parsed.options[code])
+		}
+	}
+}
+
+func TestParsePacket(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		err     error
+		options map[byte][]byte
+	}{
+		{
+			name: "too short",
+			data: rawPacket()[:optionsOffset-1],
+			err:  errPacketTooShort,
+		},
+		{
+			name: "no magic cookie",
+			data: func() []byte {
+				data := rawPacket()
+				data[236] = 0
+				return data
+			}(),
+			err: errNoMagicCookie,
+		},
+		{
+			name:    "no options",
+			data:    rawPacket(),
+			options: map[byte][]byte{},
+		},
+		{
+			name:    "pad and end",
+			data:    rawPacket(optionPad, optionPad, optionMessageType, 1, msgTypeDiscover, optionEnd, optionVendorClass, 1, 'a'),
+			options: map[byte][]byte{optionMessageType: {msgTypeDiscover}},
+		},
+		{
+			name:    "missing end",
+			data:    rawPacket(optionMessageType, 1, msgTypeRequest),
+			options: map[byte][]byte{optionMessageType: {msgTypeRequest}},
+		},
+		{
+			name:    "truncated length",
+			data:    rawPacket(optionMessageType, 1, msgTypeDiscover, optionVendorClass),
+			options: map[byte][]byte{optionMessageType: {msgTypeDiscover}},
+		},
+		{
+			name:    "truncated value",
+			data:    rawPacket(optionMessageType, 1, msgTypeDiscover, optionVendorClass, 9, 'P', 'X', 'E'),
+			options: map[byte][]byte{optionMessageType: {msgTypeDiscover}},
+		},
+		{
+			name:    "split option",
+			data:    rawPacket(optionUserClass, 2, 'i', 'P', optionMessageType, 1, msgTypeRequest, optionUserClass, 2, 'X', 'E', optionEnd),
+			options: map[byte][]byte{optionUserClass: []byte("iPXE"), optionMessageType: {msgTypeRequest}},
+		},
+		{
+			name: "overloaded file",
+			data: func() []byte {
+				data := rawPacket(optionOverload, 1, 1, optionMessageType, 1, msgTypeDiscover, optionEnd)
+				copy(data[108:], []byte{optionVendorClass, 9, 'P', 'X', 'E', 'C', 'l', 'i', 'e', 'n', 't', optionEnd})
+				copy(data[44:], []byte{optionUserClass, 4, 'i', 'P', 'X', 'E', optionEnd})
+				return data
+			}(),
+			options: map[byte][]byte{
+				optionOverload:    {1},
+				optionMessageType: {msgTypeDiscover},
+				optionVendorClass: []byte("PXEClient"),
+			},
+		},
+		{
+			name: "overloaded file and sname",
+			data: func() []byte {
+				data := rawPacket(optionOverload, 1, 3, optionClientArchitecture, 1, 0, optionEnd)
+				copy(data[108:], []byte{optionClientArchitecture, 1, 7, optionEnd})
+				copy(data[44:], []byte{optionUserClass, 4, 'i', 'P', 'X', 'E', optionEnd})
+				return data
+			}(),
+			options: map[byte][]byte{
+				optionOverload:           {3},
+				optionClientArchitecture: {0, 7},
+				optionUserClass:          []byte("iPXE"),
+			},
+		},
+		{
+			name: "truncated overloaded sname",
+			data: func() []byte {
+				data := rawPacket(optionOverload, 1, 2, optionEnd)
+				copy(data[44:], []byte{optionUserClass, 4, 'i', 'P', 'X', 'E'})
+				data[106] = optionVendorClass
+				data[107] = 9
+				return data
+			}(),
+			options: map[byte][]byte{
+				optionOverload:  {2},
+				optionUserClass: []byte("iPXE"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := parsePacket(test.data)
+			if err != test.err {
+				t.Fatalf("expected error %v got %v", test.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(p.options) != len(test.options) {
+				t.Errorf("expected options %v got %v", test.options, p.options)
+			}
+			for code, value := range test.options {
+				if bytes.Equal(p.options[code], value) == false {
+					t.Errorf("expected option %d to be %v got %v", code, value, p.options[code])
+				}
+			}
+		})
+	}
+}
+
+func TestPacketOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		options      map[byte][]byte
+		messageType  byte
+		architecture uint16
+		hasArch      bool
+	}{
+		{name: "empty", options: map[byte][]byte{}},
+		{
+			name:         "bios discover",
+			options:      map[byte][]byte{optionMessageType: {msgTypeDiscover}, optionClientArchitecture: {0, 0}},
+			messageType:  msgTypeDiscover,
+			architecture: 0,
+			hasArch:      true,
+		},
+		{
+			name:         "efi request with multiple architectures",
+			options:      map[byte][]byte{optionMessageType: {msgTypeRequest}, optionClientArchitecture: {0, 7, 0, 9}},
+			messageType:  msgTypeRequest,
+			architecture: 7,
+			hasArch:      true,
+		},
+		{
+			name:    "malformed",
+			options: map[byte][]byte{optionMessageType: {msgTypeRequest, 1}, optionClientArchitecture: {7}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := &packet{options: test.options}
+			if p.messageType() != test.messageType {
+				t.Errorf("expected message type %d got %d", test.messageType, p.messageType())
+			}
+			arch, ok := p.clientArchitecture()
+			if ok != test.hasArch || arch != test.architecture {
+				t.Errorf("expected architecture %d %v got %d %v", test.architecture, test.hasArch, arch, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package pxe
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// client system architecture types (RFC 4578)
+const (
+	archBIOS    uint16 = 0
+	archEFIx64  uint16 = 7
+	archEFIBC   uint16 = 9
+	archDefault        = archBIOS
+)
+
+// the ipxe binaries that are chained to from the pxe rom
+var bootFiles = map[uint16]string{
+	archBIOS:   "undionly.kpxe",
+	archEFIx64: "ipxe.efi",
+	archEFIBC:  "ipxe.efi",
+}
+
+// ProxyDHCPServer answers pxe clients with boot information without handing out addresses (proxyDHCP)
+// so it can run next to an existing dhcp server that only assigns addresses
+// pxe roms are pointed at the ipxe binaries on the tftp server and ipxe is pointed at the boot url
+type ProxyDHCPServer struct {
+	ServerIP net.IP
+	BootURL  string
+
+	logger logr.Logger
+}
+
+func NewProxyDHCPServer(serverIP net.IP, bootURL string) *ProxyDHCPServer {
+	return &ProxyDHCPServer{
+		ServerIP: serverIP.To4(),
+		BootURL:  bootURL,
+
+		logger: ctrl.Log.WithName("proxydhcp-server"),
+	}
+}
+
+func (s *ProxyDHCPServer) Run(stop <-chan struct{}) error {
+	// dhcp discovers are broadcast to port 67
+	dhcpConn, err := net.ListenPacket("udp4", ":67")
+	if err != nil {
+		return err
+	}
+	defer dhcpConn.Close()
+
+	// pxe roms send a request to port 4011 on the proxy after they have an address
+	pxeConn, err := net.ListenPacket("udp4", ":4011")
+	if err != nil {
+		return err
+	}
+	defer pxeConn.Close()
+
+	s.logger.Info("Starting proxyDHCP server", "server-ip", s.ServerIP.String())
+
+	go s.serve(dhcpConn, true, stop)
+	go s.serve(pxeConn, false, stop)
+
+	<-stop
+	s.logger.Info("Stopping proxyDHCP server")
+
+	return nil
+}
+
+func (s *ProxyDHCPServer) serve(conn net.PacketConn, dhcpPort bool, stop <-chan struct{}) {
+	buf := make([]byte, 1500)
+
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-stop:
+				return
+			default:
+				s.logger.Error(err, "Error reading proxyDHCP packet")
+				continue
+			}
+		}
+
+		req, err := parsePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		resp := s.response(req, dhcpPort)
+		if resp == nil {
+			continue
+		}
+
+		dst := addr
+		if dhcpPort {
+			// the client doesn't have an address yet so reply with a broadcast or to the relay agent
+			dst = &net.UDPAddr{IP: net.IPv4bcast, Port: 68}
+			if req.giaddr.Equal(net.IPv4zero) == false {
+				dst = &net.UDPAddr{IP: req.giaddr, Port: 67}
+			}
+		}
+
+		if _, err := conn.WriteTo(resp.marshal(), dst); err != nil {
+			s.logger.Error(err, "Error sending proxyDHCP packet", "mac", req.chaddr.String())
+		}
+	}
+}
+
+// response builds the reply for a pxe client or returns nil when the packet should be ignored
+func (s *ProxyDHCPServer) response(req *packet, dhcpPort bool) *packet {
+	if req.op != opRequest {
+		return nil
+	}
+
+	// only answer pxe clients, everything else is handled by the dhcp server
+	if bytes.HasPrefix(req.options[optionVendorClass], []byte("PXEClient")) == false {
+		return nil
+	}
+
+	var msgType byte
+	switch req.messageType() {
+	case msgTypeDiscover:
+		if dhcpPort == false {
+			return nil
+		}
+		msgType = msgTypeOffer
+	case msgTypeRequest, msgTypeInform:
+		if dhcpPort {
+			return nil
+		}
+		msgType = msgTypeAck
+	default:
+		return nil
+	}
+
+	arch, ok := req.clientArchitecture()
+	if ok == false {
+		arch = archDefault
+	}
+
+	// ipxe is already running so point it at the boot script
+	// otherwise chain load ipxe from tftp
+	bootFile := s.BootURL
+	if bytes.Equal(req.options[optionUserClass], []byte("iPXE")) == false {
+		bootFile, ok = bootFiles[arch]
+		if ok == false {
+			s.logger.Info("Ignoring pxe client with unsupported architecture", "mac", req.chaddr.String(), "arch", arch)
+			return nil
+		}
+	}
+
+	s.logger.Info("Answering pxe client", "mac", req.chaddr.String(), "arch", arch, "boot-file", bootFile)
+
+	resp := &packet{
+		op:     opReply,
+		xid:    req.xid,
+		flags:  req.flags,
+		ciaddr: req.ciaddr,
+		yiaddr: net.IPv4zero,
+		siaddr: s.ServerIP,
+		giaddr: req.giaddr,
+		chaddr: req.chaddr,
+		file:   bootFile,
+		options: map[byte][]byte{
+			optionMessageType:      {msgType},
+			optionServerIdentifier: s.ServerIP,
+			optionVendorClass:      []byte("PXEClient"),
+			// pxe discovery control (sub-option 6) set to 8 so the client skips boot server discovery
+			// and downloads the boot file from the server address
+			optionVendorSpecific: {6, 1, 8, 255},
+		},
+	}
+
+	// the pxe spec requires the client machine id to be sent back
+	if guid, ok := req.options[optionClientMachineID]; ok {
+		resp.options[optionClientMachineID] = guid
+	}
+
+	return resp
+}
@@ -0,0 +1,273 @@
+package pxe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// tftp opcodes (RFC 1350) and option acknowledgment (RFC 2347)
+const (
+	tftpOpRRQ   uint16 = 1
+	tftpOpData  uint16 = 3
+	tftpOpAck   uint16 = 4
+	tftpOpError uint16 = 5
+	tftpOpOACK  uint16 = 6
+
+	tftpErrNotDefined     uint16 = 0
+	tftpErrFileNotFound   uint16 = 1
+	tftpErrAccessViolated uint16 = 2
+	tftpErrIllegalOp      uint16 = 4
+
+	tftpDefaultBlockSize = 512
+	tftpMaxBlockSize     = 65464
+	tftpDefaultTimeout   = 3 * time.Second
+	tftpRetries          = 5
+)
+
+// TFTPServer is a read-only tftp server used to hand out the ipxe binaries to pxe roms
+type TFTPServer struct {
+	Address string
+	Root    string
+
+	logger logr.Logger
+}
+
+func NewTFTPServer(address string, root string) *TFTPServer {
+	return &TFTPServer{
+		Address: address,
+		Root:    root,
+
+		logger: ctrl.Log.WithName("tftp-server"),
+	}
+}
+
+func (s *TFTPServer) Run(stop <-chan struct{}) error {
+	conn, err := net.ListenPacket("udp4", s.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	s.logger.Info("Starting tftp server", "root", s.Root)
+
+	go func() {
+		buf := make([]byte, 1500)
+
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				select {
+				case <-stop:
+					return
+				default:
+					s.logger.Error(err, "Error reading tftp packet")
+					continue
+				}
+			}
+
+			req := append([]byte{}, buf[:n]...)
+			go s.handle(req, addr.(*net.UDPAddr))
+		}
+	}()
+
+	<-stop
+	s.logger.Info("Stopping tftp server")
+
+	return nil
+}
+
+type tftpTransfer struct {
+	conn      *net.UDPConn
+	addr      *net.UDPAddr
+	blockSize int
+	timeout   time.Duration
+}
+
+// handle a request, every transfer uses its own port (transfer identifier)
+func (s *TFTPServer) handle(req []byte, addr *net.UDPAddr) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		s.logger.Error(err, "Error creating tftp transfer connection")
+		return
+	}
+	defer conn.Close()
+
+	t := &tftpTransfer{
+		conn:      conn,
+		addr:      addr,
+		blockSize: tftpDefaultBlockSize,
+		timeout:   tftpDefaultTimeout,
+	}
+
+	if len(req) < 2 {
+		return
+	}
+
+	opcode := binary.BigEndian.Uint16(req[:2])
+	if opcode != tftpOpRRQ {
+		t.sendError(tftpErrIllegalOp, "only read requests are supported")
+		return
+	}
+
+	// filename, mode and then option name and value pairs all null terminated
+	fields := strings.Split(string(req[2:]), "\x00")
+	if len(fields) < 2 {
+		t.sendError(tftpErrNotDefined, "invalid read request")
+		return
+	}
+	filename := fields[0]
+	requestedOptions := make(map[string]string)
+	for i := 2; i+1 < len(fields); i += 2 {
+		requestedOptions[strings.ToLower(fields[i])] = fields[i+1]
+	}
+
+	log := s.logger.WithValues("client-ip", addr.IP.String(), "file", filename)
+
+	// never allow reading outside of the root
+	filePath := filepath.Join(s.Root, filepath.FromSlash(path.Clean("/"+filename)))
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Info("tftp file not found")
+		t.sendError(tftpErrFileNotFound, "file not found")
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		t.sendError(tftpErrAccessViolated, "not a file")
+		return
+	}
+
+	// negotiate the options the client asked for (RFC 2348 and RFC 2349)
+	var oack [][]byte
+	if value, ok := requestedOptions["blksize"]; ok {
+		if blockSize, err := strconv.Atoi(value); err == nil && blockSize >= 8 {
+			if blockSize > tftpMaxBlockSize {
+				blockSize = tftpMaxBlockSize
+			}
+			t.blockSize = blockSize
+			oack = append(oack, []byte("blksize"), []byte(strconv.Itoa(blockSize)))
+		}
+	}
+	if value, ok := requestedOptions["timeout"]; ok {
+		if timeout, err := strconv.Atoi(value); err == nil && timeout >= 1 && timeout <= 255 {
+			t.timeout = time.Duration(timeout) * time.Second
+			oack = append(oack, []byte("timeout"), []byte(value))
+		}
+	}
+	if _, ok := requestedOptions["tsize"]; ok {
+		oack = append(oack, []byte("tsize"), []byte(strconv.FormatInt(info.Size(), 10)))
+	}
+
+	log.Info("sending tftp file", "size", info.Size(), "block-size", t.blockSize)
+
+	if len(oack) > 0 {
+		packet := []byte{0, byte(tftpOpOACK)}
+		for _, o := range oack {
+			packet = append(packet, o...)
+			packet = append(packet, 0)
+		}
+
+		if err := t.send(packet, 0); err != nil {
+			// pxe roms commonly abort the first request after getting the file size
+			log.Info("tftp transfer aborted", "reason", err.Error())
+			return
+		}
+	}
+
+	data := make([]byte, t.blockSize)
+	// block numbers wrap around for big files
+	var block uint16 = 1
+	for {
+		n, err := io.ReadFull(file, data)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			t.sendError(tftpErrNotDefined, err.Error())
+			return
+		}
+
+		packet := make([]byte, 4+n)
+		binary.BigEndian.PutUint16(packet[0:2], tftpOpData)
+		binary.BigEndian.PutUint16(packet[2:4], block)
+		copy(packet[4:], data[:n])
+
+		if err := t.send(packet, block); err != nil {
+			log.Info("tftp transfer aborted", "reason", err.Error())
+			return
+		}
+
+		// a short block ends the transfer
+		if n < t.blockSize {
+			return
+		}
+		block++
+	}
+}
+
+// send a packet and wait for it to be acknowledged, retrying on timeouts
+func (t *tftpTransfer) send(packet []byte, block uint16) error {
+	buf := make([]byte, 1500)
+
+	for attempt := 0; attempt < tftpRetries; attempt++ {
+		if _, err := t.conn.WriteToUDP(packet, t.addr); err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(t.timeout)
+		for {
+			if err := t.conn.SetReadDeadline(deadline); err != nil {
+				return err
+			}
+
+			n, addr, err := t.conn.ReadFromUDP(buf)
+			if err != nil {
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					break
+				}
+				return err
+			}
+
+			// ignore packets from anything else then the client
+			if addr.Port != t.addr.Port || addr.IP.Equal(t.addr.IP) == false || n < 4 {
+				continue
+			}
+
+			switch binary.BigEndian.Uint16(buf[0:2]) {
+			case tftpOpAck:
+				if binary.BigEndian.Uint16(buf[2:4]) == block {
+					return nil
+				}
+				// duplicate ack for an older block, keep waiting
+			case tftpOpError:
+				return fmt.Errorf("client sent error: %s", string(bytes.TrimRight(buf[4:n], "\x00")))
+			default:
+				t.sendError(tftpErrIllegalOp, "unexpected packet")
+				return fmt.Errorf("client sent unexpected opcode %d", binary.BigEndian.Uint16(buf[0:2]))
+			}
+		}
+	}
+
+	return fmt.Errorf("timed out waiting for ack of block %d", block)
+}
+
+func (t *tftpTransfer) sendError(code uint16, message string) {
+	packet := make([]byte, 4, 5+len(message))
+	binary.BigEndian.PutUint16(packet[0:2], tftpOpError)
+	binary.BigEndian.PutUint16(packet[2:4], code)
+	packet = append(packet, message...)
+	packet = append(packet, 0)
+
+	_, _ = t.conn.WriteToUDP(packet, t.addr)
+}
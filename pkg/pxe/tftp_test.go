@@ -0,0 +1,244 @@
+package pxe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tftpClient is a minimal tftp client that talks to a single transfer of the server over loopback
+type tftpClient struct {
+	t        *testing.T
+	conn     *net.UDPConn
+	transfer *net.UDPAddr
+}
+
+func newTFTPClient(t *testing.T) *tftpClient {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+
+	return &tftpClient{t: t, conn: conn}
+}
+
+// helper method to start a transfer for the read request on the server
+func (c *tftpClient) request(s *TFTPServer, opcode uint16, filename string, options ...string) {
+	req := make([]byte, 2)
+	binary.BigEndian.PutUint16(req, opcode)
+	for _, field := range append([]string{filename, "octet"}, options...) {
+		req = append(req, field...)
+		req = append(req, 0)
+	}
+
+	go s.handle(req, c.conn.LocalAddr().(*net.UDPAddr))
+}
+
+// helper method to read the next packet from the transfer, it returns the opcode and the rest of the packet
+func (c *tftpClient) read() (uint16, []byte) {
+	buf := make([]byte, 65536)
+	if err := c.conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		c.t.Fatalf("error setting deadline: %v", err)
+	}
+
+	n, addr, err := c.conn.ReadFromUDP(buf)
+	if err != nil {
+		c.t.Fatalf("error reading packet: %v", err)
+	}
+	if n < 4 {
+		c.t.Fatalf("packet is too short: %v", buf[:n])
+	}
+	c.transfer = addr
+
+	return binary.BigEndian.Uint16(buf[0:2]), buf[2:n]
+}
+
+func (c *tftpClient) ack(block uint16) {
+	packet := make([]byte, 4)
+	binary.BigEndian.PutUint16(packet[0:2], tftpOpAck)
+	binary.BigEndian.PutUint16(packet[2:4], block)
+
+	if _, err := c.conn.WriteToUDP(packet, c.transfer); err != nil {
+		c.t.Fatalf("error sending ack: %v", err)
+	}
+}
+
+func TestTFTPTransfer(t *testing.T) {
+	root, err := ioutil.TempDir("", "tftp")
+	if err != nil {
+		t.Fatalf("error creating root: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string][]byte{
+		"short.kpxe": bytes.Repeat([]byte{1}, 100),
+		"exact.kpxe": bytes.Repeat([]byte{2}, 2*tftpDefaultBlockSize),
+		"large.efi":  bytes.Repeat([]byte{3, 4, 5}, 1000),
+		"empty.kpxe": {},
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(root, name), data, 0644); err != nil {
+			t.Fatalf("error writing %s: %v", name, err)
+		}
+	}
+
+	s := NewTFTPServer("127.0.0.1:0", root)
+
+	tests := []struct {
+		name    string
+		file    string
+		options []string
+		oack    map[string]string
+		blocks  []int
+	}{
+		{
+			name:   "final short block",
+			file:   "short.kpxe",
+			blocks: []int{100},
+		},
+		{
+			name:   "final empty block",
+			file:   "exact.kpxe",
+			blocks: []int{512, 512, 0},
+		},
+		{
+			name:   "empty file",
+			file:   "empty.kpxe",
+			blocks: []int{0},
+		},
+		{
+			name:    "blksize and tsize",
+			file:    "large.efi",
+			options: []string{"blksize", "1024", "tsize", "0"},
+			oack:    map[string]string{"blksize": "1024", "tsize": "3000"},
+			blocks:  []int{1024, 1024, 952},
+		},
+		{
+			name:    "option names are case insensitive",
+			file:    "/large.efi",
+			options: []string{"BLKSIZE", "1468", "TSize", "0", "timeout", "1"},
+			oack:    map[string]string{"blksize": "1468", "tsize": "3000", "timeout": "1"},
+			blocks:  []int{1468, 1468, 64},
+		},
+		{
+			name:    "blksize is limited",
+			file:    "short.kpxe",
+			options: []string{"blksize", "70000"},
+			oack:    map[string]string{"blksize": "65464"},
+			blocks:  []int{100},
+		},
+		{
+			name:    "invalid options are ignored",
+			file:    "short.kpxe",
+			options: []string{"blksize", "4", "timeout", "0", "unknown", "1"},
+			blocks:  []int{100},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTFTPClient(t)
+			defer c.conn.Close()
+
+			c.request(s, tftpOpRRQ, test.file, test.options...)
+
+			if test.oack != nil {
+				opcode, data := c.read()
+				if opcode != tftpOpOACK {
+					t.Fatalf("expected an option acknowledgment got opcode %d", opcode)
+				}
+
+				fields := strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+				oack := make(map[string]string)
+				for i := 0; i+1 < len(fields); i += 2 {
+					oack[fields[i]] = fields[i+1]
+				}
+				if len(oack) != len(test.oack) {
+					t.Fatalf("expected options %v got %v", test.oack, oack)
+				}
+				for name, value := range test.oack {
+					if oack[name] != value {
+						t.Fatalf("expected options %v got %v", test.oack, oack)
+					}
+				}
+				c.ack(0)
+			}
+
+			var received []byte
+			for i, size := range test.blocks {
+				opcode, data := c.read()
+				if opcode != tftpOpData {
+					t.Fatalf("expected data got opcode %d: %s", opcode, data)
+				}
+				if block := binary.BigEndian.Uint16(data[0:2]); block != uint16(i+1) {
+					t.Fatalf("expected block %d got %d", i+1, block)
+				}
+				if len(data)-2 != size {
+					t.Fatalf("expected block %d to have %d bytes got %d", i+1, size, len(data)-2)
+				}
+				received = append(received, data[2:]...)
+				c.ack(uint16(i + 1))
+			}
+
+			if bytes.Equal(received, files[strings.TrimPrefix(test.file, "/")]) == false {
+				t.Errorf("received file does not match")
+			}
+		})
+	}
+}
+
+func TestTFTPErrors(t *testing.T) {
+	root, err := ioutil.TempDir("", "tftp")
+	if err != nil {
+		t.Fatalf("error creating root: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := os.Mkdir(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatalf("error creating dir: %v", err)
+	}
+
+	// a file next to the root that must not be readable through the server
+	outside := root + ".secret"
+	if err := ioutil.WriteFile(outside, []byte("secret"), 0644); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	defer os.Remove(outside)
+
+	s := NewTFTPServer("127.0.0.1:0", root)
+
+	tests := []struct {
+		name   string
+		opcode uint16
+		file   string
+		code   uint16
+	}{
+		{name: "write request", opcode: 2, file: "undionly.kpxe", code: tftpErrIllegalOp},
+		{name: "not found", opcode: tftpOpRRQ, file: "undionly.kpxe", code: tftpErrFileNotFound},
+		{name: "directory", opcode: tftpOpRRQ, file: "dir", code: tftpErrAccessViolated},
+		{name: "outside of root", opcode: tftpOpRRQ, file: "../" + filepath.Base(outside), code: tftpErrFileNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTFTPClient(t)
+			defer c.conn.Close()
+
+			c.request(s, test.opcode, test.file)
+
+			opcode, data := c.read()
+			if opcode != tftpOpError {
+				t.Fatalf("expected an error got opcode %d", opcode)
+			}
+			if code := binary.BigEndian.Uint16(data[0:2]); code != test.code {
+				t.Errorf("expected error code %d got %d: %s", test.code, code, data[2:])
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package quota
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+const (
+	InstancesResource = "instances"
+	CPUSResource      = "cpus"
+	RamResource       = "ram"
+)
+
+// Usage is what the instances of a namespace count against its quotas
+type Usage struct {
+	// The instances that aren't being deleted
+	Instances int64
+
+	// The cpus and ram of the hardware assigned to the instances
+	CPUS resource.Quantity
+	Ram  resource.Quantity
+}
+
+// HardwareResources returns the cpus and ram of the hardware
+// hardware that hasn't been discovered yet counts as nothing
+func HardwareResources(bmh *baremetalv1alpha1.BareMetalHardware) (resource.Quantity, resource.Quantity) {
+	if bmh.Status.Hardware == nil {
+		return resource.Quantity{}, resource.Quantity{}
+	}
+
+	return bmh.Status.Hardware.CPU.CPUS, bmh.Status.Hardware.Ram
+}
+
+// Calculate returns the usage of the instances
+// hardware looks up the hardware assigned to an instance, returning nil when it doesn't exist
+// cluster hardware is looked up as a BareMetalHardware without a namespace
+func Calculate(instances []*baremetalv1alpha1.BareMetalInstance, hardware func(bmi *baremetalv1alpha1.BareMetalInstance) *baremetalv1alpha1.BareMetalHardware) Usage {
+	usage := Usage{}
+
+	for _, bmi := range instances {
+		if bmi.DeletionTimestamp.IsZero() {
+			usage.Instances++
+		}
+
+		if len(bmi.Status.HardwareName) == 0 {
+			continue
+		}
+
+		bmh := hardware(bmi)
+		if bmh == nil {
+			continue
+		}
+
+		usage = usage.AddHardware(bmh)
+	}
+
+	return usage
+}
+
+// Requested returns the cpus and ram requested by the instance
+func Requested(bmi *baremetalv1alpha1.BareMetalInstance) Usage {
+	usage := Usage{}
+	if bmi.Spec.Resources == nil {
+		return usage
+	}
+
+	if bmi.Spec.Resources.CPUS != nil {
+		usage.CPUS = bmi.Spec.Resources.CPUS.DeepCopy()
+	}
+	if bmi.Spec.Resources.Ram != nil {
+		usage.Ram = bmi.Spec.Resources.Ram.DeepCopy()
+	}
+
+	return usage
+}
+
+// AddHardware returns the usage with the cpus and ram of the hardware added
+func (u Usage) AddHardware(bmh *baremetalv1alpha1.BareMetalHardware) Usage {
+	cpus, ram := HardwareResources(bmh)
+
+	return u.Add(Usage{CPUS: cpus, Ram: ram})
+}
+
+// Add returns the sum of both usages
+func (u Usage) Add(other Usage) Usage {
+	// quantities share their internal state when copied so they need a deep copy before being changed
+	sum := Usage{
+		Instances: u.Instances + other.Instances,
+		CPUS:      u.CPUS.DeepCopy(),
+		Ram:       u.Ram.DeepCopy(),
+	}
+	sum.CPUS.Add(other.CPUS)
+	sum.Ram.Add(other.Ram)
+
+	return sum
+}
+
+// Resources returns the usage the way it is reported in the quota status
+func (u Usage) Resources() baremetalv1alpha1.BareMetalQuotaResources {
+	instances := u.Instances
+	cpus := u.CPUS.DeepCopy()
+	ram := u.Ram.DeepCopy()
+
+	return baremetalv1alpha1.BareMetalQuotaResources{
+		Instances: &instances,
+		CPUS:      &cpus,
+		Ram:       &ram,
+	}
+}
+
+// Exceeded returns the resources of the usage that are over the hard limits
+func Exceeded(hard baremetalv1alpha1.BareMetalQuotaResources, usage Usage) []string {
+	var exceeded []string
+
+	if hard.Instances != nil && usage.Instances > *hard.Instances {
+		exceeded = append(exceeded, InstancesResource)
+	}
+
+	return append(exceeded, ExceededHardware(hard, usage)...)
+}
+
+// ExceededHardware returns the hardware resources of the usage that are over the hard limits
+// the number of instances is only limited when they are created so it isn't checked
+func ExceededHardware(hard baremetalv1alpha1.BareMetalQuotaResources, usage Usage) []string {
+	var exceeded []string
+
+	if hard.CPUS != nil && usage.CPUS.Cmp(*hard.CPUS) > 0 {
+		exceeded = append(exceeded, CPUSResource)
+	}
+
+	if hard.Ram != nil && usage.Ram.Cmp(*hard.Ram) > 0 {
+		exceeded = append(exceeded, RamResource)
+	}
+
+	return exceeded
+}
@@ -0,0 +1,250 @@
+package quota
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+func testHardware(name string, cpus, ram string) *baremetalv1alpha1.BareMetalHardware {
+	bmh := &baremetalv1alpha1.BareMetalHardware{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	if len(cpus) > 0 {
+		bmh.Status.Hardware = &baremetalv1alpha1.BareMetalDiscoveryHardware{
+			CPU: baremetalv1alpha1.BareMetalDiscoveryHardwareCPU{CPUS: resource.MustParse(cpus)},
+			Ram: resource.MustParse(ram),
+		}
+	}
+
+	return bmh
+}
+
+func testInstance(name, hardwareName string, clusterHardware bool, deleting bool) *baremetalv1alpha1.BareMetalInstance {
+	bmi := &baremetalv1alpha1.BareMetalInstance{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+	}
+	bmi.Status.HardwareName = hardwareName
+	bmi.Status.ClusterHardware = clusterHardware
+	if deleting {
+		now := metav1.Now()
+		bmi.DeletionTimestamp = &now
+	}
+
+	return bmi
+}
+
+func quantity(value string) *resource.Quantity {
+	q := resource.MustParse(value)
+	return &q
+}
+
+func instances(value int64) *int64 {
+	return &value
+}
+
+func TestCalculate(t *testing.T) {
+	hardware := map[string]*baremetalv1alpha1.BareMetalHardware{
+		"small": testHardware("small", "4", "8Gi"),
+		"large": testHardware("large", "32", "128Gi"),
+		// not discovered yet
+		"unknown": testHardware("unknown", "", ""),
+	}
+	clusterHardware := map[string]*baremetalv1alpha1.BareMetalHardware{
+		"small": testHardware("small", "8", "16Gi"),
+	}
+
+	// the same lookup the webhook and quota controller use
+	lookup := func(bmi *baremetalv1alpha1.BareMetalInstance) *baremetalv1alpha1.BareMetalHardware {
+		if bmi.Status.ClusterHardware {
+			return clusterHardware[bmi.Status.HardwareName]
+		}
+		return hardware[bmi.Status.HardwareName]
+	}
+
+	tests := []struct {
+		name      string
+		instances []*baremetalv1alpha1.BareMetalInstance
+		usage     baremetalv1alpha1.BareMetalQuotaResources
+	}{
+		{
+			name:  "no instances",
+			usage: baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(0), CPUS: quantity("0"), Ram: quantity("0")},
+		},
+		{
+			name: "scheduled",
+			instances: []*baremetalv1alpha1.BareMetalInstance{
+				testInstance("a", "small", false, false),
+				testInstance("b", "large", false, false),
+			},
+			usage: baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(2), CPUS: quantity("36"), Ram: quantity("136Gi")},
+		},
+		{
+			name: "pending only count as instances",
+			instances: []*baremetalv1alpha1.BareMetalInstance{
+				testInstance("a", "", false, false),
+				testInstance("b", "small", false, false),
+			},
+			usage: baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(2), CPUS: quantity("4"), Ram: quantity("8Gi")},
+		},
+		{
+			name: "cluster hardware",
+			instances: []*baremetalv1alpha1.BareMetalInstance{
+				testInstance("a", "small", true, false),
+				testInstance("b", "small", false, false),
+			},
+			usage: baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(2), CPUS: quantity("12"), Ram: quantity("24Gi")},
+		},
+		{
+			name: "deleting keeps the hardware until it is released",
+			instances: []*baremetalv1alpha1.BareMetalInstance{
+				testInstance("a", "large", false, true),
+				testInstance("b", "", false, true),
+			},
+			usage: baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(0), CPUS: quantity("32"), Ram: quantity("128Gi")},
+		},
+		{
+			name: "missing or undiscovered hardware",
+			instances: []*baremetalv1alpha1.BareMetalInstance{
+				testInstance("a", "gone", false, false),
+				testInstance("b", "large", true, false),
+				testInstance("c", "unknown", false, false),
+			},
+			usage: baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(3), CPUS: quantity("0"), Ram: quantity("0")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			usage := Calculate(test.instances, lookup).Resources()
+
+			if *usage.Instances != *test.usage.Instances {
+				t.Errorf("expected %d instances got %d", *test.usage.Instances, *usage.Instances)
+			}
+			if usage.CPUS.Cmp(*test.usage.CPUS) != 0 {
+				t.Errorf("expected %s cpus got %s", test.usage.CPUS.String(), usage.CPUS.String())
+			}
+			if usage.Ram.Cmp(*test.usage.Ram) != 0 {
+				t.Errorf("expected %s ram got %s", test.usage.Ram.String(), usage.Ram.String())
+			}
+		})
+	}
+}
+
+func TestUsageAdd(t *testing.T) {
+	usage := Usage{Instances: 1, CPUS: resource.MustParse("4"), Ram: resource.MustParse("8Gi")}
+
+	withHardware := usage.AddHardware(testHardware("a", "8", "16Gi"))
+	if withHardware.Instances != 1 || withHardware.CPUS.Cmp(resource.MustParse("12")) != 0 || withHardware.Ram.Cmp(resource.MustParse("24Gi")) != 0 {
+		t.Errorf("expected 1 instance 12 cpus 24Gi ram got %d %s %s", withHardware.Instances, withHardware.CPUS.String(), withHardware.Ram.String())
+	}
+
+	undiscovered := usage.AddHardware(testHardware("b", "", ""))
+	if undiscovered.CPUS.Cmp(usage.CPUS) != 0 || undiscovered.Ram.Cmp(usage.Ram) != 0 {
+		t.Errorf("expected undiscovered hardware to add nothing got %s %s", undiscovered.CPUS.String(), undiscovered.Ram.String())
+	}
+
+	// adding must not change the original usage
+	if usage.CPUS.Cmp(resource.MustParse("4")) != 0 || usage.Ram.Cmp(resource.MustParse("8Gi")) != 0 {
+		t.Errorf("expected the original usage to be unchanged got %s %s", usage.CPUS.String(), usage.Ram.String())
+	}
+}
+
+func TestRequested(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources *baremetalv1alpha1.BareMetalInstanceResources
+		cpus      string
+		ram       string
+	}{
+		{
+			name: "no resources",
+			cpus: "0",
+			ram:  "0",
+		},
+		{
+			name:      "cpus only",
+			resources: &baremetalv1alpha1.BareMetalInstanceResources{CPUS: quantity("4")},
+			cpus:      "4",
+			ram:       "0",
+		},
+		{
+			name:      "cpus and ram",
+			resources: &baremetalv1alpha1.BareMetalInstanceResources{CPUS: quantity("4"), Ram: quantity("8Gi"), ImageDriveSize: quantity("100Gi")},
+			cpus:      "4",
+			ram:       "8Gi",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bmi := testInstance("a", "", false, false)
+			bmi.Spec.Resources = test.resources
+
+			usage := Requested(bmi)
+			if usage.Instances != 0 {
+				t.Errorf("expected no instances got %d", usage.Instances)
+			}
+			if usage.CPUS.Cmp(resource.MustParse(test.cpus)) != 0 || usage.Ram.Cmp(resource.MustParse(test.ram)) != 0 {
+				t.Errorf("expected %s cpus %s ram got %s %s", test.cpus, test.ram, usage.CPUS.String(), usage.Ram.String())
+			}
+		})
+	}
+}
+
+func TestExceeded(t *testing.T) {
+	usage := Usage{Instances: 3, CPUS: resource.MustParse("12"), Ram: resource.MustParse("24Gi")}
+
+	tests := []struct {
+		name     string
+		hard     baremetalv1alpha1.BareMetalQuotaResources
+		exceeded []string
+		hardware []string
+	}{
+		{
+			name: "no limits",
+		},
+		{
+			name: "at the limits",
+			hard: baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(3), CPUS: quantity("12"), Ram: quantity("24Gi")},
+		},
+		{
+			name:     "instances",
+			hard:     baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(2)},
+			exceeded: []string{InstancesResource},
+		},
+		{
+			name:     "cpus",
+			hard:     baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(10), CPUS: quantity("11")},
+			exceeded: []string{CPUSResource},
+			hardware: []string{CPUSResource},
+		},
+		{
+			name:     "ram",
+			hard:     baremetalv1alpha1.BareMetalQuotaResources{Ram: quantity("16Gi")},
+			exceeded: []string{RamResource},
+			hardware: []string{RamResource},
+		},
+		{
+			name:     "everything",
+			hard:     baremetalv1alpha1.BareMetalQuotaResources{Instances: instances(0), CPUS: quantity("0"), Ram: quantity("0")},
+			exceeded: []string{InstancesResource, CPUSResource, RamResource},
+			hardware: []string{CPUSResource, RamResource},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if exceeded := Exceeded(test.hard, usage); reflect.DeepEqual(exceeded, test.exceeded) == false {
+				t.Errorf("expected exceeded %v got %v", test.exceeded, exceeded)
+			}
+			if exceeded := ExceededHardware(test.hard, usage); reflect.DeepEqual(exceeded, test.hardware) == false {
+				t.Errorf("expected exceeded hardware %v got %v", test.hardware, exceeded)
+			}
+		})
+	}
+}
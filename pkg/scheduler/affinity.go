@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+const (
+	HardwareAffinityName = "HardwareAffinity"
+	InstanceAffinityName = "InstanceAffinity"
+)
+
+// HardwareAffinity prefers hardware that matches the preferred hardware affinity terms of the instance
+type HardwareAffinity struct{}
+
+func NewHardwareAffinity(args json.RawMessage) (ScorePlugin, error) {
+	return &HardwareAffinity{}, nil
+}
+
+func (p *HardwareAffinity) Name() string {
+	return HardwareAffinityName
+}
+
+func (p *HardwareAffinity) Score(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error) {
+	raw := make([]int64, len(hardware))
+
+	if bmi.Spec.Affinity == nil || bmi.Spec.Affinity.HardwareAffinity == nil {
+		return raw, nil
+	}
+
+	for _, term := range bmi.Spec.Affinity.HardwareAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		selector, err := term.Preference.Selector()
+		if err != nil {
+			return nil, err
+		}
+
+		for i, bmh := range hardware {
+			if selector.Matches(labels.Set(bmh.Labels)) {
+				raw[i] += int64(term.Weight)
+			}
+		}
+	}
+
+	return normalizeScores(raw, 0), nil
+}
+
+// InstanceAffinityFilter filters out hardware that doesn't satisfy the required instance affinity and anti-affinity
+// of the instance or the required anti-affinity of the instances that are already assigned
+type InstanceAffinityFilter struct{}
+
+func NewInstanceAffinityFilter(args json.RawMessage) (FilterPlugin, error) {
+	return &InstanceAffinityFilter{}, nil
+}
+
+func (p *InstanceAffinityFilter) Name() string {
+	return InstanceAffinityName
+}
+
+func (p *InstanceAffinityFilter) Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) []string {
+	assigned := assignedInstances(snapshot, bmi)
+
+	// anti-affinity is symmetric so assigned instances can keep this instance out of their domain
+	for _, other := range antiAffinityInstances(snapshot, bmi) {
+		for _, term := range other.bmi.Spec.Affinity.InstanceAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			if sameDomain(other.bmh, bmh, term.TopologyKey) && selectorFor(term.LabelSelector).Matches(labels.Set(bmi.Labels)) {
+				return []string{"didn't satisfy existing instances anti-affinity rules"}
+			}
+		}
+	}
+
+	if bmi.Spec.Affinity == nil {
+		return nil
+	}
+
+	if affinity := bmi.Spec.Affinity.InstanceAffinity; affinity != nil {
+		for _, term := range affinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			selector := selectorFor(term.LabelSelector)
+
+			matchesAny := false
+			matchesDomain := false
+			for _, other := range assigned {
+				if selector.Matches(labels.Set(other.bmi.Labels)) == false {
+					continue
+				}
+				matchesAny = true
+
+				if sameDomain(other.bmh, bmh, term.TopologyKey) {
+					matchesDomain = true
+					break
+				}
+			}
+
+			if matchesDomain {
+				continue
+			}
+
+			// the first instance of a group that matches its own term can go into any domain
+			if _, ok := bmh.Labels[term.TopologyKey]; ok && matchesAny == false && selector.Matches(labels.Set(bmi.Labels)) {
+				continue
+			}
+
+			return []string{"didn't match instance affinity rules"}
+		}
+	}
+
+	if antiAffinity := bmi.Spec.Affinity.InstanceAntiAffinity; antiAffinity != nil {
+		for _, term := range antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			selector := selectorFor(term.LabelSelector)
+
+			for _, other := range assigned {
+				if sameDomain(other.bmh, bmh, term.TopologyKey) && selector.Matches(labels.Set(other.bmi.Labels)) {
+					return []string{"didn't match instance anti-affinity rules"}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// InstanceAffinityScore prefers hardware that satisfies the preferred instance affinity and anti-affinity of the instance
+// every matching instance in the domain of the hardware adds the weight of the term for affinity and removes it for anti-affinity
+type InstanceAffinityScore struct{}
+
+func NewInstanceAffinityScore(args json.RawMessage) (ScorePlugin, error) {
+	return &InstanceAffinityScore{}, nil
+}
+
+func (p *InstanceAffinityScore) Name() string {
+	return InstanceAffinityName
+}
+
+func (p *InstanceAffinityScore) Score(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error) {
+	raw := make([]int64, len(hardware))
+
+	if bmi.Spec.Affinity == nil {
+		return raw, nil
+	}
+
+	assigned := assignedInstances(snapshot, bmi)
+
+	addTerms := func(terms []baremetalv1alpha1.WeightedInstanceAffinityTerm, sign int64) {
+		for _, term := range terms {
+			selector := selectorFor(term.InstanceAffinityTerm.LabelSelector)
+
+			for _, other := range assigned {
+				if selector.Matches(labels.Set(other.bmi.Labels)) == false {
+					continue
+				}
+
+				for i, bmh := range hardware {
+					if sameDomain(other.bmh, bmh, term.InstanceAffinityTerm.TopologyKey) {
+						raw[i] += sign * int64(term.Weight)
+					}
+				}
+			}
+		}
+	}
+
+	if affinity := bmi.Spec.Affinity.InstanceAffinity; affinity != nil {
+		addTerms(affinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+	}
+	if antiAffinity := bmi.Spec.Affinity.InstanceAntiAffinity; antiAffinity != nil {
+		addTerms(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, -1)
+	}
+
+	return normalizeScores(raw, 0), nil
+}
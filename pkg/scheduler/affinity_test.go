@@ -0,0 +1,412 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+const testTopologyKey = "topology.kubernetes.io/zone"
+
+// zonedHardware returns hardware a and b in zone-1, c and d in zone-2, e in zone-3 and f without a zone
+func zonedHardware() []*baremetalv1alpha1.BareMetalHardware {
+	hardware := namedHardware("a", "b", "c", "d", "e", "f")
+	zones := []string{"zone-1", "zone-1", "zone-2", "zone-2", "zone-3"}
+	for i, zone := range zones {
+		hardware[i].Labels = map[string]string{testTopologyKey: zone}
+	}
+
+	return hardware
+}
+
+// labeledInstance returns an instance with the app label, it is assigned to the hardware when the hardware name is set
+func labeledInstance(name, app, hardwareName string) *baremetalv1alpha1.BareMetalInstance {
+	bmi := pendingInstance(name, nil, time.Minute)
+	bmi.UID = types.UID(name)
+	bmi.Labels = map[string]string{"app": app}
+	if len(hardwareName) > 0 {
+		bmi.Status.HardwareName = hardwareName
+		bmi.Status.Phase = baremetalv1alpha1.BareMetalInstanceStatusPhaseRunning
+	}
+
+	return bmi
+}
+
+func appSelector(app string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}
+}
+
+func instanceTerm(app string) baremetalv1alpha1.InstanceAffinityTerm {
+	return baremetalv1alpha1.InstanceAffinityTerm{LabelSelector: appSelector(app), TopologyKey: testTopologyKey}
+}
+
+func weightedInstanceTerm(app string, weight int32) baremetalv1alpha1.WeightedInstanceAffinityTerm {
+	return baremetalv1alpha1.WeightedInstanceAffinityTerm{Weight: weight, InstanceAffinityTerm: instanceTerm(app)}
+}
+
+// helper method to return the names of the hardware that passes the filter
+func filterHardware(plugin FilterPlugin, snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance) []string {
+	names := make([]string, 0, len(snapshot.Hardware))
+	for _, bmh := range snapshot.Hardware {
+		if len(plugin.Filter(snapshot, bmi, bmh)) == 0 {
+			names = append(names, bmh.Name)
+		}
+	}
+
+	return names
+}
+
+func TestInstanceAffinityFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		app      string
+		affinity *baremetalv1alpha1.Affinity
+		existing []*baremetalv1alpha1.BareMetalInstance
+		feasible []string
+	}{
+		{
+			name:     "no affinity",
+			app:      "web",
+			feasible: []string{"a", "b", "c", "d", "e", "f"},
+		},
+		{
+			name: "required affinity",
+			app:  "web",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAffinity: &baremetalv1alpha1.InstanceAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.InstanceAffinityTerm{instanceTerm("db")},
+			}},
+			feasible: []string{"c", "d"},
+		},
+		{
+			name: "required affinity to every term",
+			app:  "web",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAffinity: &baremetalv1alpha1.InstanceAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.InstanceAffinityTerm{instanceTerm("db"), instanceTerm("web")},
+			}},
+			feasible: []string{},
+		},
+		{
+			name: "required affinity without matching instances",
+			app:  "web",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAffinity: &baremetalv1alpha1.InstanceAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.InstanceAffinityTerm{instanceTerm("cache")},
+			}},
+			feasible: []string{},
+		},
+		{
+			name: "first instance of a group matching its own term",
+			app:  "batch",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAffinity: &baremetalv1alpha1.InstanceAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.InstanceAffinityTerm{instanceTerm("batch")},
+			}},
+			feasible: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name: "required anti-affinity",
+			app:  "web",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAntiAffinity: &baremetalv1alpha1.InstanceAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.InstanceAffinityTerm{instanceTerm("web")},
+			}},
+			feasible: []string{"c", "d", "e", "f"},
+		},
+		{
+			name: "anti-affinity of existing instances",
+			app:  "web",
+			existing: []*baremetalv1alpha1.BareMetalInstance{
+				func() *baremetalv1alpha1.BareMetalInstance {
+					bmi := labeledInstance("lonely", "lonely", "e")
+					bmi.Spec.Affinity = &baremetalv1alpha1.Affinity{InstanceAntiAffinity: &baremetalv1alpha1.InstanceAntiAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.InstanceAffinityTerm{instanceTerm("web")},
+					}}
+					return bmi
+				}(),
+			},
+			feasible: []string{"a", "b", "c", "d", "f"},
+		},
+		{
+			name: "terminated and pending instances are ignored",
+			app:  "web",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAntiAffinity: &baremetalv1alpha1.InstanceAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.InstanceAffinityTerm{instanceTerm("batch")},
+			}},
+			existing: []*baremetalv1alpha1.BareMetalInstance{
+				func() *baremetalv1alpha1.BareMetalInstance {
+					bmi := labeledInstance("terminated", "batch", "a")
+					bmi.Status.Phase = baremetalv1alpha1.BareMetalInstanceStatusPhaseTerminated
+					return bmi
+				}(),
+				labeledInstance("pending", "batch", ""),
+			},
+			feasible: []string{"a", "b", "c", "d", "e", "f"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bmi := labeledInstance("new", test.app, "")
+			bmi.Spec.Affinity = test.affinity
+
+			instances := append([]*baremetalv1alpha1.BareMetalInstance{
+				labeledInstance("web-1", "web", "a"),
+				labeledInstance("db-1", "db", "c"),
+				bmi,
+			}, test.existing...)
+			snapshot := &Snapshot{Hardware: zonedHardware(), Instances: instances}
+
+			if feasible := filterHardware(&InstanceAffinityFilter{}, snapshot, bmi); reflect.DeepEqual(feasible, test.feasible) == false {
+				t.Errorf("expected feasible %v got %v", test.feasible, feasible)
+			}
+		})
+	}
+}
+
+func TestInstanceAffinityScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		affinity *baremetalv1alpha1.Affinity
+		scores   []int64
+	}{
+		{
+			name:   "no affinity",
+			scores: []int64{0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "preferred affinity",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAffinity: &baremetalv1alpha1.InstanceAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.WeightedInstanceAffinityTerm{weightedInstanceTerm("db", 10)},
+			}},
+			scores: []int64{0, 0, 100, 100, 0, 0},
+		},
+		{
+			name: "preferred anti-affinity",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAntiAffinity: &baremetalv1alpha1.InstanceAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.WeightedInstanceAffinityTerm{weightedInstanceTerm("web", 10)},
+			}},
+			// zone-1 has two web instances and zone-3 has one
+			scores: []int64{0, 0, 100, 100, 50, 100},
+		},
+		{
+			name: "every matching instance adds the weight",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAffinity: &baremetalv1alpha1.InstanceAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.WeightedInstanceAffinityTerm{weightedInstanceTerm("web", 10)},
+			}},
+			// zone-1 has two web instances and zone-3 has one
+			scores: []int64{100, 100, 0, 0, 50, 0},
+		},
+		{
+			name: "affinity and anti-affinity",
+			affinity: &baremetalv1alpha1.Affinity{
+				InstanceAffinity: &baremetalv1alpha1.InstanceAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.WeightedInstanceAffinityTerm{weightedInstanceTerm("db", 20)},
+				},
+				InstanceAntiAffinity: &baremetalv1alpha1.InstanceAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.WeightedInstanceAffinityTerm{weightedInstanceTerm("web", 5)},
+				},
+			},
+			// zone-1 is -10, zone-2 is 20, zone-3 is -5 and f is 0
+			scores: []int64{0, 0, 100, 100, 16, 33},
+		},
+		{
+			name: "no matching instances",
+			affinity: &baremetalv1alpha1.Affinity{InstanceAffinity: &baremetalv1alpha1.InstanceAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []baremetalv1alpha1.WeightedInstanceAffinityTerm{weightedInstanceTerm("cache", 10)},
+			}},
+			scores: []int64{0, 0, 0, 0, 0, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bmi := labeledInstance("new", "web", "")
+			bmi.Spec.Affinity = test.affinity
+
+			hardware := zonedHardware()
+			snapshot := &Snapshot{Hardware: hardware, Instances: []*baremetalv1alpha1.BareMetalInstance{
+				labeledInstance("web-1", "web", "a"),
+				labeledInstance("web-2", "web", "b"),
+				labeledInstance("web-3", "web", "e"),
+				labeledInstance("db-1", "db", "c"),
+				bmi,
+			}}
+
+			scores, err := (&InstanceAffinityScore{}).Score(snapshot, bmi, hardware)
+			if err != nil {
+				t.Fatalf("error scoring: %v", err)
+			}
+			if reflect.DeepEqual(scores, test.scores) == false {
+				t.Errorf("expected scores %v got %v", test.scores, scores)
+			}
+		})
+	}
+}
+
+func hardwareTerm(key string, operator baremetalv1alpha1.HardwareSelectorOperator, values ...string) baremetalv1alpha1.HardwareSelectorTerm {
+	return baremetalv1alpha1.HardwareSelectorTerm{MatchExpressions: []baremetalv1alpha1.HardwareSelectorRequirement{
+		{Key: key, Operator: operator, Values: values},
+	}}
+}
+
+// ratedHardware returns the zoned hardware with a cpu generation label on all but f
+func ratedHardware() []*baremetalv1alpha1.BareMetalHardware {
+	hardware := zonedHardware()
+	for i, generation := range []string{"1", "2", "3", "4", "5"} {
+		hardware[i].Labels["cpu-generation"] = generation
+	}
+
+	return hardware
+}
+
+func TestHardwareAffinityFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector map[string]string
+		terms    []baremetalv1alpha1.HardwareSelectorTerm
+		feasible []string
+	}{
+		{
+			name:     "no affinity",
+			feasible: []string{"a", "b", "c", "d", "e", "f"},
+		},
+		{
+			name:     "in",
+			terms:    []baremetalv1alpha1.HardwareSelectorTerm{hardwareTerm(testTopologyKey, baremetalv1alpha1.HardwareSelectorOpIn, "zone-1", "zone-3")},
+			feasible: []string{"a", "b", "e"},
+		},
+		{
+			name:     "not in matches hardware without the label",
+			terms:    []baremetalv1alpha1.HardwareSelectorTerm{hardwareTerm(testTopologyKey, baremetalv1alpha1.HardwareSelectorOpNotIn, "zone-1")},
+			feasible: []string{"c", "d", "e", "f"},
+		},
+		{
+			name:     "exists",
+			terms:    []baremetalv1alpha1.HardwareSelectorTerm{hardwareTerm(testTopologyKey, baremetalv1alpha1.HardwareSelectorOpExists)},
+			feasible: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:     "does not exist",
+			terms:    []baremetalv1alpha1.HardwareSelectorTerm{hardwareTerm(testTopologyKey, baremetalv1alpha1.HardwareSelectorOpDoesNotExist)},
+			feasible: []string{"f"},
+		},
+		{
+			name:     "greater than",
+			terms:    []baremetalv1alpha1.HardwareSelectorTerm{hardwareTerm("cpu-generation", baremetalv1alpha1.HardwareSelectorOpGt, "3")},
+			feasible: []string{"d", "e"},
+		},
+		{
+			name:     "less than",
+			terms:    []baremetalv1alpha1.HardwareSelectorTerm{hardwareTerm("cpu-generation", baremetalv1alpha1.HardwareSelectorOpLt, "2")},
+			feasible: []string{"a"},
+		},
+		{
+			name: "requirements of a term are anded",
+			terms: []baremetalv1alpha1.HardwareSelectorTerm{{MatchExpressions: []baremetalv1alpha1.HardwareSelectorRequirement{
+				{Key: testTopologyKey, Operator: baremetalv1alpha1.HardwareSelectorOpIn, Values: []string{"zone-2"}},
+				{Key: "cpu-generation", Operator: baremetalv1alpha1.HardwareSelectorOpGt, Values: []string{"3"}},
+			}}},
+			feasible: []string{"d"},
+		},
+		{
+			name: "terms are ored",
+			terms: []baremetalv1alpha1.HardwareSelectorTerm{
+				hardwareTerm(testTopologyKey, baremetalv1alpha1.HardwareSelectorOpIn, "zone-3"),
+				hardwareTerm(testTopologyKey, baremetalv1alpha1.HardwareSelectorOpDoesNotExist),
+			},
+			feasible: []string{"e", "f"},
+		},
+		{
+			name:     "a term without requirements matches nothing",
+			terms:    []baremetalv1alpha1.HardwareSelectorTerm{{}},
+			feasible: []string{},
+		},
+		{
+			name:     "selector and affinity",
+			selector: map[string]string{testTopologyKey: "zone-2"},
+			terms:    []baremetalv1alpha1.HardwareSelectorTerm{hardwareTerm("cpu-generation", baremetalv1alpha1.HardwareSelectorOpLt, "4")},
+			feasible: []string{"c"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bmi := labeledInstance("new", "web", "")
+			bmi.Spec.Selector = test.selector
+			if test.terms != nil {
+				bmi.Spec.Affinity = &baremetalv1alpha1.Affinity{HardwareAffinity: &baremetalv1alpha1.HardwareAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &baremetalv1alpha1.HardwareSelector{HardwareSelectorTerms: test.terms},
+				}}
+			}
+
+			snapshot := &Snapshot{Hardware: ratedHardware()}
+			if feasible := filterHardware(&HardwareSelector{}, snapshot, bmi); reflect.DeepEqual(feasible, test.feasible) == false {
+				t.Errorf("expected feasible %v got %v", test.feasible, feasible)
+			}
+		})
+	}
+}
+
+func TestHardwareAffinityScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		preferred []baremetalv1alpha1.PreferredSchedulingTerm
+		scores    []int64
+		err       bool
+	}{
+		{
+			name:   "no affinity",
+			scores: []int64{0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "single term",
+			preferred: []baremetalv1alpha1.PreferredSchedulingTerm{
+				{Weight: 10, Preference: hardwareTerm(testTopologyKey, baremetalv1alpha1.HardwareSelectorOpIn, "zone-2")},
+			},
+			scores: []int64{0, 0, 100, 100, 0, 0},
+		},
+		{
+			name: "weights of matching terms are added up",
+			preferred: []baremetalv1alpha1.PreferredSchedulingTerm{
+				{Weight: 30, Preference: hardwareTerm(testTopologyKey, baremetalv1alpha1.HardwareSelectorOpIn, "zone-2")},
+				{Weight: 10, Preference: hardwareTerm("cpu-generation", baremetalv1alpha1.HardwareSelectorOpGt, "3")},
+			},
+			scores: []int64{0, 0, 75, 100, 25, 0},
+		},
+		{
+			name: "invalid term",
+			preferred: []baremetalv1alpha1.PreferredSchedulingTerm{
+				{Weight: 10, Preference: hardwareTerm(testTopologyKey, "Unknown", "zone-2")},
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bmi := labeledInstance("new", "web", "")
+			if test.preferred != nil {
+				bmi.Spec.Affinity = &baremetalv1alpha1.Affinity{HardwareAffinity: &baremetalv1alpha1.HardwareAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: test.preferred,
+				}}
+			}
+
+			hardware := ratedHardware()
+			scores, err := (&HardwareAffinity{}).Score(&Snapshot{Hardware: hardware}, bmi, hardware)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error scoring: %v", err)
+			}
+			if reflect.DeepEqual(scores, test.scores) == false {
+				t.Errorf("expected scores %v got %v", test.scores, scores)
+			}
+		})
+	}
+}
@@ -0,0 +1,547 @@
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/pkg/quota"
+)
+
+const (
+	// DefaultAssumeTTL is how long an assumed instance is kept after it has been bound
+	// if the informer doesn't see the assignment by then the assumption is dropped
+	DefaultAssumeTTL = 30 * time.Second
+)
+
+var (
+	// ErrHardwareAssigned is returned when assuming an instance onto hardware that has an instance
+	ErrHardwareAssigned = errors.New("hardware is already assigned to an instance")
+
+	// ErrInstanceAssumed is returned when assuming an instance that is already assumed onto hardware
+	ErrInstanceAssumed = errors.New("instance is already assumed onto hardware")
+
+	// ErrQuotaExceeded is returned when assuming an instance onto hardware would exceed a quota of its namespace
+	ErrQuotaExceeded = errors.New("hardware would exceed a quota of the namespace")
+)
+
+type namespaceCache struct {
+	hardware  map[string]*baremetalv1alpha1.BareMetalHardware
+	instances map[string]*baremetalv1alpha1.BareMetalInstance
+	quotas    map[string]*baremetalv1alpha1.BareMetalQuota
+}
+
+type clusterHardware struct {
+	cbmh *baremetalv1alpha1.ClusterBareMetalHardware
+	bmh  *baremetalv1alpha1.BareMetalHardware
+}
+
+type assumedInstance struct {
+	hardware types.NamespacedName
+
+	// set when the instance is waiting for the rest of its gang
+	gang *types.NamespacedName
+
+	// set once the instance has been bound, the assumption expires after it
+	deadline *time.Time
+}
+
+// Cache holds the hardware and instances seen by the informers along with which hardware each instance is assigned to
+//
+// Scheduling cycles take a snapshot from the cache instead of listing, then assume the instance onto the picked
+// hardware before writing the assignment to the api server. The assumption reserves the hardware so concurrent
+// cycles can't pick it as well, it is removed once the informer sees the assignment or dropped when binding fails.
+type Cache struct {
+	lock  sync.RWMutex
+	clock clock.Clock
+	ttl   time.Duration
+
+	namespaces map[string]*namespaceCache
+
+	// cluster hardware and the namespaces that can use it, the hardware is kept as a BareMetalHardware without a namespace
+	clusterHardware map[string]*clusterHardware
+
+	// hardware to the instance assigned to it as seen by the informer
+	assigned map[types.NamespacedName]types.NamespacedName
+
+	// instances that have been assumed but not seen by the informer yet
+	assumed map[types.NamespacedName]*assumedInstance
+
+	// hardware to the instance assumed onto it
+	assumedHardware map[types.NamespacedName]types.NamespacedName
+
+	// gangs with members that are waiting for the rest of the gang
+	gangs map[types.NamespacedName]*gangState
+}
+
+func NewCache(clock clock.Clock, ttl time.Duration) *Cache {
+	return &Cache{
+		clock:           clock,
+		ttl:             ttl,
+		namespaces:      make(map[string]*namespaceCache),
+		clusterHardware: make(map[string]*clusterHardware),
+		assigned:        make(map[types.NamespacedName]types.NamespacedName),
+		assumed:         make(map[types.NamespacedName]*assumedInstance),
+		assumedHardware: make(map[types.NamespacedName]types.NamespacedName),
+		gangs:           make(map[types.NamespacedName]*gangState),
+	}
+}
+
+func instanceKey(bmi *baremetalv1alpha1.BareMetalInstance) types.NamespacedName {
+	return types.NamespacedName{Namespace: bmi.Namespace, Name: bmi.Name}
+}
+
+// cluster hardware doesn't have a namespace so it is the same for instances in every namespace
+func hardwareKey(bmh *baremetalv1alpha1.BareMetalHardware) types.NamespacedName {
+	return types.NamespacedName{Namespace: bmh.Namespace, Name: bmh.Name}
+}
+
+func assignedHardwareKey(bmi *baremetalv1alpha1.BareMetalInstance) types.NamespacedName {
+	if bmi.Status.ClusterHardware {
+		return types.NamespacedName{Name: bmi.Status.HardwareName}
+	}
+
+	return types.NamespacedName{Namespace: bmi.Namespace, Name: bmi.Status.HardwareName}
+}
+
+func (c *Cache) namespace(namespace string) *namespaceCache {
+	ns, ok := c.namespaces[namespace]
+	if ok == false {
+		ns = &namespaceCache{
+			hardware:  make(map[string]*baremetalv1alpha1.BareMetalHardware),
+			instances: make(map[string]*baremetalv1alpha1.BareMetalInstance),
+			quotas:    make(map[string]*baremetalv1alpha1.BareMetalQuota),
+		}
+		c.namespaces[namespace] = ns
+	}
+
+	return ns
+}
+
+// AddHardware adds or replaces the hardware in the cache
+func (c *Cache) AddHardware(bmh *baremetalv1alpha1.BareMetalHardware) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.namespace(bmh.Namespace).hardware[bmh.Name] = bmh
+}
+
+// RemoveHardware removes the hardware from the cache
+func (c *Cache) RemoveHardware(bmh *baremetalv1alpha1.BareMetalHardware) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.namespace(bmh.Namespace).hardware, bmh.Name)
+}
+
+// AddClusterHardware adds or replaces the cluster hardware in the cache
+func (c *Cache) AddClusterHardware(cbmh *baremetalv1alpha1.ClusterBareMetalHardware) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.clusterHardware[cbmh.Name] = &clusterHardware{
+		cbmh: cbmh,
+		bmh:  cbmh.AsBareMetalHardware(),
+	}
+}
+
+// RemoveClusterHardware removes the cluster hardware from the cache
+func (c *Cache) RemoveClusterHardware(cbmh *baremetalv1alpha1.ClusterBareMetalHardware) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.clusterHardware, cbmh.Name)
+}
+
+// helper method to get the hardware by its key, the lock must be held
+func (c *Cache) hardware(key types.NamespacedName) *baremetalv1alpha1.BareMetalHardware {
+	if len(key.Namespace) == 0 {
+		if cluster, ok := c.clusterHardware[key.Name]; ok {
+			return cluster.bmh
+		}
+		return nil
+	}
+
+	if ns, ok := c.namespaces[key.Namespace]; ok {
+		return ns.hardware[key.Name]
+	}
+	return nil
+}
+
+// helper method to get the hardware by its key as it is seen by the namespace, the lock must be held
+// cluster hardware is only returned when it allows the namespace and isn't shadowed by hardware in the namespace
+func (c *Cache) namespaceHardware(namespace string, key types.NamespacedName) *baremetalv1alpha1.BareMetalHardware {
+	if len(key.Namespace) > 0 {
+		if key.Namespace != namespace {
+			return nil
+		}
+		return c.hardware(key)
+	}
+
+	cluster, ok := c.clusterHardware[key.Name]
+	if ok == false || cluster.cbmh.AllowsNamespace(namespace) == false {
+		return nil
+	}
+	if ns, ok := c.namespaces[namespace]; ok {
+		if _, ok := ns.hardware[key.Name]; ok {
+			return nil
+		}
+	}
+	return cluster.bmh
+}
+
+// helper method to return the instance with the hardware it is assumed onto, the lock must be held
+// the instance is copied when it is changed since it is shared with the informer
+func (c *Cache) withAssumedHardware(bmi *baremetalv1alpha1.BareMetalInstance) *baremetalv1alpha1.BareMetalInstance {
+	assumed, ok := c.assumed[instanceKey(bmi)]
+	if ok == false || len(bmi.Status.HardwareName) > 0 {
+		return bmi
+	}
+
+	bmi = bmi.DeepCopy()
+	bmi.Status.HardwareName = assumed.hardware.Name
+	bmi.Status.ClusterHardware = len(assumed.hardware.Namespace) == 0
+
+	return bmi
+}
+
+// AddInstance adds or replaces the instance in the cache
+// an assumed instance is confirmed once its hardware name is seen
+func (c *Cache) AddInstance(bmi *baremetalv1alpha1.BareMetalInstance) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := instanceKey(bmi)
+	ns := c.namespace(bmi.Namespace)
+
+	if existing, ok := ns.instances[bmi.Name]; ok {
+		c.unassign(existing)
+	}
+	ns.instances[bmi.Name] = bmi
+
+	if len(bmi.Status.HardwareName) > 0 {
+		c.assigned[assignedHardwareKey(bmi)] = key
+		c.forget(key)
+	}
+}
+
+// RemoveInstance removes the instance and any assumption for it from the cache
+func (c *Cache) RemoveInstance(bmi *baremetalv1alpha1.BareMetalInstance) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ns := c.namespace(bmi.Namespace)
+	if existing, ok := ns.instances[bmi.Name]; ok {
+		c.unassign(existing)
+	}
+	delete(ns.instances, bmi.Name)
+
+	c.forget(instanceKey(bmi))
+}
+
+// AddQuota adds or replaces the quota in the cache
+func (c *Cache) AddQuota(bmq *baremetalv1alpha1.BareMetalQuota) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.namespace(bmq.Namespace).quotas[bmq.Name] = bmq
+}
+
+// RemoveQuota removes the quota from the cache
+func (c *Cache) RemoveQuota(bmq *baremetalv1alpha1.BareMetalQuota) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.namespace(bmq.Namespace).quotas, bmq.Name)
+}
+
+// helper method to remove the assignment of the instance, the lock must be held
+func (c *Cache) unassign(bmi *baremetalv1alpha1.BareMetalInstance) {
+	if len(bmi.Status.HardwareName) == 0 {
+		return
+	}
+
+	hardwareKey := assignedHardwareKey(bmi)
+	if c.assigned[hardwareKey] == instanceKey(bmi) {
+		delete(c.assigned, hardwareKey)
+	}
+}
+
+// helper method to remove the assumption of the instance, the lock must be held
+func (c *Cache) forget(key types.NamespacedName) {
+	assumed, ok := c.assumed[key]
+	if ok == false {
+		return
+	}
+
+	if c.assumedHardware[assumed.hardware] == key {
+		delete(c.assumedHardware, assumed.hardware)
+	}
+	c.leaveGang(key, assumed)
+	delete(c.assumed, key)
+}
+
+// helper method to drop assumptions whose binding finished more then the ttl ago, the lock must be held
+func (c *Cache) cleanupExpired() {
+	now := c.clock.Now()
+	for key, assumed := range c.assumed {
+		if assumed.deadline != nil && now.After(*assumed.deadline) {
+			c.forget(key)
+		}
+	}
+}
+
+// Assume reserves the hardware for the instance until it is bound
+// an error is returned if the hardware is already assigned or assumed by another instance
+func (c *Cache) Assume(bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.assume(bmi, bmh)
+}
+
+// helper method to assume the instance onto the hardware, the lock must be held
+func (c *Cache) assume(bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) error {
+	c.cleanupExpired()
+
+	key := instanceKey(bmi)
+	hardwareKey := hardwareKey(bmh)
+
+	if _, ok := c.assumed[key]; ok {
+		return ErrInstanceAssumed
+	}
+
+	if owner, ok := c.assigned[hardwareKey]; ok && owner != key {
+		return ErrHardwareAssigned
+	}
+	if owner, ok := c.assumedHardware[hardwareKey]; ok && owner != key {
+		return ErrHardwareAssigned
+	}
+
+	// concurrent cycles filter against their own snapshot so the quotas are checked again with every assumption
+	if c.exceedsQuota(bmi.Namespace, hardwareKey) {
+		return ErrQuotaExceeded
+	}
+
+	c.assumed[key] = &assumedInstance{hardware: hardwareKey}
+	c.assumedHardware[hardwareKey] = key
+
+	return nil
+}
+
+// helper method to check if adding the hardware to the usage of the namespace exceeds any of its quotas, the lock must be held
+func (c *Cache) exceedsQuota(namespace string, hardwareKey types.NamespacedName) bool {
+	ns := c.namespace(namespace)
+	if len(ns.quotas) == 0 {
+		return false
+	}
+
+	bmh := c.namespaceHardware(namespace, hardwareKey)
+	if bmh == nil {
+		return false
+	}
+
+	instances := make([]*baremetalv1alpha1.BareMetalInstance, 0, len(ns.instances))
+	for _, bmi := range ns.instances {
+		instances = append(instances, c.withAssumedHardware(bmi))
+	}
+
+	// the hardware is looked up the same way as in the snapshot so the usage matches the one the quota plugin sees
+	usage := quota.Calculate(instances, func(bmi *baremetalv1alpha1.BareMetalInstance) *baremetalv1alpha1.BareMetalHardware {
+		return c.namespaceHardware(namespace, assignedHardwareKey(bmi))
+	}).AddHardware(bmh)
+
+	for _, bmq := range ns.quotas {
+		if len(quota.ExceededHardware(bmq.Spec.Hard, usage)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FinishBinding starts the expiration of the assumption once the assignment has been written
+func (c *Cache) FinishBinding(bmi *baremetalv1alpha1.BareMetalInstance) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	assumed, ok := c.assumed[instanceKey(bmi)]
+	if ok == false {
+		return
+	}
+
+	c.leaveGang(instanceKey(bmi), assumed)
+
+	deadline := c.clock.Now().Add(c.ttl)
+	assumed.deadline = &deadline
+}
+
+// Forget removes the assumption of the instance, used when binding fails
+func (c *Cache) Forget(bmi *baremetalv1alpha1.BareMetalInstance) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.forget(instanceKey(bmi))
+}
+
+// IsAssumed returns if the instance has been assumed onto hardware and not confirmed yet
+func (c *Cache) IsAssumed(bmi *baremetalv1alpha1.BareMetalInstance) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.cleanupExpired()
+
+	_, ok := c.assumed[instanceKey(bmi)]
+	return ok
+}
+
+// Snapshot returns the hardware, instances and quotas in the namespace along with the cluster hardware the namespace can use
+// cluster hardware with the same name as hardware in the namespace is left out so hardware names stay unique
+// assumed instances are returned with their hardware name set
+// the objects are shared with the cache so they must not be modified
+func (c *Cache) Snapshot(namespace string) *Snapshot {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	snapshot := &Snapshot{}
+
+	ns, ok := c.namespaces[namespace]
+	if ok == false {
+		ns = &namespaceCache{}
+	}
+
+	snapshot.Hardware = make([]*baremetalv1alpha1.BareMetalHardware, 0, len(ns.hardware)+len(c.clusterHardware))
+	for _, bmh := range ns.hardware {
+		snapshot.Hardware = append(snapshot.Hardware, bmh)
+	}
+	for _, cluster := range c.clusterHardware {
+		if c.namespaceHardware(namespace, hardwareKey(cluster.bmh)) == nil {
+			continue
+		}
+		snapshot.Hardware = append(snapshot.Hardware, cluster.bmh)
+	}
+
+	// cluster hardware can be assigned to or reserved by instances in other namespaces
+	// so the assignments are taken from the whole cache instead of the instances in the namespace
+	snapshot.assignedHardware = make(map[types.NamespacedName]bool)
+	for _, hardware := range []map[types.NamespacedName]types.NamespacedName{c.assigned, c.assumedHardware} {
+		for key := range hardware {
+			if key.Namespace == namespace || len(key.Namespace) == 0 {
+				snapshot.assignedHardware[key] = true
+			}
+		}
+	}
+
+	snapshot.Instances = make([]*baremetalv1alpha1.BareMetalInstance, 0, len(ns.instances))
+	for _, bmi := range ns.instances {
+		snapshot.Instances = append(snapshot.Instances, c.withAssumedHardware(bmi))
+	}
+
+	snapshot.Quotas = make([]*baremetalv1alpha1.BareMetalQuota, 0, len(ns.quotas))
+	for _, bmq := range ns.quotas {
+		snapshot.Quotas = append(snapshot.Quotas, bmq)
+	}
+
+	return snapshot
+}
+
+// HardwareEventHandler returns an informer event handler that keeps the hardware in the cache up to date
+func (c *Cache) HardwareEventHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if bmh, ok := obj.(*baremetalv1alpha1.BareMetalHardware); ok {
+				c.AddHardware(bmh)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if bmh, ok := newObj.(*baremetalv1alpha1.BareMetalHardware); ok {
+				c.AddHardware(bmh)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if bmh, ok := obj.(*baremetalv1alpha1.BareMetalHardware); ok {
+				c.RemoveHardware(bmh)
+			}
+		},
+	}
+}
+
+// ClusterHardwareEventHandler returns an informer event handler that keeps the cluster hardware in the cache up to date
+func (c *Cache) ClusterHardwareEventHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if cbmh, ok := obj.(*baremetalv1alpha1.ClusterBareMetalHardware); ok {
+				c.AddClusterHardware(cbmh)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if cbmh, ok := newObj.(*baremetalv1alpha1.ClusterBareMetalHardware); ok {
+				c.AddClusterHardware(cbmh)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if cbmh, ok := obj.(*baremetalv1alpha1.ClusterBareMetalHardware); ok {
+				c.RemoveClusterHardware(cbmh)
+			}
+		},
+	}
+}
+
+// InstanceEventHandler returns an informer event handler that keeps the instances in the cache up to date
+func (c *Cache) InstanceEventHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if bmi, ok := obj.(*baremetalv1alpha1.BareMetalInstance); ok {
+				c.AddInstance(bmi)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if bmi, ok := newObj.(*baremetalv1alpha1.BareMetalInstance); ok {
+				c.AddInstance(bmi)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if bmi, ok := obj.(*baremetalv1alpha1.BareMetalInstance); ok {
+				c.RemoveInstance(bmi)
+			}
+		},
+	}
+}
+
+// QuotaEventHandler returns an informer event handler that keeps the quotas in the cache up to date
+func (c *Cache) QuotaEventHandler() toolscache.ResourceEventHandler {
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if bmq, ok := obj.(*baremetalv1alpha1.BareMetalQuota); ok {
+				c.AddQuota(bmq)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if bmq, ok := newObj.(*baremetalv1alpha1.BareMetalQuota); ok {
+				c.AddQuota(bmq)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if bmq, ok := obj.(*baremetalv1alpha1.BareMetalQuota); ok {
+				c.RemoveQuota(bmq)
+			}
+		},
+	}
+}
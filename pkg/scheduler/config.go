@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"sigs.k8s.io/yaml"
+)
+
+type FilterPluginFactory func(args json.RawMessage) (FilterPlugin, error)
+type ScorePluginFactory func(args json.RawMessage) (ScorePlugin, error)
+
+// the plugins that can be enabled in the config by name
+var (
+	filterPlugins = map[string]FilterPluginFactory{
+		HardwareSelectorName: NewHardwareSelector,
+		CanProvisionName:     NewCanProvision,
+		TaintTolerationName:  NewTaintToleration,
+		ResourcesName:        NewResources,
+		InstanceAffinityName: NewInstanceAffinityFilter,
+		TopologySpreadName:   NewTopologySpreadFilter,
+		QuotaName:            NewQuota,
+	}
+
+	scorePlugins = map[string]ScorePluginFactory{
+		LeastWasteName:  NewLeastWaste,
+		PreferLabelName: NewPreferLabel,
+		SpreadName:      NewSpread,
+
+		HardwareAffinityName: NewHardwareAffinity,
+		InstanceAffinityName: NewInstanceAffinityScore,
+		TopologySpreadName:   NewTopologySpreadScore,
+	}
+)
+
+type PluginConfig struct {
+	// The name of the plugin
+	Name string `json:"name"`
+
+	// How much the score of the plugin counts towards the total score, defaults to 1
+	// only used by score plugins
+	Weight int64 `json:"weight,omitempty"`
+
+	// Plugin specific arguments
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// Config is the set of plugins the scheduler runs
+type Config struct {
+	// The filter plugins to run in order, when empty the default filters are used
+	Filters []PluginConfig `json:"filters,omitempty"`
+
+	// The score plugins to run
+	Scores []PluginConfig `json:"scores,omitempty"`
+
+	// The http extenders to call after the plugins, in order
+	Extenders []ExtenderConfig `json:"extenders,omitempty"`
+}
+
+// DefaultConfig returns the config used when no config file is given
+func DefaultConfig() *Config {
+	return &Config{
+		Filters: defaultFilters(),
+		Scores: []PluginConfig{
+			{Name: LeastWasteName, Weight: 1},
+			{Name: SpreadName, Weight: 1},
+			{Name: HardwareAffinityName, Weight: 1},
+			{Name: InstanceAffinityName, Weight: 1},
+			{Name: TopologySpreadName, Weight: 2},
+		},
+	}
+}
+
+func defaultFilters() []PluginConfig {
+	return []PluginConfig{
+		{Name: HardwareSelectorName},
+		{Name: CanProvisionName},
+		{Name: TaintTolerationName},
+		{Name: ResourcesName},
+		{Name: InstanceAffinityName},
+		{Name: TopologySpreadName},
+		{Name: QuotaName},
+	}
+}
+
+// LoadConfig reads a yaml or json config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.Filters) == 0 {
+		config.Filters = defaultFilters()
+	}
+
+	return config, nil
+}
@@ -0,0 +1,298 @@
+package scheduler
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+const (
+	// ExtenderMaxScore is the highest score an extender can give hardware
+	// it is scaled up to MaxScore before being weighted
+	ExtenderMaxScore int64 = 10
+
+	// DefaultExtenderTimeout is how long to wait for an extender when the config does not set a timeout
+	DefaultExtenderTimeout = 30 * time.Second
+)
+
+// ExtenderConfig configures an http extender that is called after the filter and score plugins
+type ExtenderConfig struct {
+	// The url the verbs are appended to
+	URLPrefix string `json:"urlPrefix"`
+
+	// The verb to call to filter hardware, filtering is skipped when empty
+	FilterVerb string `json:"filterVerb,omitempty"`
+
+	// The verb to call to score hardware, scoring is skipped when empty
+	PrioritizeVerb string `json:"prioritizeVerb,omitempty"`
+
+	// How much the score of the extender counts towards the total score, defaults to 1
+	Weight int64 `json:"weight,omitempty"`
+
+	// How long to wait for the extender, defaults to 30s
+	HTTPTimeout metav1.Duration `json:"httpTimeout,omitempty"`
+
+	// When true only the names of the hardware are sent and returned instead of the full objects
+	HardwareNamesOnly bool `json:"hardwareNamesOnly,omitempty"`
+
+	// When true scheduling continues without the extender when it can't be reached or returns an error
+	Ignorable bool `json:"ignorable,omitempty"`
+
+	// The pem encoded ca to verify the extender with, the system cas are used when empty
+	CAFile string `json:"caFile,omitempty"`
+
+	// Don't verify the certificate of the extender
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// ExtenderArgs is sent to the filter and prioritize verbs
+type ExtenderArgs struct {
+	// The instance being scheduled
+	Instance *baremetalv1alpha1.BareMetalInstance `json:"instance"`
+
+	// The candidate hardware, set when hardwareNamesOnly is false
+	Hardware *baremetalv1alpha1.BareMetalHardwareList `json:"hardware,omitempty"`
+
+	// The names of the candidate hardware, set when hardwareNamesOnly is true
+	HardwareNames *[]string `json:"hardwareNames,omitempty"`
+}
+
+// ExtenderFilterResult is returned by the filter verb
+type ExtenderFilterResult struct {
+	// The hardware that passed the filter, used when hardwareNamesOnly is false
+	Hardware *baremetalv1alpha1.BareMetalHardwareList `json:"hardware,omitempty"`
+
+	// The names of the hardware that passed the filter, used when hardwareNamesOnly is true
+	HardwareNames *[]string `json:"hardwareNames,omitempty"`
+
+	// The names of the hardware that didn't pass the filter and why
+	FailedHardware map[string]string `json:"failedHardware,omitempty"`
+
+	// Set when the extender failed, the whole result is ignored
+	Error string `json:"error,omitempty"`
+}
+
+// ExtenderHardwareScore is the score the prioritize verb gives hardware
+type ExtenderHardwareScore struct {
+	Hardware string `json:"hardware"`
+	Score    int64  `json:"score"`
+}
+
+// ExtenderHardwareScoreList is returned by the prioritize verb
+// hardware that is missing from the list gets a score of 0
+type ExtenderHardwareScoreList []ExtenderHardwareScore
+
+// Extender is an http service that filters and scores hardware next to the plugins
+// it lets hardware be picked on data outside of the cluster without changing the scheduler
+type Extender struct {
+	config     ExtenderConfig
+	weight     int64
+	httpClient *http.Client
+}
+
+func NewExtender(config ExtenderConfig) (*Extender, error) {
+	if len(config.URLPrefix) == 0 {
+		return nil, fmt.Errorf("extender urlPrefix is required")
+	}
+
+	if len(config.FilterVerb) == 0 && len(config.PrioritizeVerb) == 0 {
+		return nil, fmt.Errorf("extender %s must have a filterVerb or prioritizeVerb", config.URLPrefix)
+	}
+
+	if config.Weight < 0 {
+		return nil, fmt.Errorf("extender %s has a negative weight", config.URLPrefix)
+	}
+
+	weight := config.Weight
+	if weight == 0 {
+		weight = 1
+	}
+
+	timeout := config.HTTPTimeout.Duration
+	if timeout == 0 {
+		timeout = DefaultExtenderTimeout
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+	if len(config.CAFile) > 0 {
+		caData, err := ioutil.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the ca of extender %s: %v", config.URLPrefix, err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if tlsConfig.RootCAs.AppendCertsFromPEM(caData) == false {
+			return nil, fmt.Errorf("the ca of extender %s doesn't contain any pem certificates", config.URLPrefix)
+		}
+	}
+
+	return &Extender{
+		config: config,
+		weight: weight,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+	}, nil
+}
+
+// Name returns how the extender is shown in scheduling diagnostics
+func (e *Extender) Name() string {
+	return "Extender(" + e.config.URLPrefix + ")"
+}
+
+// IsIgnorable returns if scheduling should continue when the extender fails
+func (e *Extender) IsIgnorable() bool {
+	return e.config.Ignorable
+}
+
+// Filter returns the hardware that passed the filter verb and why the rest of the hardware didn't
+func (e *Extender) Filter(bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]*baremetalv1alpha1.BareMetalHardware, map[string]string, error) {
+	if len(e.config.FilterVerb) == 0 || len(hardware) == 0 {
+		return hardware, nil, nil
+	}
+
+	result := &ExtenderFilterResult{}
+	err := e.send(e.config.FilterVerb, e.args(bmi, hardware), result)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(result.Error) > 0 {
+		return nil, nil, fmt.Errorf("extender returned an error: %s", result.Error)
+	}
+
+	var passedNames []string
+	if e.config.HardwareNamesOnly {
+		if result.HardwareNames != nil {
+			passedNames = *result.HardwareNames
+		}
+	} else if result.Hardware != nil {
+		for _, bmh := range result.Hardware.Items {
+			passedNames = append(passedNames, bmh.Name)
+		}
+	}
+
+	passed := make(map[string]bool, len(passedNames))
+	for _, name := range passedNames {
+		passed[name] = true
+	}
+
+	// only keep the candidates so the extender can't add hardware
+	failed := make(map[string]string)
+	feasible := make([]*baremetalv1alpha1.BareMetalHardware, 0, len(passedNames))
+	for _, bmh := range hardware {
+		if passed[bmh.Name] {
+			feasible = append(feasible, bmh)
+			continue
+		}
+
+		reason := result.FailedHardware[bmh.Name]
+		if len(reason) == 0 {
+			reason = "were rejected by the extender"
+		}
+		failed[bmh.Name] = reason
+	}
+
+	return feasible, failed, nil
+}
+
+// Prioritize returns the weighted scores of the hardware from the prioritize verb, in the same order
+func (e *Extender) Prioritize(bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error) {
+	scores := make([]int64, len(hardware))
+	if len(e.config.PrioritizeVerb) == 0 || len(hardware) == 0 {
+		return scores, nil
+	}
+
+	result := ExtenderHardwareScoreList{}
+	err := e.send(e.config.PrioritizeVerb, e.args(bmi, hardware), &result)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]int64, len(result))
+	for _, hardwareScore := range result {
+		byName[hardwareScore.Hardware] = hardwareScore.Score
+	}
+
+	for i, bmh := range hardware {
+		score := byName[bmh.Name]
+		if score < 0 {
+			score = 0
+		}
+		if score > ExtenderMaxScore {
+			score = ExtenderMaxScore
+		}
+		scores[i] = score * (MaxScore / ExtenderMaxScore) * e.weight
+	}
+
+	return scores, nil
+}
+
+// helper to build the request for the verbs
+func (e *Extender) args(bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) *ExtenderArgs {
+	args := &ExtenderArgs{
+		Instance: bmi,
+	}
+
+	if e.config.HardwareNamesOnly {
+		names := make([]string, 0, len(hardware))
+		for _, bmh := range hardware {
+			names = append(names, bmh.Name)
+		}
+		args.HardwareNames = &names
+	} else {
+		list := &baremetalv1alpha1.BareMetalHardwareList{}
+		for _, bmh := range hardware {
+			list.Items = append(list.Items, *bmh)
+		}
+		args.Hardware = list
+	}
+
+	return args
+}
+
+func (e *Extender) send(verb string, args *ExtenderArgs, result interface{}) error {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimRight(e.config.URLPrefix, "/") + "/" + verb
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("extender %s responded with %d: %s", url, resp.StatusCode, strings.TrimSpace(string(respData)))
+	}
+
+	return json.Unmarshal(respData, result)
+}
@@ -0,0 +1,334 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// fakeExtender is an extender that records the requests it gets and responds with the configured results
+type fakeExtender struct {
+	t *testing.T
+
+	// the names of the hardware that pass the filter and why the rest failed
+	passed map[string]bool
+	failed map[string]string
+	error  string
+
+	scores ExtenderHardwareScoreList
+
+	status   int
+	requests map[string]*ExtenderArgs
+}
+
+func (e *fakeExtender) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+		e.t.Errorf("unexpected %s request with content type %s", r.Method, r.Header.Get("Content-Type"))
+	}
+
+	args := &ExtenderArgs{}
+	if err := json.NewDecoder(r.Body).Decode(args); err != nil {
+		e.t.Errorf("error decoding args: %v", err)
+	}
+	e.requests[r.URL.Path] = args
+
+	if e.status != 0 {
+		http.Error(w, "extender failed", e.status)
+		return
+	}
+
+	var result interface{}
+	switch r.URL.Path {
+	case "/scheduler/filter":
+		filterResult := &ExtenderFilterResult{FailedHardware: e.failed, Error: e.error}
+		if args.HardwareNames != nil {
+			names := []string{"not-a-candidate"}
+			for _, name := range *args.HardwareNames {
+				if e.passed[name] {
+					names = append(names, name)
+				}
+			}
+			filterResult.HardwareNames = &names
+		}
+		if args.Hardware != nil {
+			filterResult.Hardware = &baremetalv1alpha1.BareMetalHardwareList{}
+			for _, bmh := range args.Hardware.Items {
+				if e.passed[bmh.Name] {
+					filterResult.Hardware.Items = append(filterResult.Hardware.Items, bmh)
+				}
+			}
+		}
+		result = filterResult
+	case "/scheduler/prioritize":
+		result = e.scores
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		e.t.Errorf("error encoding result: %v", err)
+	}
+}
+
+func newFakeExtender(t *testing.T) (*fakeExtender, *httptest.Server) {
+	extender := &fakeExtender{t: t, requests: make(map[string]*ExtenderArgs)}
+	return extender, httptest.NewServer(extender)
+}
+
+func TestExtenderFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		namesOnly bool
+		passed    map[string]bool
+		failed    map[string]string
+		error     string
+		status    int
+		feasible  []string
+		rejected  map[string]string
+		err       bool
+	}{
+		{
+			name:      "names only",
+			namesOnly: true,
+			passed:    map[string]bool{"a": true, "c": true},
+			failed:    map[string]string{"b": "were in maintenance"},
+			feasible:  []string{"a", "c"},
+			rejected:  map[string]string{"b": "were in maintenance"},
+		},
+		{
+			name:     "full hardware",
+			passed:   map[string]bool{"b": true},
+			feasible: []string{"b"},
+			rejected: map[string]string{"a": "were rejected by the extender", "c": "were rejected by the extender"},
+		},
+		{
+			name:     "nothing passed",
+			failed:   map[string]string{"a": "were too far away"},
+			feasible: []string{},
+			rejected: map[string]string{"a": "were too far away", "b": "were rejected by the extender", "c": "were rejected by the extender"},
+		},
+		{
+			name:  "error in result",
+			error: "database unavailable",
+			err:   true,
+		},
+		{
+			name:   "error status",
+			status: http.StatusInternalServerError,
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, server := newFakeExtender(t)
+			defer server.Close()
+			fake.passed = test.passed
+			fake.failed = test.failed
+			fake.error = test.error
+			fake.status = test.status
+
+			extender, err := NewExtender(ExtenderConfig{
+				URLPrefix:         server.URL + "/scheduler/",
+				FilterVerb:        "filter",
+				HardwareNamesOnly: test.namesOnly,
+			})
+			if err != nil {
+				t.Fatalf("error creating extender: %v", err)
+			}
+
+			bmi := fakeInstance(0)
+			feasible, rejected, err := extender.Filter(bmi, namedHardware("a", "b", "c"))
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error filtering: %v", err)
+			}
+
+			if names := hardwareNames(feasible); reflect.DeepEqual(names, test.feasible) == false {
+				t.Errorf("expected feasible %v got %v", test.feasible, names)
+			}
+			if reflect.DeepEqual(rejected, test.rejected) == false {
+				t.Errorf("expected rejected %v got %v", test.rejected, rejected)
+			}
+
+			args := fake.requests["/scheduler/filter"]
+			if args == nil || args.Instance == nil || args.Instance.Name != bmi.Name {
+				t.Fatalf("expected the instance to be sent got %v", args)
+			}
+			if test.namesOnly {
+				if args.Hardware != nil || args.HardwareNames == nil || reflect.DeepEqual(*args.HardwareNames, []string{"a", "b", "c"}) == false {
+					t.Errorf("expected only the hardware names to be sent got %v %v", args.Hardware, args.HardwareNames)
+				}
+			} else if args.HardwareNames != nil || args.Hardware == nil || len(args.Hardware.Items) != 3 {
+				t.Errorf("expected the hardware to be sent got %v %v", args.Hardware, args.HardwareNames)
+			}
+		})
+	}
+}
+
+func TestExtenderPrioritize(t *testing.T) {
+	tests := []struct {
+		name     string
+		weight   int64
+		scores   ExtenderHardwareScoreList
+		status   int
+		expected []int64
+		err      bool
+	}{
+		{
+			name:     "scaled to max score",
+			scores:   ExtenderHardwareScoreList{{Hardware: "a", Score: 10}, {Hardware: "b", Score: 3}},
+			expected: []int64{100, 30, 0},
+		},
+		{
+			name:     "weighted",
+			weight:   2,
+			scores:   ExtenderHardwareScoreList{{Hardware: "c", Score: 5}},
+			expected: []int64{0, 0, 100},
+		},
+		{
+			name:     "clamped",
+			scores:   ExtenderHardwareScoreList{{Hardware: "a", Score: -5}, {Hardware: "b", Score: 50}, {Hardware: "unknown", Score: 10}},
+			expected: []int64{0, 100, 0},
+		},
+		{
+			name:   "error status",
+			status: http.StatusBadGateway,
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, server := newFakeExtender(t)
+			defer server.Close()
+			fake.scores = test.scores
+			fake.status = test.status
+
+			extender, err := NewExtender(ExtenderConfig{
+				URLPrefix:      server.URL + "/scheduler",
+				PrioritizeVerb: "prioritize",
+				Weight:         test.weight,
+			})
+			if err != nil {
+				t.Fatalf("error creating extender: %v", err)
+			}
+
+			scores, err := extender.Prioritize(fakeInstance(0), namedHardware("a", "b", "c"))
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error prioritizing: %v", err)
+			}
+
+			if reflect.DeepEqual(scores, test.expected) == false {
+				t.Errorf("expected scores %v got %v", test.expected, scores)
+			}
+		})
+	}
+}
+
+func TestFrameworkExtenders(t *testing.T) {
+	tests := []struct {
+		name      string
+		ignorable bool
+		status    int
+		feasible  []string
+		reasons   []string
+		selected  string
+		err       bool
+	}{
+		{
+			name:     "filters and scores after the plugins",
+			feasible: []string{"b", "c"},
+			reasons:  []string{"1 hardware(s) were unschedulable", "1 hardware(s) were rejected by the extender"},
+			selected: "c",
+		},
+		{
+			name:      "ignorable failure",
+			ignorable: true,
+			status:    http.StatusServiceUnavailable,
+			feasible:  []string{"b", "c", "d"},
+			reasons:   []string{"1 hardware(s) were unschedulable"},
+			selected:  "d",
+		},
+		{
+			name:   "failure",
+			status: http.StatusServiceUnavailable,
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, server := newFakeExtender(t)
+			defer server.Close()
+			fake.passed = map[string]bool{"b": true, "c": true}
+			fake.scores = ExtenderHardwareScoreList{{Hardware: "c", Score: 10}}
+			fake.status = test.status
+
+			framework, err := NewFramework(&Config{
+				Filters: []PluginConfig{{Name: CanProvisionName}},
+				Extenders: []ExtenderConfig{{
+					URLPrefix:      server.URL + "/scheduler",
+					FilterVerb:     "filter",
+					PrioritizeVerb: "prioritize",
+					Ignorable:      test.ignorable,
+				}},
+			})
+			if err != nil {
+				t.Fatalf("error creating framework: %v", err)
+			}
+			framework.scores = []weightedScorePlugin{{ScorePlugin: &fakeScore{name: "Fake", scores: map[string]int64{"d": 60}}, weight: 1}}
+
+			hardware := namedHardware("a", "b", "c", "d")
+			for _, bmh := range hardware[1:] {
+				bmh.Spec.CanProvision = true
+			}
+
+			snapshot := &Snapshot{}
+			bmi := fakeInstance(0)
+			feasible, diagnosis, err := framework.Filter(snapshot, bmi, hardware)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error filtering: %v", err)
+			}
+
+			if names := hardwareNames(feasible); reflect.DeepEqual(names, test.feasible) == false {
+				t.Errorf("expected feasible %v got %v", test.feasible, names)
+			}
+			if reasons := diagnosis.Reasons(); reflect.DeepEqual(reasons, test.reasons) == false {
+				t.Errorf("expected reasons %v got %v", test.reasons, reasons)
+			}
+
+			// the plugin gave d 60 and the extender gave c 100
+			selected, err := framework.Select(snapshot, bmi, feasible)
+			if err != nil {
+				t.Fatalf("error selecting: %v", err)
+			}
+			if selected.Name != test.selected {
+				t.Errorf("expected %s to be selected got %s", test.selected, selected.Name)
+			}
+		})
+	}
+}
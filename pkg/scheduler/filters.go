@@ -0,0 +1,224 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+const (
+	HardwareSelectorName = "HardwareSelector"
+	CanProvisionName     = "CanProvision"
+	TaintTolerationName  = "TaintToleration"
+	ResourcesName        = "Resources"
+)
+
+// helper to decode plugin arguments, unknown arguments are an error so typos are noticed
+func decodeArgs(args json.RawMessage, into interface{}) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(args))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(into)
+}
+
+// HardwareSelector filters out hardware that doesn't match the hardware selector
+// or the required hardware affinity of the instance
+type HardwareSelector struct{}
+
+func NewHardwareSelector(args json.RawMessage) (FilterPlugin, error) {
+	return &HardwareSelector{}, nil
+}
+
+func (p *HardwareSelector) Name() string {
+	return HardwareSelectorName
+}
+
+func (p *HardwareSelector) Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) []string {
+	if len(bmi.Spec.Selector) > 0 && labels.SelectorFromSet(bmi.Spec.Selector).Matches(labels.Set(bmh.Labels)) == false {
+		return []string{"didn't match hardware selector"}
+	}
+
+	if matchesRequiredHardwareAffinity(bmi, bmh) == false {
+		return []string{"didn't match hardware affinity"}
+	}
+
+	return nil
+}
+
+// helper to check if the hardware matches any of the required hardware affinity terms of the instance
+func matchesRequiredHardwareAffinity(bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) bool {
+	if bmi.Spec.Affinity == nil || bmi.Spec.Affinity.HardwareAffinity == nil || bmi.Spec.Affinity.HardwareAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+
+	for _, term := range bmi.Spec.Affinity.HardwareAffinity.RequiredDuringSchedulingIgnoredDuringExecution.HardwareSelectorTerms {
+		selector, err := term.Selector()
+		if err != nil {
+			// terms are validated by the webhook so an invalid term should never happen
+			continue
+		}
+
+		if selector.Matches(labels.Set(bmh.Labels)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanProvision filters out hardware that can't be provisioned
+type CanProvision struct{}
+
+func NewCanProvision(args json.RawMessage) (FilterPlugin, error) {
+	return &CanProvision{}, nil
+}
+
+func (p *CanProvision) Name() string {
+	return CanProvisionName
+}
+
+func (p *CanProvision) Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) []string {
+	if bmh.Spec.CanProvision == false {
+		return []string{"were unschedulable"}
+	}
+
+	return nil
+}
+
+// TaintToleration filters out hardware with NoSchedule or NoExecute taints that the instance doesn't tolerate
+type TaintToleration struct{}
+
+func NewTaintToleration(args json.RawMessage) (FilterPlugin, error) {
+	return &TaintToleration{}, nil
+}
+
+func (p *TaintToleration) Name() string {
+	return TaintTolerationName
+}
+
+func (p *TaintToleration) Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) []string {
+	for _, taint := range bmh.Spec.Taints {
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+
+		tolerates := false
+
+		for _, toleration := range bmi.Spec.Tolerations {
+			if toleration.ToleratesTaint(&taint) {
+				tolerates = true
+				break
+			}
+		}
+
+		if tolerates == false {
+			return []string{"had taints that the instance didn't tolerate"}
+		}
+	}
+
+	return nil
+}
+
+// Resources filters out hardware that doesn't have the resources the instance requests
+type Resources struct{}
+
+func NewResources(args json.RawMessage) (FilterPlugin, error) {
+	return &Resources{}, nil
+}
+
+func (p *Resources) Name() string {
+	return ResourcesName
+}
+
+func (p *Resources) Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) []string {
+	var reasons []string
+
+	resources := bmi.Spec.Resources
+	if resources == nil {
+		return reasons
+	}
+
+	hardware := bmh.Status.Hardware
+	if hardware == nil {
+		return append(reasons, "hadn't been discovered")
+	}
+
+	if resources.CPUS != nil && hardware.CPU.CPUS.Cmp(*resources.CPUS) < 0 {
+		reasons = append(reasons, "had insufficient cpus")
+	}
+
+	if resources.Ram != nil && hardware.Ram.Cmp(*resources.Ram) < 0 {
+		reasons = append(reasons, "had insufficient ram")
+	}
+
+	if resources.ImageDriveSize != nil || len(resources.ImageDriveType) > 0 {
+		imageDrive := imageDrive(bmh)
+
+		if imageDrive == nil {
+			reasons = append(reasons, "didn't have an image drive")
+		} else {
+			if resources.ImageDriveSize != nil && imageDrive.Size.Cmp(*resources.ImageDriveSize) < 0 {
+				reasons = append(reasons, "had an image drive that was too small")
+			}
+
+			switch resources.ImageDriveType {
+			case baremetalv1alpha1.ImageDriveTypeHDD:
+				if imageDrive.Rotational == false {
+					reasons = append(reasons, "had an image drive of the wrong type")
+				}
+			case baremetalv1alpha1.ImageDriveTypeSSD:
+				if imageDrive.Rotational {
+					reasons = append(reasons, "had an image drive of the wrong type")
+				}
+			}
+		}
+	}
+
+	if resources.NICCount > 0 || resources.NICSpeed != nil {
+		nicCount := 0
+		for _, nic := range hardware.NICS {
+			// vms may report a speed of -1 so they never match a speed requirement
+			if resources.NICSpeed != nil && nic.Speed.Cmp(*resources.NICSpeed) < 0 {
+				continue
+			}
+			nicCount++
+		}
+
+		wantCount := resources.NICCount
+		if wantCount == 0 {
+			wantCount = 1
+		}
+
+		if nicCount < wantCount {
+			if resources.NICSpeed != nil && len(hardware.NICS) >= wantCount {
+				reasons = append(reasons, "didn't have nics that were fast enough")
+			} else {
+				reasons = append(reasons, "had insufficient nics")
+			}
+		}
+	}
+
+	return reasons
+}
+
+// helper to find the discovered storage device of the image drive
+func imageDrive(bmh *baremetalv1alpha1.BareMetalHardware) *baremetalv1alpha1.BareMetalDiscoveryHardwareStorage {
+	if bmh.Status.Hardware == nil {
+		return nil
+	}
+
+	for i, storage := range bmh.Status.Hardware.Storage {
+		if storage.Name == bmh.Spec.ImageDrive {
+			return &bmh.Status.Hardware.Storage[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,389 @@
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/pkg/quota"
+)
+
+const (
+	// MaxScore is the highest score a score plugin can give hardware
+	MaxScore int64 = 100
+)
+
+// Snapshot is the state a scheduling cycle runs against
+// the indexes are built the first time they are needed so the hardware and instances must not change after that
+type Snapshot struct {
+	// All hardware in the namespace of the instance and the cluster hardware the namespace can use
+	// cluster hardware doesn't have a namespace
+	Hardware []*baremetalv1alpha1.BareMetalHardware
+
+	// All instances in the namespace of the instance
+	Instances []*baremetalv1alpha1.BareMetalInstance
+
+	// All quotas in the namespace of the instance
+	Quotas []*baremetalv1alpha1.BareMetalQuota
+
+	quotaUsage *quota.Usage
+
+	hardwareByKey    map[types.NamespacedName]*baremetalv1alpha1.BareMetalHardware
+	assignedHardware map[types.NamespacedName]bool
+
+	// the assigned instances and domain counts of the last instance they were looked up for
+	assignedFor  types.UID
+	assigned     []assignedInstance
+	antiAffinity []assignedInstance
+	domainCounts map[int]map[string]int64
+}
+
+// InstanceHardware returns the hardware the instance is assigned to or nil if it doesn't exist
+func (s *Snapshot) InstanceHardware(bmi *baremetalv1alpha1.BareMetalInstance) *baremetalv1alpha1.BareMetalHardware {
+	if s.hardwareByKey == nil {
+		s.hardwareByKey = make(map[types.NamespacedName]*baremetalv1alpha1.BareMetalHardware, len(s.Hardware))
+		for _, bmh := range s.Hardware {
+			s.hardwareByKey[hardwareKey(bmh)] = bmh
+		}
+	}
+
+	return s.hardwareByKey[assignedHardwareKey(bmi)]
+}
+
+// HardwareAssigned returns if any instance has the hardware as its hardware name or has it reserved
+// snapshots from the cache include the instances in other namespaces, otherwise only the instances in the snapshot are checked
+func (s *Snapshot) HardwareAssigned(bmh *baremetalv1alpha1.BareMetalHardware) bool {
+	if s.assignedHardware == nil {
+		s.assignedHardware = make(map[types.NamespacedName]bool)
+		for _, bmi := range s.Instances {
+			if len(bmi.Status.HardwareName) > 0 {
+				s.assignedHardware[assignedHardwareKey(bmi)] = true
+			}
+		}
+	}
+
+	return s.assignedHardware[hardwareKey(bmh)]
+}
+
+// QuotaUsage returns what the instances in the snapshot count against the quotas
+func (s *Snapshot) QuotaUsage() quota.Usage {
+	if s.quotaUsage == nil {
+		usage := quota.Calculate(s.Instances, s.InstanceHardware)
+		s.quotaUsage = &usage
+	}
+
+	return *s.quotaUsage
+}
+
+// FilterPlugin removes hardware that the instance can't be scheduled onto
+type FilterPlugin interface {
+	Name() string
+
+	// Filter returns the reasons the instance can't be scheduled onto the hardware
+	// an empty list means the hardware is acceptable
+	// reasons are shown as "{count} hardware(s) {reason}" so they should read that way
+	Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) []string
+}
+
+// ScorePlugin ranks the hardware that passed all the filters
+type ScorePlugin interface {
+	Name() string
+
+	// Score returns a score between 0 and MaxScore for each of the hardware, in the same order
+	Score(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error)
+}
+
+type weightedScorePlugin struct {
+	ScorePlugin
+	weight int64
+}
+
+// Framework runs the filter and score plugins to pick hardware for an instance
+type Framework struct {
+	filters   []FilterPlugin
+	scores    []weightedScorePlugin
+	extenders []*Extender
+}
+
+// NewFramework creates a framework with the plugins enabled in the config
+func NewFramework(config *Config) (*Framework, error) {
+	f := &Framework{}
+
+	for _, pluginConfig := range config.Filters {
+		factory, ok := filterPlugins[pluginConfig.Name]
+		if ok == false {
+			return nil, fmt.Errorf("unknown filter plugin %s", pluginConfig.Name)
+		}
+
+		plugin, err := factory(pluginConfig.Args)
+		if err != nil {
+			return nil, fmt.Errorf("error creating filter plugin %s: %v", pluginConfig.Name, err)
+		}
+		f.filters = append(f.filters, plugin)
+	}
+
+	// quotas are always enforced even when the config doesn't list the plugin
+	hasQuota := false
+	for _, plugin := range f.filters {
+		if plugin.Name() == QuotaName {
+			hasQuota = true
+			break
+		}
+	}
+	if hasQuota == false {
+		f.filters = append(f.filters, &Quota{})
+	}
+
+	for _, pluginConfig := range config.Scores {
+		factory, ok := scorePlugins[pluginConfig.Name]
+		if ok == false {
+			return nil, fmt.Errorf("unknown score plugin %s", pluginConfig.Name)
+		}
+
+		if pluginConfig.Weight < 0 {
+			return nil, fmt.Errorf("score plugin %s has a negative weight", pluginConfig.Name)
+		}
+
+		weight := pluginConfig.Weight
+		if weight == 0 {
+			weight = 1
+		}
+
+		plugin, err := factory(pluginConfig.Args)
+		if err != nil {
+			return nil, fmt.Errorf("error creating score plugin %s: %v", pluginConfig.Name, err)
+		}
+		f.scores = append(f.scores, weightedScorePlugin{ScorePlugin: plugin, weight: weight})
+	}
+
+	for _, extenderConfig := range config.Extenders {
+		extender, err := NewExtender(extenderConfig)
+		if err != nil {
+			return nil, err
+		}
+		f.extenders = append(f.extenders, extender)
+	}
+
+	return f, nil
+}
+
+// Diagnosis records why hardware was filtered out
+type Diagnosis struct {
+	plugins  []string
+	counts   map[filterReason]int
+	hardware map[string][]string
+}
+
+// FilterResult is how much hardware a filter plugin rejected for a reason
+type FilterResult struct {
+	Plugin string
+	Reason string
+	Count  int
+}
+
+// HardwareResult is why a filter plugin rejected the hardware
+type HardwareResult struct {
+	Name    string
+	Reasons []string
+}
+
+type filterReason struct {
+	plugin int
+	reason string
+}
+
+// helper to return the reasons sorted by plugin order
+func (d *Diagnosis) sortedReasons() []filterReason {
+	keys := make([]filterReason, 0, len(d.counts))
+	for key := range d.counts {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].plugin != keys[j].plugin {
+			return keys[i].plugin < keys[j].plugin
+		}
+		return keys[i].reason < keys[j].reason
+	})
+
+	return keys
+}
+
+// Reasons returns the reasons and how much hardware they filtered out, in plugin order
+func (d *Diagnosis) Reasons() []string {
+	keys := d.sortedReasons()
+
+	reasons := make([]string, 0, len(keys))
+	for _, key := range keys {
+		reasons = append(reasons, fmt.Sprintf("%v hardware(s) %s", d.counts[key], key.reason))
+	}
+
+	return reasons
+}
+
+// Filters returns the reasons, the plugin that gave them and how much hardware they filtered out, in plugin order
+func (d *Diagnosis) Filters() []FilterResult {
+	keys := d.sortedReasons()
+
+	results := make([]FilterResult, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, FilterResult{
+			Plugin: d.plugins[key.plugin],
+			Reason: key.reason,
+			Count:  d.counts[key],
+		})
+	}
+
+	return results
+}
+
+// Hardware returns why each of the filtered out hardware was rejected sorted by name
+// at most limit hardware is returned
+func (d *Diagnosis) Hardware(limit int) []HardwareResult {
+	names := make([]string, 0, len(d.hardware))
+	for name := range d.hardware {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > limit {
+		names = names[:limit]
+	}
+
+	results := make([]HardwareResult, 0, len(names))
+	for _, name := range names {
+		results = append(results, HardwareResult{
+			Name:    name,
+			Reasons: d.hardware[name],
+		})
+	}
+
+	return results
+}
+
+// Filter returns the hardware that passes all the filter plugins and then the extenders
+// hardware is checked against the plugins in order and stops at the first plugin that rejects it
+// an error is returned when an extender that isn't ignorable fails
+func (f *Framework) Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]*baremetalv1alpha1.BareMetalHardware, *Diagnosis, error) {
+	diagnosis := &Diagnosis{
+		plugins:  make([]string, 0, len(f.filters)+len(f.extenders)),
+		counts:   make(map[filterReason]int),
+		hardware: make(map[string][]string),
+	}
+	for _, plugin := range f.filters {
+		diagnosis.plugins = append(diagnosis.plugins, plugin.Name())
+	}
+	for _, extender := range f.extenders {
+		diagnosis.plugins = append(diagnosis.plugins, extender.Name())
+	}
+	feasible := make([]*baremetalv1alpha1.BareMetalHardware, 0, len(hardware))
+
+hardwareLoop:
+	for _, bmh := range hardware {
+		for i, plugin := range f.filters {
+			reasons := plugin.Filter(snapshot, bmi, bmh)
+			if len(reasons) == 0 {
+				continue
+			}
+
+			for _, reason := range reasons {
+				diagnosis.counts[filterReason{plugin: i, reason: reason}]++
+			}
+			diagnosis.hardware[bmh.Name] = reasons
+			continue hardwareLoop
+		}
+
+		feasible = append(feasible, bmh)
+	}
+
+	for i, extender := range f.extenders {
+		if len(feasible) == 0 {
+			break
+		}
+
+		extenderFeasible, failed, err := extender.Filter(bmi, feasible)
+		if err != nil {
+			if extender.IsIgnorable() {
+				continue
+			}
+			return nil, nil, fmt.Errorf("%s failed to filter: %v", extender.Name(), err)
+		}
+
+		for name, reason := range failed {
+			diagnosis.counts[filterReason{plugin: len(f.filters) + i, reason: reason}]++
+			diagnosis.hardware[name] = []string{reason}
+		}
+		feasible = extenderFeasible
+	}
+
+	return feasible, diagnosis, nil
+}
+
+// Score returns the weighted total score of each of the hardware from the score plugins and extenders, in the same order
+func (f *Framework) Score(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error) {
+	totals := make([]int64, len(hardware))
+
+	for _, plugin := range f.scores {
+		scores, err := plugin.Score(snapshot, bmi, hardware)
+		if err != nil {
+			return nil, fmt.Errorf("score plugin %s failed: %v", plugin.Name(), err)
+		}
+		if len(scores) != len(hardware) {
+			return nil, fmt.Errorf("score plugin %s returned %d scores for %d hardware", plugin.Name(), len(scores), len(hardware))
+		}
+
+		for i, score := range scores {
+			if score < 0 {
+				score = 0
+			}
+			if score > MaxScore {
+				score = MaxScore
+			}
+			totals[i] += score * plugin.weight
+		}
+	}
+
+	for _, extender := range f.extenders {
+		scores, err := extender.Prioritize(bmi, hardware)
+		if err != nil {
+			if extender.IsIgnorable() {
+				continue
+			}
+			return nil, fmt.Errorf("%s failed to prioritize: %v", extender.Name(), err)
+		}
+
+		for i, score := range scores {
+			totals[i] += score
+		}
+	}
+
+	return totals, nil
+}
+
+// Select scores the hardware and returns the highest scoring one, ties are broken randomly
+func (f *Framework) Select(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) (*baremetalv1alpha1.BareMetalHardware, error) {
+	if len(hardware) == 0 {
+		return nil, nil
+	}
+
+	scores, err := f.Score(snapshot, bmi, hardware)
+	if err != nil {
+		return nil, err
+	}
+
+	var best []*baremetalv1alpha1.BareMetalHardware
+	var bestScore int64
+	for i, bmh := range hardware {
+		if len(best) == 0 || scores[i] > bestScore {
+			best = []*baremetalv1alpha1.BareMetalHardware{bmh}
+			bestScore = scores[i]
+		} else if scores[i] == bestScore {
+			best = append(best, bmh)
+		}
+	}
+
+	return best[rand.Intn(len(best))], nil
+}
@@ -0,0 +1,338 @@
+package scheduler
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// fakeFilter rejects the hardware listed in reasons
+type fakeFilter struct {
+	name    string
+	reasons map[string][]string
+}
+
+func (p *fakeFilter) Name() string {
+	return p.name
+}
+
+func (p *fakeFilter) Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) []string {
+	return p.reasons[bmh.Name]
+}
+
+// fakeScore gives the hardware the listed scores and 0 to the rest
+type fakeScore struct {
+	name   string
+	scores map[string]int64
+	err    error
+	short  bool
+}
+
+func (p *fakeScore) Name() string {
+	return p.name
+}
+
+func (p *fakeScore) Score(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	scores := make([]int64, len(hardware))
+	for i, bmh := range hardware {
+		scores[i] = p.scores[bmh.Name]
+	}
+	if p.short {
+		scores = scores[1:]
+	}
+
+	return scores, nil
+}
+
+func namedHardware(names ...string) []*baremetalv1alpha1.BareMetalHardware {
+	hardware := make([]*baremetalv1alpha1.BareMetalHardware, 0, len(names))
+	for _, name := range names {
+		hardware = append(hardware, &baremetalv1alpha1.BareMetalHardware{
+			ObjectMeta: metav1.ObjectMeta{Namespace: benchmarkNamespace, Name: name},
+		})
+	}
+
+	return hardware
+}
+
+func hardwareNames(hardware []*baremetalv1alpha1.BareMetalHardware) []string {
+	names := make([]string, 0, len(hardware))
+	for _, bmh := range hardware {
+		names = append(names, bmh.Name)
+	}
+
+	return names
+}
+
+func TestNewFramework(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		filters []string
+		err     bool
+	}{
+		{
+			name:    "default",
+			config:  DefaultConfig(),
+			filters: []string{HardwareSelectorName, CanProvisionName, TaintTolerationName, ResourcesName, InstanceAffinityName, TopologySpreadName, QuotaName},
+		},
+		{
+			name:    "quota is always enabled",
+			config:  &Config{Filters: []PluginConfig{{Name: CanProvisionName}}},
+			filters: []string{CanProvisionName, QuotaName},
+		},
+		{
+			name:    "quota keeps its configured position",
+			config:  &Config{Filters: []PluginConfig{{Name: QuotaName}, {Name: CanProvisionName}}},
+			filters: []string{QuotaName, CanProvisionName},
+		},
+		{
+			name:   "unknown filter",
+			config: &Config{Filters: []PluginConfig{{Name: "Unknown"}}},
+			err:    true,
+		},
+		{
+			name:   "unknown score",
+			config: &Config{Scores: []PluginConfig{{Name: "Unknown"}}},
+			err:    true,
+		},
+		{
+			name:   "negative weight",
+			config: &Config{Scores: []PluginConfig{{Name: LeastWasteName, Weight: -1}}},
+			err:    true,
+		},
+		{
+			name:   "extender without verbs",
+			config: &Config{Extenders: []ExtenderConfig{{URLPrefix: "http://localhost"}}},
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			framework, err := NewFramework(test.config)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error creating framework: %v", err)
+			}
+
+			var filters []string
+			for _, plugin := range framework.filters {
+				filters = append(filters, plugin.Name())
+			}
+			if reflect.DeepEqual(filters, test.filters) == false {
+				t.Errorf("expected filters %v got %v", test.filters, filters)
+			}
+		})
+	}
+}
+
+func TestFrameworkFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  []FilterPlugin
+		hardware []string
+		feasible []string
+		reasons  []string
+		results  []FilterResult
+		rejected []HardwareResult
+	}{
+		{
+			name:     "no filters",
+			hardware: []string{"a", "b"},
+			feasible: []string{"a", "b"},
+			reasons:  []string{},
+			results:  []FilterResult{},
+			rejected: []HardwareResult{},
+		},
+		{
+			name: "counts reasons",
+			filters: []FilterPlugin{
+				&fakeFilter{name: "First", reasons: map[string][]string{"a": {"were too small"}, "c": {"were too small"}}},
+			},
+			hardware: []string{"a", "b", "c"},
+			feasible: []string{"b"},
+			reasons:  []string{"2 hardware(s) were too small"},
+			results:  []FilterResult{{Plugin: "First", Reason: "were too small", Count: 2}},
+			rejected: []HardwareResult{{Name: "a", Reasons: []string{"were too small"}}, {Name: "c", Reasons: []string{"were too small"}}},
+		},
+		{
+			name: "stops at the first plugin that rejects the hardware",
+			filters: []FilterPlugin{
+				&fakeFilter{name: "First", reasons: map[string][]string{"a": {"were tainted"}}},
+				&fakeFilter{name: "Second", reasons: map[string][]string{"a": {"were too small"}, "b": {"were too small", "were busy"}}},
+			},
+			hardware: []string{"a", "b", "c"},
+			feasible: []string{"c"},
+			reasons:  []string{"1 hardware(s) were tainted", "1 hardware(s) were busy", "1 hardware(s) were too small"},
+			results: []FilterResult{
+				{Plugin: "First", Reason: "were tainted", Count: 1},
+				{Plugin: "Second", Reason: "were busy", Count: 1},
+				{Plugin: "Second", Reason: "were too small", Count: 1},
+			},
+			rejected: []HardwareResult{{Name: "a", Reasons: []string{"were tainted"}}, {Name: "b", Reasons: []string{"were too small", "were busy"}}},
+		},
+		{
+			name: "nothing feasible",
+			filters: []FilterPlugin{
+				&fakeFilter{name: "First", reasons: map[string][]string{"a": {"were unschedulable"}, "b": {"were unschedulable"}}},
+			},
+			hardware: []string{"a", "b"},
+			feasible: []string{},
+			reasons:  []string{"2 hardware(s) were unschedulable"},
+			results:  []FilterResult{{Plugin: "First", Reason: "were unschedulable", Count: 2}},
+			rejected: []HardwareResult{{Name: "a", Reasons: []string{"were unschedulable"}}, {Name: "b", Reasons: []string{"were unschedulable"}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			framework := &Framework{filters: test.filters}
+
+			feasible, diagnosis, err := framework.Filter(&Snapshot{}, fakeInstance(0), namedHardware(test.hardware...))
+			if err != nil {
+				t.Fatalf("error filtering: %v", err)
+			}
+
+			if names := hardwareNames(feasible); reflect.DeepEqual(names, test.feasible) == false {
+				t.Errorf("expected feasible %v got %v", test.feasible, names)
+			}
+			if reasons := diagnosis.Reasons(); reflect.DeepEqual(reasons, test.reasons) == false {
+				t.Errorf("expected reasons %v got %v", test.reasons, reasons)
+			}
+			if results := diagnosis.Filters(); reflect.DeepEqual(results, test.results) == false {
+				t.Errorf("expected filter results %v got %v", test.results, results)
+			}
+			if rejected := diagnosis.Hardware(10); reflect.DeepEqual(rejected, test.rejected) == false {
+				t.Errorf("expected rejected hardware %v got %v", test.rejected, rejected)
+			}
+		})
+	}
+}
+
+func TestDiagnosisHardwareLimit(t *testing.T) {
+	reasons := make(map[string][]string)
+	var names []string
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("hardware-%d", i)
+		names = append(names, name)
+		reasons[name] = []string{"were unschedulable"}
+	}
+
+	framework := &Framework{filters: []FilterPlugin{&fakeFilter{name: "First", reasons: reasons}}}
+	_, diagnosis, err := framework.Filter(&Snapshot{}, fakeInstance(0), namedHardware(names...))
+	if err != nil {
+		t.Fatalf("error filtering: %v", err)
+	}
+
+	rejected := diagnosis.Hardware(2)
+	if len(rejected) != 2 || rejected[0].Name != "hardware-0" || rejected[1].Name != "hardware-1" {
+		t.Errorf("expected the first 2 hardware by name got %v", rejected)
+	}
+}
+
+func TestFrameworkSelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		scores   []weightedScorePlugin
+		hardware []string
+		selected []string
+		err      bool
+	}{
+		{
+			name:     "no hardware",
+			scores:   []weightedScorePlugin{{ScorePlugin: &fakeScore{name: "First"}, weight: 1}},
+			selected: []string{""},
+		},
+		{
+			name:     "highest score",
+			scores:   []weightedScorePlugin{{ScorePlugin: &fakeScore{name: "First", scores: map[string]int64{"a": 10, "b": 50, "c": 20}}, weight: 1}},
+			hardware: []string{"a", "b", "c"},
+			selected: []string{"b"},
+		},
+		{
+			name: "weights",
+			scores: []weightedScorePlugin{
+				{ScorePlugin: &fakeScore{name: "First", scores: map[string]int64{"a": 100, "b": 0}}, weight: 1},
+				{ScorePlugin: &fakeScore{name: "Second", scores: map[string]int64{"a": 0, "b": 60}}, weight: 2},
+			},
+			hardware: []string{"a", "b"},
+			selected: []string{"b"},
+		},
+		{
+			name: "scores are clamped",
+			scores: []weightedScorePlugin{
+				{ScorePlugin: &fakeScore{name: "First", scores: map[string]int64{"a": 1000, "b": 100}}, weight: 1},
+				{ScorePlugin: &fakeScore{name: "Second", scores: map[string]int64{"a": -50, "b": 1}}, weight: 1},
+			},
+			hardware: []string{"a", "b"},
+			selected: []string{"b"},
+		},
+		{
+			name:     "ties are picked from the best",
+			scores:   []weightedScorePlugin{{ScorePlugin: &fakeScore{name: "First", scores: map[string]int64{"a": 50, "b": 10, "c": 50}}, weight: 1}},
+			hardware: []string{"a", "b", "c"},
+			selected: []string{"a", "c"},
+		},
+		{
+			name:     "plugin error",
+			scores:   []weightedScorePlugin{{ScorePlugin: &fakeScore{name: "First", err: fmt.Errorf("failed")}, weight: 1}},
+			hardware: []string{"a"},
+			err:      true,
+		},
+		{
+			name:     "wrong number of scores",
+			scores:   []weightedScorePlugin{{ScorePlugin: &fakeScore{name: "First", short: true}, weight: 1}},
+			hardware: []string{"a", "b"},
+			err:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			framework := &Framework{scores: test.scores}
+
+			// ties are broken randomly so select a few times
+			for i := 0; i < 20; i++ {
+				selected, err := framework.Select(&Snapshot{}, fakeInstance(0), namedHardware(test.hardware...))
+				if test.err {
+					if err == nil {
+						t.Fatalf("expected an error")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("error selecting: %v", err)
+				}
+
+				name := ""
+				if selected != nil {
+					name = selected.Name
+				}
+
+				found := false
+				for _, expected := range test.selected {
+					if name == expected {
+						found = true
+					}
+				}
+				if found == false {
+					t.Fatalf("expected one of %v got %q", test.selected, name)
+				}
+			}
+		})
+	}
+}
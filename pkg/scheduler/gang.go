@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+type gangState struct {
+	// the members that reserved hardware and are waiting for the rest of the gang to the hardware they reserved
+	waiting map[types.NamespacedName]types.NamespacedName
+
+	// when the first of the waiting members reserved hardware
+	started time.Time
+}
+
+// GangStatus is how many members of a gang have hardware
+type GangStatus struct {
+	// The members that reserved hardware and are waiting for the rest of the gang
+	Waiting int
+
+	// The members that are bound to hardware
+	Bound int
+
+	// When the first of the waiting members reserved hardware
+	Started time.Time
+}
+
+// GangMember is a member of a gang that can be bound to the hardware it reserved
+type GangMember struct {
+	Instance     types.NamespacedName
+	HardwareName string
+
+	// Set when the hardware is a ClusterBareMetalHardware
+	ClusterHardware bool
+}
+
+func gangKey(bmi *baremetalv1alpha1.BareMetalInstance) types.NamespacedName {
+	return types.NamespacedName{Namespace: bmi.Namespace, Name: bmi.Spec.Gang.Name}
+}
+
+// helper method to remove the instance from the waiting members of its gang, the lock must be held
+func (c *Cache) leaveGang(key types.NamespacedName, assumed *assumedInstance) {
+	if assumed.gang == nil {
+		return
+	}
+
+	gang, ok := c.gangs[*assumed.gang]
+	if ok {
+		delete(gang.waiting, key)
+		if len(gang.waiting) == 0 {
+			delete(c.gangs, *assumed.gang)
+		}
+	}
+	assumed.gang = nil
+}
+
+// Reserve assumes the instance onto the hardware and adds it to the waiting members of its gang
+// the hardware stays reserved until the gang is permitted or released
+func (c *Cache) Reserve(bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	err := c.assume(bmi, bmh)
+	if err != nil {
+		return err
+	}
+
+	key := gangKey(bmi)
+	gang, ok := c.gangs[key]
+	if ok == false {
+		gang = &gangState{
+			waiting: make(map[types.NamespacedName]types.NamespacedName),
+			started: c.clock.Now(),
+		}
+		c.gangs[key] = gang
+	}
+
+	gang.waiting[instanceKey(bmi)] = hardwareKey(bmh)
+	c.assumed[instanceKey(bmi)].gang = &key
+
+	return nil
+}
+
+// IsWaitingForGang returns if the instance reserved hardware and is waiting for the rest of its gang
+func (c *Cache) IsWaitingForGang(bmi *baremetalv1alpha1.BareMetalInstance) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	assumed, ok := c.assumed[instanceKey(bmi)]
+	return ok && assumed.gang != nil
+}
+
+// PermitGang checks if enough members of the gang of the instance have hardware to reach the minimum members
+// when they do the waiting members are removed from the gang and returned so they can be bound
+// the returned members stay assumed until they are bound or forgotten
+func (c *Cache) PermitGang(bmi *baremetalv1alpha1.BareMetalInstance) ([]GangMember, GangStatus) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := gangKey(bmi)
+	status := GangStatus{}
+
+	gang, ok := c.gangs[key]
+	if ok {
+		status.Waiting = len(gang.waiting)
+		status.Started = gang.started
+	}
+
+	if ns, ok := c.namespaces[bmi.Namespace]; ok {
+		for _, member := range ns.instances {
+			if member.Spec.Gang == nil || member.Spec.Gang.Name != key.Name {
+				continue
+			}
+			if member.DeletionTimestamp.IsZero() == false || member.Status.Phase == baremetalv1alpha1.BareMetalInstanceStatusPhaseTerminated {
+				continue
+			}
+
+			// bound members are either seen by the informer or assumed without waiting for the gang
+			if len(member.Status.HardwareName) > 0 {
+				status.Bound++
+			} else if assumed, ok := c.assumed[instanceKey(member)]; ok && assumed.gang == nil {
+				status.Bound++
+			}
+		}
+	}
+
+	if gang == nil || status.Waiting+status.Bound < bmi.Spec.Gang.MinMembers {
+		return nil, status
+	}
+
+	members := make([]GangMember, 0, len(gang.waiting))
+	for instance, hardware := range gang.waiting {
+		members = append(members, GangMember{Instance: instance, HardwareName: hardware.Name, ClusterHardware: len(hardware.Namespace) == 0})
+		c.assumed[instance].gang = nil
+	}
+	delete(c.gangs, key)
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Instance.Name < members[j].Instance.Name
+	})
+
+	return members, status
+}
+
+// ReleaseGang forgets the waiting members of the gang of the instance so their hardware can be used by other instances
+// the released members are returned
+func (c *Cache) ReleaseGang(bmi *baremetalv1alpha1.BareMetalInstance) []types.NamespacedName {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	gang, ok := c.gangs[gangKey(bmi)]
+	if ok == false {
+		return nil
+	}
+
+	released := make([]types.NamespacedName, 0, len(gang.waiting))
+	for instance := range gang.waiting {
+		released = append(released, instance)
+	}
+	for _, instance := range released {
+		c.forget(instance)
+	}
+
+	return released
+}
@@ -0,0 +1,247 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+func gangInstance(name string, minMembers int) *baremetalv1alpha1.BareMetalInstance {
+	bmi := pendingInstance(name, nil, time.Minute)
+	bmi.Spec.Gang = &baremetalv1alpha1.InstanceGang{Name: "gang", MinMembers: minMembers}
+
+	return bmi
+}
+
+// helper method to create a cache with the hardware and the gang members, the members are reserved onto hardware in order
+func newGangCache(t *testing.T, hardware []*baremetalv1alpha1.BareMetalHardware, members []*baremetalv1alpha1.BareMetalInstance, reserved int) (*Cache, *clocktesting.FakeClock) {
+	clock := clocktesting.NewFakeClock(queueEpoch)
+	cache := NewCache(clock, DefaultAssumeTTL)
+
+	for _, bmh := range hardware {
+		cache.AddHardware(bmh)
+	}
+	for _, bmi := range members {
+		cache.AddInstance(bmi)
+	}
+	for i := 0; i < reserved; i++ {
+		if err := cache.Reserve(members[i], hardware[i]); err != nil {
+			t.Fatalf("error reserving %s: %v", members[i].Name, err)
+		}
+	}
+
+	return cache, clock
+}
+
+func gangMemberNames(members []GangMember) []string {
+	names := make([]string, 0, len(members))
+	for _, member := range members {
+		names = append(names, member.Instance.Name+"/"+member.HardwareName)
+	}
+
+	return names
+}
+
+func TestPermitGang(t *testing.T) {
+	tests := []struct {
+		name     string
+		members  int
+		reserved int
+		// called after the members are reserved
+		action  func(cache *Cache, members []*baremetalv1alpha1.BareMetalInstance)
+		permit  []string
+		waiting int
+		bound   int
+	}{
+		{
+			name:     "partial gang waits",
+			members:  3,
+			reserved: 2,
+			waiting:  2,
+		},
+		{
+			name:     "full gang binds",
+			members:  3,
+			reserved: 3,
+			permit:   []string{"a/hardware-a", "b/hardware-b", "c/hardware-c"},
+			waiting:  3,
+		},
+		{
+			name:     "bound members count towards the gang",
+			members:  3,
+			reserved: 2,
+			action: func(cache *Cache, members []*baremetalv1alpha1.BareMetalInstance) {
+				bound := members[2].DeepCopy()
+				bound.Status.HardwareName = "hardware-c"
+				cache.AddInstance(bound)
+			},
+			permit:  []string{"a/hardware-a", "b/hardware-b"},
+			waiting: 2,
+			bound:   1,
+		},
+		{
+			name:     "assumed members outside of the gang count as bound",
+			members:  3,
+			reserved: 2,
+			action: func(cache *Cache, members []*baremetalv1alpha1.BareMetalInstance) {
+				if err := cache.Assume(members[2], namedHardware("hardware-c")[0]); err != nil {
+					t.Fatalf("error assuming: %v", err)
+				}
+			},
+			permit:  []string{"a/hardware-a", "b/hardware-b"},
+			waiting: 2,
+			bound:   1,
+		},
+		{
+			name:     "deleting members don't count",
+			members:  3,
+			reserved: 2,
+			action: func(cache *Cache, members []*baremetalv1alpha1.BareMetalInstance) {
+				deleting := members[2].DeepCopy()
+				now := metav1.NewTime(queueEpoch)
+				deleting.DeletionTimestamp = &now
+				deleting.Status.HardwareName = "hardware-c"
+				cache.AddInstance(deleting)
+			},
+			waiting: 2,
+		},
+		{
+			name:     "released gang",
+			members:  3,
+			reserved: 3,
+			action: func(cache *Cache, members []*baremetalv1alpha1.BareMetalInstance) {
+				cache.ReleaseGang(members[0])
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hardware := namedHardware("hardware-a", "hardware-b", "hardware-c")
+			var members []*baremetalv1alpha1.BareMetalInstance
+			for _, name := range []string{"a", "b", "c"}[:test.members] {
+				members = append(members, gangInstance(name, 3))
+			}
+
+			cache, _ := newGangCache(t, hardware, members, test.reserved)
+			if test.action != nil {
+				test.action(cache, members)
+			}
+
+			permitted, status := cache.PermitGang(members[0])
+			if test.permit == nil && permitted != nil {
+				t.Errorf("expected the gang to not be permitted got %v", gangMemberNames(permitted))
+			} else if names := gangMemberNames(permitted); test.permit != nil && reflect.DeepEqual(names, test.permit) == false {
+				t.Errorf("expected permitted %v got %v", test.permit, names)
+			}
+			if status.Waiting != test.waiting || status.Bound != test.bound {
+				t.Errorf("expected %d waiting and %d bound got %d and %d", test.waiting, test.bound, status.Waiting, status.Bound)
+			}
+
+			// permitted members stay assumed until they are bound but aren't waiting anymore
+			for _, bmi := range members[:test.reserved] {
+				if test.permit != nil && (cache.IsWaitingForGang(bmi) || cache.IsAssumed(bmi) == false) {
+					t.Errorf("expected %s to be assumed without waiting for the gang", bmi.Name)
+				}
+				if test.permit == nil && test.waiting > 0 && cache.IsWaitingForGang(bmi) == false {
+					t.Errorf("expected %s to be waiting for the gang", bmi.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestReleaseGangAfterTimeout(t *testing.T) {
+	hardware := namedHardware("hardware-a", "hardware-b", "hardware-c")
+	members := []*baremetalv1alpha1.BareMetalInstance{gangInstance("a", 3), gangInstance("b", 3), gangInstance("c", 3)}
+
+	cache, clock := newGangCache(t, hardware, members, 1)
+
+	// the gang started when the first member reserved hardware
+	clock.Step(time.Minute)
+	if err := cache.Reserve(members[1], hardware[1]); err != nil {
+		t.Fatalf("error reserving: %v", err)
+	}
+
+	_, status := cache.PermitGang(members[1])
+	if status.Started.Equal(queueEpoch) == false {
+		t.Errorf("expected the gang to start at %v got %v", queueEpoch, status.Started)
+	}
+
+	// the scheduler releases the gang once the timeout has passed since it started
+	clock.Step(time.Duration(baremetalv1alpha1.DefaultGangScheduleTimeoutSeconds) * time.Second)
+	released := cache.ReleaseGang(members[1])
+	if len(released) != 2 {
+		t.Fatalf("expected 2 released members got %v", released)
+	}
+	for _, bmi := range members[:2] {
+		if cache.IsAssumed(bmi) || cache.IsWaitingForGang(bmi) {
+			t.Errorf("expected %s to be forgotten", bmi.Name)
+		}
+	}
+
+	// the released hardware can be used by other instances
+	other := fakeInstance(0)
+	for _, bmh := range hardware[:2] {
+		if err := cache.Assume(other, bmh); err != nil {
+			t.Errorf("expected %s to be released got %v", bmh.Name, err)
+		}
+		cache.Forget(other)
+	}
+
+	if released := cache.ReleaseGang(members[0]); len(released) != 0 {
+		t.Errorf("expected nothing to release got %v", released)
+	}
+
+	// a gang that reserves hardware again starts over
+	if err := cache.Reserve(members[2], hardware[2]); err != nil {
+		t.Fatalf("error reserving: %v", err)
+	}
+	_, status = cache.PermitGang(members[2])
+	if status.Waiting != 1 || status.Started.Equal(clock.Now()) == false {
+		t.Errorf("expected a new gang with 1 waiting member got %d started at %v", status.Waiting, status.Started)
+	}
+}
+
+func TestGangMemberDeleted(t *testing.T) {
+	hardware := namedHardware("hardware-a", "hardware-b", "hardware-c", "hardware-d")
+	members := []*baremetalv1alpha1.BareMetalInstance{gangInstance("a", 3), gangInstance("b", 3), gangInstance("c", 3), gangInstance("d", 3)}
+
+	cache, _ := newGangCache(t, hardware, members, 2)
+
+	// the deleted member stops waiting and its hardware is freed
+	cache.RemoveInstance(members[0])
+	if cache.IsAssumed(members[0]) || cache.IsWaitingForGang(members[0]) {
+		t.Errorf("expected the deleted member to be forgotten")
+	}
+	if err := cache.Reserve(members[2], hardware[0]); err != nil {
+		t.Fatalf("expected the hardware of the deleted member to be free got %v", err)
+	}
+
+	permitted, status := cache.PermitGang(members[1])
+	if permitted != nil || status.Waiting != 2 {
+		t.Fatalf("expected the gang to wait with 2 members got %v %d", gangMemberNames(permitted), status.Waiting)
+	}
+
+	if err := cache.Reserve(members[3], hardware[3]); err != nil {
+		t.Fatalf("error reserving: %v", err)
+	}
+	permitted, _ = cache.PermitGang(members[3])
+	expected := []string{"b/hardware-b", "c/hardware-a", "d/hardware-d"}
+	if names := gangMemberNames(permitted); reflect.DeepEqual(names, expected) == false {
+		t.Errorf("expected permitted %v got %v", expected, names)
+	}
+
+	// releasing a gang that was permitted doesn't forget the members that are being bound
+	if released := cache.ReleaseGang(members[1]); len(released) != 0 {
+		t.Errorf("expected nothing to release got %v", released)
+	}
+	if cache.IsAssumed(members[1]) == false || cache.IsWaitingForGang(members[1]) {
+		t.Errorf("expected the permitted member to stay assumed without waiting for the gang")
+	}
+}
@@ -0,0 +1,298 @@
+package scheduler
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// queuedInstance is an instance waiting to be scheduled
+type queuedInstance struct {
+	key      types.NamespacedName
+	priority int32
+	created  time.Time
+
+	// when the instance was found to be unschedulable
+	unschedulableTime time.Time
+
+	// the scheduling cycle the instance was popped in
+	cycle int64
+
+	// the index in the heap, maintained by the heap
+	index int
+}
+
+// less orders instances by highest priority first then the oldest first
+func (q *queuedInstance) less(other *queuedInstance) bool {
+	if q.priority != other.priority {
+		return q.priority > other.priority
+	}
+	if q.created.Equal(other.created) == false {
+		return q.created.Before(other.created)
+	}
+	if q.key.Namespace != other.key.Namespace {
+		return q.key.Namespace < other.key.Namespace
+	}
+	return q.key.Name < other.key.Name
+}
+
+type instanceHeap []*queuedInstance
+
+func (h instanceHeap) Len() int           { return len(h) }
+func (h instanceHeap) Less(i, j int) bool { return h[i].less(h[j]) }
+func (h instanceHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *instanceHeap) Push(x interface{}) {
+	item := x.(*queuedInstance)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *instanceHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+// Queue holds the pending instances that need hardware
+//
+// Instances are popped highest priority first and oldest first within a priority. Instances that didn't fit are
+// parked as unschedulable until MoveAllToActive is called because the hardware changed or the unschedulable interval
+// passed. An instance is only given to one worker at a time.
+type Queue struct {
+	lock  sync.Mutex
+	cond  *sync.Cond
+	clock clock.Clock
+
+	// how long an instance stays unschedulable when nothing changes
+	unschedulableInterval time.Duration
+
+	active        instanceHeap
+	activeItems   map[types.NamespacedName]*queuedInstance
+	unschedulable map[types.NamespacedName]*queuedInstance
+
+	// instances given to a worker, re-added when they are done if they were added while processing
+	processing map[types.NamespacedName]*queuedInstance
+	dirty      map[types.NamespacedName]bool
+
+	// incremented on every pop, the cycle of the last MoveAllToActive is kept so instances that were being
+	// scheduled when the hardware changed aren't parked as unschedulable
+	cycle            int64
+	moveRequestCycle int64
+
+	shutdown bool
+}
+
+func NewQueue(clock clock.Clock, unschedulableInterval time.Duration) *Queue {
+	q := &Queue{
+		clock:                 clock,
+		unschedulableInterval: unschedulableInterval,
+		activeItems:           make(map[types.NamespacedName]*queuedInstance),
+		unschedulable:         make(map[types.NamespacedName]*queuedInstance),
+		processing:            make(map[types.NamespacedName]*queuedInstance),
+		dirty:                 make(map[types.NamespacedName]bool),
+	}
+	q.cond = sync.NewCond(&q.lock)
+
+	return q
+}
+
+func newQueuedInstance(bmi *baremetalv1alpha1.BareMetalInstance) *queuedInstance {
+	var priority int32
+	if bmi.Spec.Priority != nil {
+		priority = *bmi.Spec.Priority
+	}
+
+	return &queuedInstance{
+		key:      instanceKey(bmi),
+		priority: priority,
+		created:  bmi.CreationTimestamp.Time,
+	}
+}
+
+// helper method to push an instance onto the active heap, the lock must be held
+func (q *Queue) pushActive(item *queuedInstance) {
+	heap.Push(&q.active, item)
+	q.activeItems[item.key] = item
+	q.cond.Signal()
+}
+
+// Add queues the instance to be scheduled
+// instances that are already queued or unschedulable are left where they are
+func (q *Queue) Add(bmi *baremetalv1alpha1.BareMetalInstance) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.shutdown {
+		return
+	}
+
+	key := instanceKey(bmi)
+	if _, ok := q.processing[key]; ok {
+		q.dirty[key] = true
+		return
+	}
+	if _, ok := q.activeItems[key]; ok {
+		return
+	}
+	if _, ok := q.unschedulable[key]; ok {
+		return
+	}
+
+	q.pushActive(newQueuedInstance(bmi))
+}
+
+// AddUnschedulable parks an instance that didn't fit until the hardware changes or the unschedulable interval passes
+func (q *Queue) AddUnschedulable(bmi *baremetalv1alpha1.BareMetalInstance) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.shutdown {
+		return
+	}
+
+	key := instanceKey(bmi)
+	if _, ok := q.activeItems[key]; ok {
+		return
+	}
+
+	// the hardware changed while the instance was being scheduled so try again once it is done
+	if processing, ok := q.processing[key]; ok && q.moveRequestCycle >= processing.cycle {
+		q.dirty[key] = true
+		return
+	}
+
+	item := newQueuedInstance(bmi)
+	item.unschedulableTime = q.clock.Now()
+	q.unschedulable[key] = item
+}
+
+// Delete removes the instance from the queue
+func (q *Queue) Delete(bmi *baremetalv1alpha1.BareMetalInstance) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	key := instanceKey(bmi)
+	if item, ok := q.activeItems[key]; ok {
+		heap.Remove(&q.active, item.index)
+		delete(q.activeItems, key)
+	}
+	delete(q.unschedulable, key)
+	delete(q.dirty, key)
+}
+
+// MoveAllToActive queues all the unschedulable instances again, called when something changed that could make them fit
+func (q *Queue) MoveAllToActive() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for key, item := range q.unschedulable {
+		delete(q.unschedulable, key)
+		q.pushActive(item)
+	}
+	q.moveRequestCycle = q.cycle
+}
+
+// helper method to queue the instances that have been unschedulable for longer then the interval, the lock must be held
+func (q *Queue) flushUnschedulable() {
+	now := q.clock.Now()
+	for key, item := range q.unschedulable {
+		if now.Sub(item.unschedulableTime) < q.unschedulableInterval {
+			continue
+		}
+
+		delete(q.unschedulable, key)
+		q.pushActive(item)
+	}
+}
+
+// Pop blocks until an instance is queued and returns the one with the highest priority
+// the worker must call Done with the key once it is finished, false is returned when the queue is shut down
+func (q *Queue) Pop() (types.NamespacedName, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for len(q.active) == 0 && q.shutdown == false {
+		q.cond.Wait()
+	}
+	if q.shutdown {
+		return types.NamespacedName{}, false
+	}
+
+	item := heap.Pop(&q.active).(*queuedInstance)
+	delete(q.activeItems, item.key)
+	q.cycle++
+	item.cycle = q.cycle
+	q.processing[item.key] = item
+
+	return item.key, true
+}
+
+// Done marks the instance as no longer being processed
+// instances that were added while being processed are queued again
+func (q *Queue) Done(key types.NamespacedName) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	item, ok := q.processing[key]
+	if ok == false {
+		return
+	}
+	delete(q.processing, key)
+
+	if q.dirty[key] {
+		delete(q.dirty, key)
+		if _, ok := q.unschedulable[key]; ok {
+			return
+		}
+		if _, ok := q.activeItems[key]; ok {
+			return
+		}
+		q.pushActive(item)
+	}
+}
+
+// Len returns the number of instances waiting to be popped
+func (q *Queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return len(q.active)
+}
+
+// Run flushes the unschedulable instances until the stop channel is closed, then shuts the queue down
+func (q *Queue) Run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			q.ShutDown()
+			return
+		case <-q.clock.After(q.unschedulableInterval / 4):
+			q.lock.Lock()
+			q.flushUnschedulable()
+			q.lock.Unlock()
+		}
+	}
+}
+
+// ShutDown wakes up all the workers waiting in Pop so they can exit
+func (q *Queue) ShutDown() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.shutdown = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,274 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+var queueEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func pendingInstance(name string, priority *int32, age time.Duration) *baremetalv1alpha1.BareMetalInstance {
+	return &baremetalv1alpha1.BareMetalInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         benchmarkNamespace,
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(queueEpoch.Add(-age)),
+		},
+		Spec: baremetalv1alpha1.BareMetalInstanceSpec{
+			Priority: priority,
+		},
+	}
+}
+
+func priority(p int32) *int32 {
+	return &p
+}
+
+// helper method to pop all the queued instances and mark them done
+func popAll(t *testing.T, q *Queue) []string {
+	var names []string
+	for q.Len() > 0 {
+		key, ok := q.Pop()
+		if ok == false {
+			t.Fatalf("queue was shut down")
+		}
+		names = append(names, key.Name)
+		q.Done(key)
+	}
+
+	return names
+}
+
+func TestQueueOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances []*baremetalv1alpha1.BareMetalInstance
+		expected  []string
+	}{
+		{
+			name: "oldest first",
+			instances: []*baremetalv1alpha1.BareMetalInstance{
+				pendingInstance("new", nil, time.Minute),
+				pendingInstance("old", nil, time.Hour),
+				pendingInstance("middle", nil, 10*time.Minute),
+			},
+			expected: []string{"old", "middle", "new"},
+		},
+		{
+			name: "highest priority first",
+			instances: []*baremetalv1alpha1.BareMetalInstance{
+				pendingInstance("default", nil, time.Hour),
+				pendingInstance("high", priority(1000), time.Minute),
+				pendingInstance("low", priority(-10), 2*time.Hour),
+				pendingInstance("medium", priority(10), time.Minute),
+			},
+			expected: []string{"high", "medium", "default", "low"},
+		},
+		{
+			name: "name breaks ties",
+			instances: []*baremetalv1alpha1.BareMetalInstance{
+				pendingInstance("c", priority(1), time.Minute),
+				pendingInstance("a", priority(1), time.Minute),
+				pendingInstance("b", priority(1), time.Minute),
+			},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name: "duplicates are only queued once",
+			instances: []*baremetalv1alpha1.BareMetalInstance{
+				pendingInstance("a", nil, time.Minute),
+				pendingInstance("a", priority(100), time.Minute),
+				pendingInstance("b", nil, time.Hour),
+			},
+			expected: []string{"b", "a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := NewQueue(clocktesting.NewFakeClock(queueEpoch), time.Minute)
+			for _, bmi := range test.instances {
+				q.Add(bmi)
+			}
+
+			if names := popAll(t, q); reflect.DeepEqual(names, test.expected) == false {
+				t.Errorf("expected %v got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestQueueUnschedulable(t *testing.T) {
+	tests := []struct {
+		name string
+		// called with the instance already popped and parked as unschedulable
+		action   func(q *Queue, clock *clocktesting.FakeClock, bmi *baremetalv1alpha1.BareMetalInstance)
+		expected []string
+	}{
+		{
+			name:   "stays parked",
+			action: func(q *Queue, clock *clocktesting.FakeClock, bmi *baremetalv1alpha1.BareMetalInstance) {},
+		},
+		{
+			name: "add leaves it parked",
+			action: func(q *Queue, clock *clocktesting.FakeClock, bmi *baremetalv1alpha1.BareMetalInstance) {
+				q.Add(bmi)
+			},
+		},
+		{
+			name: "move all to active",
+			action: func(q *Queue, clock *clocktesting.FakeClock, bmi *baremetalv1alpha1.BareMetalInstance) {
+				q.MoveAllToActive()
+			},
+			expected: []string{"a"},
+		},
+		{
+			name: "flushed after the interval",
+			action: func(q *Queue, clock *clocktesting.FakeClock, bmi *baremetalv1alpha1.BareMetalInstance) {
+				clock.Step(time.Minute)
+				q.lock.Lock()
+				q.flushUnschedulable()
+				q.lock.Unlock()
+			},
+			expected: []string{"a"},
+		},
+		{
+			name: "not flushed before the interval",
+			action: func(q *Queue, clock *clocktesting.FakeClock, bmi *baremetalv1alpha1.BareMetalInstance) {
+				clock.Step(time.Minute - time.Second)
+				q.lock.Lock()
+				q.flushUnschedulable()
+				q.lock.Unlock()
+			},
+		},
+		{
+			name: "deleted",
+			action: func(q *Queue, clock *clocktesting.FakeClock, bmi *baremetalv1alpha1.BareMetalInstance) {
+				q.Delete(bmi)
+				q.MoveAllToActive()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := clocktesting.NewFakeClock(queueEpoch)
+			q := NewQueue(clock, time.Minute)
+			bmi := pendingInstance("a", nil, time.Minute)
+
+			q.Add(bmi)
+			key, _ := q.Pop()
+			q.AddUnschedulable(bmi)
+			q.Done(key)
+
+			test.action(q, clock, bmi)
+
+			if names := popAll(t, q); reflect.DeepEqual(names, test.expected) == false {
+				t.Errorf("expected %v got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestQueueProcessing(t *testing.T) {
+	tests := []struct {
+		name string
+		// called while the instance is being processed
+		action   func(q *Queue, bmi *baremetalv1alpha1.BareMetalInstance)
+		expected []string
+	}{
+		{
+			name:   "done",
+			action: func(q *Queue, bmi *baremetalv1alpha1.BareMetalInstance) {},
+		},
+		{
+			name: "added while processing is queued again",
+			action: func(q *Queue, bmi *baremetalv1alpha1.BareMetalInstance) {
+				q.Add(bmi)
+			},
+			expected: []string{"a"},
+		},
+		{
+			name: "unschedulable is parked",
+			action: func(q *Queue, bmi *baremetalv1alpha1.BareMetalInstance) {
+				q.AddUnschedulable(bmi)
+			},
+		},
+		{
+			name: "hardware changed while processing",
+			action: func(q *Queue, bmi *baremetalv1alpha1.BareMetalInstance) {
+				q.MoveAllToActive()
+				q.AddUnschedulable(bmi)
+			},
+			expected: []string{"a"},
+		},
+		{
+			name: "deleted while processing",
+			action: func(q *Queue, bmi *baremetalv1alpha1.BareMetalInstance) {
+				q.Add(bmi)
+				q.Delete(bmi)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := NewQueue(clocktesting.NewFakeClock(queueEpoch), time.Minute)
+			bmi := pendingInstance("a", nil, time.Minute)
+
+			q.Add(bmi)
+			key, ok := q.Pop()
+			if ok == false || key != (types.NamespacedName{Namespace: benchmarkNamespace, Name: "a"}) {
+				t.Fatalf("expected to pop a got %v %v", key, ok)
+			}
+
+			test.action(q, bmi)
+
+			// an instance is only given to one worker at a time
+			if q.Len() != 0 {
+				t.Fatalf("expected the instance to not be queued while it is processing")
+			}
+			q.Done(key)
+
+			if names := popAll(t, q); reflect.DeepEqual(names, test.expected) == false {
+				t.Errorf("expected %v got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestQueueShutDown(t *testing.T) {
+	q := NewQueue(clocktesting.NewFakeClock(queueEpoch), time.Minute)
+
+	popped := make(chan bool)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, ok := q.Pop()
+			popped <- ok
+		}()
+	}
+
+	q.ShutDown()
+	for i := 0; i < 3; i++ {
+		select {
+		case ok := <-popped:
+			if ok {
+				t.Errorf("expected pop to return false after shut down")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("workers were not woken up by shut down")
+		}
+	}
+
+	q.Add(pendingInstance("a", nil, time.Minute))
+	if q.Len() != 0 {
+		t.Errorf("expected instances to not be added after shut down")
+	}
+}
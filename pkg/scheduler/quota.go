@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/pkg/quota"
+)
+
+const (
+	QuotaName = "Quota"
+)
+
+// Quota filters out hardware that would put the namespace of the instance over the cpus or ram of its quotas
+// it is always enabled, when the config doesn't list it the plugin runs after the configured filters
+type Quota struct{}
+
+func NewQuota(args json.RawMessage) (FilterPlugin, error) {
+	return &Quota{}, nil
+}
+
+func (p *Quota) Name() string {
+	return QuotaName
+}
+
+func (p *Quota) Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) []string {
+	var reasons []string
+
+	if len(snapshot.Quotas) == 0 {
+		return reasons
+	}
+
+	usage := snapshot.QuotaUsage().AddHardware(bmh)
+	for _, bmq := range snapshot.Quotas {
+		for _, resourceName := range quota.ExceededHardware(bmq.Spec.Hard, usage) {
+			reasons = append(reasons, fmt.Sprintf("would exceed the %s of quota %s", resourceName, bmq.Name))
+		}
+	}
+
+	// quotas come from a map so sort the reasons to keep the diagnosis stable
+	sort.Strings(reasons)
+
+	return reasons
+}
@@ -0,0 +1,177 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+const (
+	benchmarkNamespace = "default"
+	benchmarkHardware  = 5000
+	benchmarkInstances = 2500
+)
+
+func fakeHardware(i int) *baremetalv1alpha1.BareMetalHardware {
+	return &baremetalv1alpha1.BareMetalHardware{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: benchmarkNamespace,
+			Name:      fmt.Sprintf("hardware-%d", i),
+			UID:       types.UID(fmt.Sprintf("hardware-%d", i)),
+			Labels: map[string]string{
+				"topology.kubernetes.io/zone": fmt.Sprintf("zone-%d", i%3),
+				"baremetal.com.rmb938/rack":   fmt.Sprintf("rack-%d", i%50),
+			},
+		},
+		Spec: baremetalv1alpha1.BareMetalHardwareSpec{
+			CanProvision: true,
+			ImageDrive:   "sda",
+		},
+		Status: baremetalv1alpha1.BareMetalHardwareStatus{
+			Hardware: &baremetalv1alpha1.BareMetalDiscoveryHardware{
+				CPU: baremetalv1alpha1.BareMetalDiscoveryHardwareCPU{
+					CPUS: *resource.NewQuantity(int64(8+(i%4)*8), resource.DecimalSI),
+				},
+				Ram: *resource.NewQuantity(int64(16+(i%4)*16)*1024*1024*1024, resource.BinarySI),
+				Storage: []baremetalv1alpha1.BareMetalDiscoveryHardwareStorage{
+					{
+						Name: "sda",
+						Size: resource.MustParse("500Gi"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func fakeInstance(i int) *baremetalv1alpha1.BareMetalInstance {
+	cpus := resource.MustParse("8")
+	ram := resource.MustParse("16Gi")
+
+	return &baremetalv1alpha1.BareMetalInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: benchmarkNamespace,
+			Name:      fmt.Sprintf("instance-%d", i),
+			UID:       types.UID(fmt.Sprintf("instance-%d", i)),
+			Labels: map[string]string{
+				"app": fmt.Sprintf("app-%d", i%10),
+			},
+		},
+		Spec: baremetalv1alpha1.BareMetalInstanceSpec{
+			Resources: &baremetalv1alpha1.BareMetalInstanceResources{
+				CPUS: &cpus,
+				Ram:  &ram,
+			},
+			TopologySpreadConstraints: []baremetalv1alpha1.TopologySpreadConstraint{
+				{
+					MaxSkew:           1,
+					TopologyKey:       "topology.kubernetes.io/zone",
+					WhenUnsatisfiable: baremetalv1alpha1.ScheduleAnyway,
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app": fmt.Sprintf("app-%d", i%10)},
+					},
+				},
+			},
+		},
+		Status: baremetalv1alpha1.BareMetalInstanceStatus{
+			Phase: baremetalv1alpha1.BareMetalInstanceStatusPhasePending,
+		},
+	}
+}
+
+// newBenchmarkCache returns a cache with the fake hardware where half of it has an instance assigned
+func newBenchmarkCache(b *testing.B) (*Framework, *Cache) {
+	framework, err := NewFramework(DefaultConfig())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	cache := NewCache(clock.RealClock{}, DefaultAssumeTTL)
+	for i := 0; i < benchmarkHardware; i++ {
+		cache.AddHardware(fakeHardware(i))
+	}
+	for i := 0; i < benchmarkInstances; i++ {
+		bmi := fakeInstance(i)
+		bmi.Status.Phase = baremetalv1alpha1.BareMetalInstanceStatusPhaseRunning
+		bmi.Status.HardwareName = fmt.Sprintf("hardware-%d", i*2)
+		cache.AddInstance(bmi)
+	}
+
+	return framework, cache
+}
+
+// scheduleOne runs a scheduling cycle the same way the scheduler controller does and assumes the instance
+func scheduleOne(framework *Framework, cache *Cache, bmi *baremetalv1alpha1.BareMetalInstance) (string, error) {
+	snapshot := cache.Snapshot(bmi.Namespace)
+
+	free := make([]*baremetalv1alpha1.BareMetalHardware, 0, len(snapshot.Hardware))
+	for _, bmh := range snapshot.Hardware {
+		if bmh.DeletionTimestamp.IsZero() == false || bmh.Status.InstanceRef != nil || snapshot.HardwareAssigned(bmh) {
+			continue
+		}
+		free = append(free, bmh)
+	}
+
+	feasible, diagnosis, err := framework.Filter(snapshot, bmi, free)
+	if err != nil {
+		return "", err
+	}
+	if len(feasible) == 0 {
+		return "", fmt.Errorf("no hardware available: %v", diagnosis.Reasons())
+	}
+
+	selected, err := framework.Select(snapshot, bmi, feasible)
+	if err != nil {
+		return "", err
+	}
+
+	return selected.Name, cache.Assume(bmi, selected)
+}
+
+func BenchmarkSchedule(b *testing.B) {
+	framework, cache := newBenchmarkCache(b)
+	bmi := fakeInstance(benchmarkInstances)
+	cache.AddInstance(bmi)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := scheduleOne(framework, cache, bmi); err != nil {
+			b.Fatal(err)
+		}
+		cache.Forget(bmi)
+	}
+}
+
+func BenchmarkScheduleParallel(b *testing.B) {
+	framework, cache := newBenchmarkCache(b)
+
+	var next int64 = benchmarkInstances
+	var conflicts int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		bmi := fakeInstance(int(atomic.AddInt64(&next, 1)))
+		cache.AddInstance(bmi)
+
+		for pb.Next() {
+			_, err := scheduleOne(framework, cache, bmi)
+			if err == ErrHardwareAssigned {
+				atomic.AddInt64(&conflicts, 1)
+				continue
+			}
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			cache.Forget(bmi)
+		}
+	})
+	b.ReportMetric(float64(conflicts)/float64(b.N), "conflicts/op")
+}
@@ -0,0 +1,249 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+const (
+	LeastWasteName  = "LeastWaste"
+	PreferLabelName = "PreferLabel"
+	SpreadName      = "Spread"
+)
+
+// LeastWaste prefers the hardware that has the least cpus, ram and image drive space left over after the instance's requests
+// so small instances don't take up the big hardware
+type LeastWaste struct{}
+
+func NewLeastWaste(args json.RawMessage) (ScorePlugin, error) {
+	return &LeastWaste{}, nil
+}
+
+func (p *LeastWaste) Name() string {
+	return LeastWasteName
+}
+
+func (p *LeastWaste) Score(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error) {
+	resources := bmi.Spec.Resources
+	if resources == nil {
+		resources = &baremetalv1alpha1.BareMetalInstanceResources{}
+	}
+
+	type dimension struct {
+		requested *resource.Quantity
+		available func(bmh *baremetalv1alpha1.BareMetalHardware) *resource.Quantity
+	}
+
+	dimensions := []dimension{
+		{
+			requested: resources.CPUS,
+			available: func(bmh *baremetalv1alpha1.BareMetalHardware) *resource.Quantity {
+				return &bmh.Status.Hardware.CPU.CPUS
+			},
+		},
+		{
+			requested: resources.Ram,
+			available: func(bmh *baremetalv1alpha1.BareMetalHardware) *resource.Quantity {
+				return &bmh.Status.Hardware.Ram
+			},
+		},
+	}
+
+	// the image drive is only compared when a size is requested, otherwise hardware is never penalized for a big drive
+	if resources.ImageDriveSize != nil {
+		dimensions = append(dimensions, dimension{
+			requested: resources.ImageDriveSize,
+			available: func(bmh *baremetalv1alpha1.BareMetalHardware) *resource.Quantity {
+				if drive := imageDrive(bmh); drive != nil {
+					return &drive.Size
+				}
+				return nil
+			},
+		})
+	}
+
+	scores := make([]int64, len(hardware))
+	for _, d := range dimensions {
+		var requested float64
+		if d.requested != nil {
+			requested = float64(d.requested.MilliValue())
+		}
+
+		// the waste of each hardware, hardware that hasn't been discovered is treated as the most wasteful
+		waste := make([]float64, len(hardware))
+		known := make([]bool, len(hardware))
+		var minWaste, maxWaste float64
+		first := true
+		for i, bmh := range hardware {
+			if bmh.Status.Hardware == nil {
+				continue
+			}
+			available := d.available(bmh)
+			if available == nil {
+				continue
+			}
+
+			waste[i] = float64(available.MilliValue()) - requested
+			known[i] = true
+			if first || waste[i] < minWaste {
+				minWaste = waste[i]
+			}
+			if first || waste[i] > maxWaste {
+				maxWaste = waste[i]
+			}
+			first = false
+		}
+
+		for i := range hardware {
+			if known[i] == false {
+				continue
+			}
+
+			if maxWaste == minWaste {
+				scores[i] += MaxScore
+				continue
+			}
+			scores[i] += int64(float64(MaxScore) * (maxWaste - waste[i]) / (maxWaste - minWaste))
+		}
+	}
+
+	for i := range scores {
+		scores[i] /= int64(len(dimensions))
+	}
+
+	return scores, nil
+}
+
+type PreferLabelArgs struct {
+	// The label on the hardware
+	Label string `json:"label"`
+
+	// The value the label must have, when empty any value matches
+	Value string `json:"value,omitempty"`
+
+	// Prefer hardware that has the label when true (default) or hardware that doesn't have it when false
+	Presence *bool `json:"presence,omitempty"`
+}
+
+// PreferLabel prefers hardware that has (or doesn't have) a label
+type PreferLabel struct {
+	args PreferLabelArgs
+}
+
+func NewPreferLabel(args json.RawMessage) (ScorePlugin, error) {
+	p := &PreferLabel{}
+	if err := decodeArgs(args, &p.args); err != nil {
+		return nil, err
+	}
+
+	if len(p.args.Label) == 0 {
+		return nil, fmt.Errorf("label must be set")
+	}
+
+	if p.args.Presence == nil {
+		p.args.Presence = func(b bool) *bool { return &b }(true)
+	}
+
+	return p, nil
+}
+
+func (p *PreferLabel) Name() string {
+	return PreferLabelName
+}
+
+func (p *PreferLabel) Score(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error) {
+	scores := make([]int64, len(hardware))
+
+	for i, bmh := range hardware {
+		value, ok := bmh.Labels[p.args.Label]
+		matches := ok && (len(p.args.Value) == 0 || value == p.args.Value)
+
+		if matches == *p.args.Presence {
+			scores[i] = MaxScore
+		}
+	}
+
+	return scores, nil
+}
+
+type SpreadArgs struct {
+	// The hardware label that groups hardware into a domain, i.e. a rack or a zone
+	// hardware without the label is its own domain
+	TopologyKey string `json:"topologyKey,omitempty"`
+}
+
+// Spread prefers the domains that run the fewest instances that have the same labels as the instance
+// so a failing domain takes out as few of them as possible
+type Spread struct {
+	args SpreadArgs
+}
+
+func NewSpread(args json.RawMessage) (ScorePlugin, error) {
+	p := &Spread{}
+	if err := decodeArgs(args, &p.args); err != nil {
+		return nil, err
+	}
+
+	if len(p.args.TopologyKey) == 0 {
+		p.args.TopologyKey = "topology.kubernetes.io/zone"
+	}
+
+	return p, nil
+}
+
+func (p *Spread) Name() string {
+	return SpreadName
+}
+
+// helper to get the domain of the hardware
+func (p *Spread) domain(bmh *baremetalv1alpha1.BareMetalHardware) string {
+	if value, ok := bmh.Labels[p.args.TopologyKey]; ok {
+		return p.args.TopologyKey + "=" + value
+	}
+
+	return "hardware=" + bmh.Name
+}
+
+func (p *Spread) Score(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error) {
+	selector := labels.SelectorFromSet(bmi.Labels)
+
+	counts := make(map[string]int64)
+	for _, other := range snapshot.Instances {
+		if other.UID == bmi.UID || len(other.Status.HardwareName) == 0 || other.Status.Phase == baremetalv1alpha1.BareMetalInstanceStatusPhaseTerminated {
+			continue
+		}
+
+		if selector.Matches(labels.Set(other.Labels)) == false {
+			continue
+		}
+
+		bmh := snapshot.InstanceHardware(other)
+		if bmh == nil {
+			continue
+		}
+		counts[p.domain(bmh)]++
+	}
+
+	var maxCount int64
+	for _, bmh := range hardware {
+		if count := counts[p.domain(bmh)]; count > maxCount {
+			maxCount = count
+		}
+	}
+
+	scores := make([]int64, len(hardware))
+	for i, bmh := range hardware {
+		if maxCount == 0 {
+			scores[i] = MaxScore
+			continue
+		}
+		scores[i] = MaxScore * (maxCount - counts[p.domain(bmh)]) / maxCount
+	}
+
+	return scores, nil
+}
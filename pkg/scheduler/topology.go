@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// assignedInstance is an instance that has been scheduled onto hardware
+type assignedInstance struct {
+	bmi *baremetalv1alpha1.BareMetalInstance
+	bmh *baremetalv1alpha1.BareMetalHardware
+}
+
+// assignedInstances returns the instances other then the given instance that have hardware assigned
+func assignedInstances(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance) []assignedInstance {
+	if snapshot.assigned != nil && snapshot.assignedFor == bmi.UID {
+		return snapshot.assigned
+	}
+
+	assigned := make([]assignedInstance, 0)
+	var antiAffinity []assignedInstance
+
+	for _, other := range snapshot.Instances {
+		if other.UID == bmi.UID || len(other.Status.HardwareName) == 0 || other.Status.Phase == baremetalv1alpha1.BareMetalInstanceStatusPhaseTerminated {
+			continue
+		}
+
+		bmh := snapshot.InstanceHardware(other)
+		if bmh == nil {
+			continue
+		}
+
+		assigned = append(assigned, assignedInstance{bmi: other, bmh: bmh})
+		if other.Spec.Affinity != nil && other.Spec.Affinity.InstanceAntiAffinity != nil && len(other.Spec.Affinity.InstanceAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 {
+			antiAffinity = append(antiAffinity, assignedInstance{bmi: other, bmh: bmh})
+		}
+	}
+
+	snapshot.assignedFor = bmi.UID
+	snapshot.assigned = assigned
+	snapshot.antiAffinity = antiAffinity
+	snapshot.domainCounts = nil
+
+	return assigned
+}
+
+// antiAffinityInstances returns the assigned instances that have required anti-affinity terms
+func antiAffinityInstances(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance) []assignedInstance {
+	assignedInstances(snapshot, bmi)
+	return snapshot.antiAffinity
+}
+
+// selectorFor converts a label selector, a nil or invalid selector matches nothing
+// selectors are validated by the webhook so invalid selectors should never happen
+func selectorFor(selector *metav1.LabelSelector) labels.Selector {
+	if selector == nil {
+		return labels.Nothing()
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return labels.Nothing()
+	}
+
+	return s
+}
+
+// sameDomain returns if both hardware have the topology key with the same value
+func sameDomain(a, b *baremetalv1alpha1.BareMetalHardware, topologyKey string) bool {
+	aValue, ok := a.Labels[topologyKey]
+	if ok == false {
+		return false
+	}
+
+	bValue, ok := b.Labels[topologyKey]
+	if ok == false {
+		return false
+	}
+
+	return aValue == bValue
+}
+
+// normalizeScores scales the raw scores so the highest is MaxScore and the lowest is 0
+// when all the scores are equal they are all set to the given default
+func normalizeScores(raw []int64, equal int64) []int64 {
+	scores := make([]int64, len(raw))
+	if len(raw) == 0 {
+		return scores
+	}
+
+	minScore, maxScore := raw[0], raw[0]
+	for _, score := range raw {
+		if score < minScore {
+			minScore = score
+		}
+		if score > maxScore {
+			maxScore = score
+		}
+	}
+
+	for i, score := range raw {
+		if maxScore == minScore {
+			scores[i] = equal
+			continue
+		}
+		scores[i] = MaxScore * (score - minScore) / (maxScore - minScore)
+	}
+
+	return scores
+}
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+const (
+	TopologySpreadName = "TopologySpread"
+)
+
+// domainCounts returns the number of instances matching the constraint at the index in each domain
+// domains are all the values of the topology key on hardware that matches the hardware selector and affinity of the instance
+func domainCounts(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, index int) map[string]int64 {
+	assigned := assignedInstances(snapshot, bmi)
+	if counts, ok := snapshot.domainCounts[index]; ok {
+		return counts
+	}
+
+	constraint := bmi.Spec.TopologySpreadConstraints[index]
+	counts := make(map[string]int64)
+
+	var hardwareSelector labels.Selector
+	if len(bmi.Spec.Selector) > 0 {
+		hardwareSelector = labels.SelectorFromSet(bmi.Spec.Selector)
+	}
+
+	for _, bmh := range snapshot.Hardware {
+		if bmh.DeletionTimestamp.IsZero() == false {
+			continue
+		}
+		if hardwareSelector != nil && hardwareSelector.Matches(labels.Set(bmh.Labels)) == false {
+			continue
+		}
+		if matchesRequiredHardwareAffinity(bmi, bmh) == false {
+			continue
+		}
+
+		if value, ok := bmh.Labels[constraint.TopologyKey]; ok {
+			counts[value] = 0
+		}
+	}
+
+	selector := selectorFor(constraint.LabelSelector)
+	for _, other := range assigned {
+		value, ok := other.bmh.Labels[constraint.TopologyKey]
+		if ok == false {
+			continue
+		}
+
+		// only count domains that the instance could be scheduled into
+		if _, ok := counts[value]; ok == false {
+			continue
+		}
+
+		if selector.Matches(labels.Set(other.bmi.Labels)) {
+			counts[value]++
+		}
+	}
+
+	if snapshot.domainCounts == nil {
+		snapshot.domainCounts = make(map[int]map[string]int64)
+	}
+	snapshot.domainCounts[index] = counts
+
+	return counts
+}
+
+// TopologySpreadFilter filters out hardware that would break a DoNotSchedule topology spread constraint
+type TopologySpreadFilter struct{}
+
+func NewTopologySpreadFilter(args json.RawMessage) (FilterPlugin, error) {
+	return &TopologySpreadFilter{}, nil
+}
+
+func (p *TopologySpreadFilter) Name() string {
+	return TopologySpreadName
+}
+
+func (p *TopologySpreadFilter) Filter(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, bmh *baremetalv1alpha1.BareMetalHardware) []string {
+	for i, constraint := range bmi.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable != baremetalv1alpha1.DoNotSchedule {
+			continue
+		}
+
+		value, ok := bmh.Labels[constraint.TopologyKey]
+		if ok == false {
+			return []string{"didn't match instance topology spread constraints (missing required label)"}
+		}
+
+		counts := domainCounts(snapshot, bmi, i)
+
+		var minCount int64 = -1
+		for _, count := range counts {
+			if minCount == -1 || count < minCount {
+				minCount = count
+			}
+		}
+
+		// the instance only adds to the count when it matches its own constraint
+		var selfMatch int64
+		if selectorFor(constraint.LabelSelector).Matches(labels.Set(bmi.Labels)) {
+			selfMatch = 1
+		}
+
+		if counts[value]+selfMatch-minCount > int64(constraint.MaxSkew) {
+			return []string{"didn't match instance topology spread constraints"}
+		}
+	}
+
+	return nil
+}
+
+// TopologySpreadScore prefers the domains with the fewest matching instances for ScheduleAnyway topology spread constraints
+type TopologySpreadScore struct{}
+
+func NewTopologySpreadScore(args json.RawMessage) (ScorePlugin, error) {
+	return &TopologySpreadScore{}, nil
+}
+
+func (p *TopologySpreadScore) Name() string {
+	return TopologySpreadName
+}
+
+func (p *TopologySpreadScore) Score(snapshot *Snapshot, bmi *baremetalv1alpha1.BareMetalInstance, hardware []*baremetalv1alpha1.BareMetalHardware) ([]int64, error) {
+	raw := make([]int64, len(hardware))
+
+	for i, constraint := range bmi.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable != baremetalv1alpha1.ScheduleAnyway {
+			continue
+		}
+
+		counts := domainCounts(snapshot, bmi, i)
+
+		var maxCount int64
+		for _, count := range counts {
+			if count > maxCount {
+				maxCount = count
+			}
+		}
+
+		for i, bmh := range hardware {
+			value, ok := bmh.Labels[constraint.TopologyKey]
+			if ok == false {
+				// hardware outside of all domains is the least preferred
+				raw[i] -= maxCount + 1
+				continue
+			}
+			raw[i] -= counts[value]
+		}
+	}
+
+	return normalizeScores(raw, MaxScore), nil
+}
@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+)
+
+// spreadSnapshot returns the zoned hardware with two web instances in zone-1, one in zone-2 and a db instance in zone-3
+func spreadSnapshot(bmi *baremetalv1alpha1.BareMetalInstance) *Snapshot {
+	return &Snapshot{Hardware: zonedHardware(), Instances: []*baremetalv1alpha1.BareMetalInstance{
+		labeledInstance("web-1", "web", "a"),
+		labeledInstance("web-2", "web", "b"),
+		labeledInstance("web-3", "web", "c"),
+		labeledInstance("db-1", "db", "e"),
+		bmi,
+	}}
+}
+
+func spreadConstraint(maxSkew int32, whenUnsatisfiable baremetalv1alpha1.UnsatisfiableConstraintAction) baremetalv1alpha1.TopologySpreadConstraint {
+	return baremetalv1alpha1.TopologySpreadConstraint{
+		MaxSkew:           maxSkew,
+		TopologyKey:       testTopologyKey,
+		WhenUnsatisfiable: whenUnsatisfiable,
+		LabelSelector:     appSelector("web"),
+	}
+}
+
+func TestTopologySpreadFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		app         string
+		constraints []baremetalv1alpha1.TopologySpreadConstraint
+		feasible    []string
+	}{
+		{
+			name:     "no constraints",
+			app:      "web",
+			feasible: []string{"a", "b", "c", "d", "e", "f"},
+		},
+		{
+			name:        "max skew 1",
+			app:         "web",
+			constraints: []baremetalv1alpha1.TopologySpreadConstraint{spreadConstraint(1, baremetalv1alpha1.DoNotSchedule)},
+			feasible:    []string{"e"},
+		},
+		{
+			name:        "max skew 2",
+			app:         "web",
+			constraints: []baremetalv1alpha1.TopologySpreadConstraint{spreadConstraint(2, baremetalv1alpha1.DoNotSchedule)},
+			feasible:    []string{"c", "d", "e"},
+		},
+		{
+			name:        "max skew 3",
+			app:         "web",
+			constraints: []baremetalv1alpha1.TopologySpreadConstraint{spreadConstraint(3, baremetalv1alpha1.DoNotSchedule)},
+			feasible:    []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:        "instance not matching its own constraint",
+			app:         "batch",
+			constraints: []baremetalv1alpha1.TopologySpreadConstraint{spreadConstraint(1, baremetalv1alpha1.DoNotSchedule)},
+			feasible:    []string{"c", "d", "e"},
+		},
+		{
+			name:        "schedule anyway is only scored",
+			app:         "web",
+			constraints: []baremetalv1alpha1.TopologySpreadConstraint{spreadConstraint(1, baremetalv1alpha1.ScheduleAnyway)},
+			feasible:    []string{"a", "b", "c", "d", "e", "f"},
+		},
+		{
+			// web rejects zone-1 and db rejects zone-3 since the instance adds to its count
+			name: "every constraint must be satisfied",
+			app:  "db",
+			constraints: []baremetalv1alpha1.TopologySpreadConstraint{
+				spreadConstraint(1, baremetalv1alpha1.DoNotSchedule),
+				func() baremetalv1alpha1.TopologySpreadConstraint {
+					constraint := spreadConstraint(1, baremetalv1alpha1.DoNotSchedule)
+					constraint.LabelSelector = appSelector("db")
+					return constraint
+				}(),
+			},
+			feasible: []string{"c", "d"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bmi := labeledInstance("new", test.app, "")
+			bmi.Spec.TopologySpreadConstraints = test.constraints
+
+			if feasible := filterHardware(&TopologySpreadFilter{}, spreadSnapshot(bmi), bmi); reflect.DeepEqual(feasible, test.feasible) == false {
+				t.Errorf("expected feasible %v got %v", test.feasible, feasible)
+			}
+		})
+	}
+}
+
+func TestTopologySpreadScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints []baremetalv1alpha1.TopologySpreadConstraint
+		scores      []int64
+	}{
+		{
+			name:   "no constraints",
+			scores: []int64{100, 100, 100, 100, 100, 100},
+		},
+		{
+			name:        "do not schedule is only filtered",
+			constraints: []baremetalv1alpha1.TopologySpreadConstraint{spreadConstraint(1, baremetalv1alpha1.DoNotSchedule)},
+			scores:      []int64{100, 100, 100, 100, 100, 100},
+		},
+		{
+			// zone-1 has 2, zone-2 has 1, zone-3 has none and hardware without a zone is the least preferred
+			name:        "fewest matching instances",
+			constraints: []baremetalv1alpha1.TopologySpreadConstraint{spreadConstraint(1, baremetalv1alpha1.ScheduleAnyway)},
+			scores:      []int64{33, 33, 66, 66, 100, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bmi := labeledInstance("new", "web", "")
+			bmi.Spec.TopologySpreadConstraints = test.constraints
+
+			snapshot := spreadSnapshot(bmi)
+			scores, err := (&TopologySpreadScore{}).Score(snapshot, bmi, snapshot.Hardware)
+			if err != nil {
+				t.Fatalf("error scoring: %v", err)
+			}
+			if reflect.DeepEqual(scores, test.scores) == false {
+				t.Errorf("expected scores %v got %v", test.scores, scores)
+			}
+		})
+	}
+}
+
+func TestTopologySpreadHardwareAffinity(t *testing.T) {
+	bmi := labeledInstance("new", "web", "")
+	bmi.Spec.TopologySpreadConstraints = []baremetalv1alpha1.TopologySpreadConstraint{spreadConstraint(1, baremetalv1alpha1.DoNotSchedule)}
+
+	// zone-3 is left out of the domains so zone-2 has the fewest matching instances
+	// e is still feasible here as it is only rejected by the hardware selector filter
+	bmi.Spec.Affinity = &baremetalv1alpha1.Affinity{HardwareAffinity: &baremetalv1alpha1.HardwareAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &baremetalv1alpha1.HardwareSelector{HardwareSelectorTerms: []baremetalv1alpha1.HardwareSelectorTerm{
+			hardwareTerm(testTopologyKey, baremetalv1alpha1.HardwareSelectorOpNotIn, "zone-3"),
+		}},
+	}}
+
+	expected := []string{"c", "d", "e"}
+	if feasible := filterHardware(&TopologySpreadFilter{}, spreadSnapshot(bmi), bmi); reflect.DeepEqual(feasible, expected) == false {
+		t.Errorf("expected feasible %v got %v", expected, feasible)
+	}
+}
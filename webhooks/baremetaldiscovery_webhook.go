@@ -128,24 +128,8 @@ func (w *BareMetalDiscoveryWebhook) ValidateCreate(obj runtime.Object) error {
 		))
 	}
 
-	// Block creation if existing BMH
-	existingBMH := &baremetalv1alpha1.BareMetalDiscoveryList{}
-	err = w.client.List(ctx, existingBMH, client.MatchingFields{"spec.systemUUID": string(r.Spec.SystemUUID)})
-	if err != nil {
-		allErrs = append(allErrs, field.InternalError(
-			field.NewPath("spec").Child("systemUUID"),
-			err,
-		))
-	}
-
-	if len(existingBMH.Items) > 0 {
-		allErrs = append(allErrs, field.Duplicate(
-			field.NewPath("spec").Child("systemUUID"),
-			string(r.Spec.SystemUUID),
-		))
-	}
-
-	// TODO: block creation if existing CBMH
+	// Block creation if existing BMH or CBMH
+	allErrs = append(allErrs, validateSystemUUID(ctx, w.client, r.Spec.SystemUUID)...)
 
 	// Validate Hardware
 	if r.Status.Hardware != nil {
@@ -109,6 +109,11 @@ func (w *BareMetalEndpointWebhook) ValidateCreate(obj runtime.Object) error {
 		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("mac"), r.Spec.MAC, err.Error()))
 	}
 
+	// validate requested ip
+	if len(r.Spec.RequestedIP) > 0 && net.ParseIP(r.Spec.RequestedIP) == nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("requestedIP"), r.Spec.RequestedIP, "invalid requested ip address"))
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -174,13 +179,28 @@ func (w *BareMetalEndpointWebhook) ValidateUpdate(obj runtime.Object, old runtim
 		))
 	}
 
+	// never allow changing requested ip
+	if r.Spec.RequestedIP != oldBME.Spec.RequestedIP {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("spec").Child("requestedIP"),
+			"Cannot change the requestedIP",
+		))
+	}
+
 	if r.Status.Address != nil {
 		// never allow changing address if it is already set
-		if reflect.DeepEqual(r.Status.Address, oldBME.Status.Address) == false {
+		// the dns, routes and mtu can change when the network is updated
+		if oldBME.Status.Address == nil || r.Status.Address.IP != oldBME.Status.Address.IP || r.Status.Address.CIDR != oldBME.Status.Address.CIDR || r.Status.Address.Gateway != oldBME.Status.Address.Gateway ||
+			r.Status.Address.DHCP != oldBME.Status.Address.DHCP || r.Status.Address.IPFamily != oldBME.Status.Address.IPFamily {
 			allErrs = append(allErrs, field.Forbidden(
 				field.NewPath("status").Child("address"),
 				"Cannot change the address",
 			))
+		} else if r.Status.Address.DHCP {
+			// dhcp addresses are only configured on the instance
+			if len(r.Status.Address.IP) > 0 || len(r.Status.Address.CIDR) > 0 || len(r.Status.Address.Gateway) > 0 || len(r.Status.Address.Nameservers) > 0 || len(r.Status.Address.Routes) > 0 {
+				allErrs = append(allErrs, field.Forbidden(field.NewPath("status").Child("address"), "Cannot set ip, cidr, gateway, nameservers or routes on a dhcp address"))
+			}
 		} else {
 			// validate network
 			var networkIP net.IP
@@ -209,10 +229,8 @@ func (w *BareMetalEndpointWebhook) ValidateUpdate(obj runtime.Object, old runtim
 			if gateway == nil {
 				allErrs = append(allErrs, field.Invalid(field.NewPath("status").Child("address").Child("gateway"), r.Status.Address.Gateway, "invalid gateway address"))
 			} else {
-				if network != nil {
-					if network.Contains(gateway) == false {
-						allErrs = append(allErrs, field.Invalid(field.NewPath("status").Child("address").Child("gateway"), r.Status.Address.Gateway, "gateway is not in cidr"))
-					}
+				if network != nil && validGateway(gateway, network) == false {
+					allErrs = append(allErrs, field.Invalid(field.NewPath("status").Child("address").Child("gateway"), r.Status.Address.Gateway, "gateway is not in cidr"))
 				}
 			}
 
@@ -222,6 +240,10 @@ func (w *BareMetalEndpointWebhook) ValidateUpdate(obj runtime.Object, old runtim
 				if nsIP == nil {
 					allErrs = append(allErrs, field.Invalid(field.NewPath("status").Child("address").Child("nameservers").Index(i), ns, "invalid nameserver address"))
 				} else {
+					nsIP4 := nsIP.To4()
+					if nsIP4 != nil {
+						nsIP = nsIP4
+					}
 					if networkIP != nil {
 						if len(networkIP) != len(nsIP) {
 							allErrs = append(allErrs, field.Invalid(field.NewPath("status").Child("address").Child("nameservers").Index(i), ns, "nameserver ip version is different then cidr ip version"))
@@ -239,6 +261,9 @@ func (w *BareMetalEndpointWebhook) ValidateUpdate(obj runtime.Object, old runtim
 					}
 				}
 			}
+
+			// Validate routes
+			allErrs = append(allErrs, validateRoutes(r.Status.Address.Routes, network, field.NewPath("status").Child("address").Child("routes"))...)
 		}
 	}
 
@@ -17,11 +17,15 @@ package webhooks
 
 import (
 	"context"
+	"net"
 	"reflect"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -57,18 +61,40 @@ func (w *BareMetalHardwareWebhook) Default(obj runtime.Object) {
 
 	baremetalhardwarelog.Info("default", "name", r.Name)
 
-	if r.DeletionTimestamp.IsZero() {
+	defaultHardware(r)
+}
+
+// defaultHardware sets the defaults that BareMetalHardware and ClusterBareMetalHardware share
+func defaultHardware(hw baremetalv1alpha1.Hardware) {
+	spec := hw.GetHardwareSpec()
+
+	if hw.GetDeletionTimestamp().IsZero() {
 		// add the finalizer
-		if baremetalapi.HasFinalizer(r, baremetalv1alpha1.BareMetalHardwareFinalizer) == false {
-			r.Finalizers = append(r.Finalizers, baremetalv1alpha1.BareMetalHardwareFinalizer)
+		if baremetalapi.HasFinalizer(hw, baremetalv1alpha1.BareMetalHardwareFinalizer) == false {
+			hw.SetFinalizers(append(hw.GetFinalizers(), baremetalv1alpha1.BareMetalHardwareFinalizer))
 		}
 
 		// set the default nic bond mode
-		for _, nic := range r.Spec.NICS {
+		for _, nic := range spec.NICS {
 			if nic.Bond != nil {
 				if len(nic.Bond.Mode) == 0 {
 					nic.Bond.Mode = baremetalv1alpha1.BondModeActiveBackup
 				}
+
+				// set the default nic bond miimon
+				if nic.Bond.MiiMon == nil {
+					miiMon := baremetalv1alpha1.BondDefaultMiiMon
+					nic.Bond.MiiMon = &miiMon
+				}
+			}
+		}
+
+		// record when NoExecute taints are added so tolerationSeconds can be counted from it
+		for i := range spec.Taints {
+			taint := &spec.Taints[i]
+			if taint.Effect == corev1.TaintEffectNoExecute && taint.TimeAdded == nil {
+				nowTime := metav1.Now()
+				taint.TimeAdded = &nowTime
 			}
 		}
 	}
@@ -76,14 +102,14 @@ func (w *BareMetalHardwareWebhook) Default(obj runtime.Object) {
 
 var _ webhook.Validator = &BareMetalHardwareWebhook{}
 
-func (w *BareMetalHardwareWebhook) validateNICs(bmh *baremetalv1alpha1.BareMetalHardware) field.ErrorList {
+func validateNICs(spec *baremetalv1alpha1.BareMetalHardwareSpec) field.ErrorList {
 	var allErrs field.ErrorList
 
-	if len(bmh.Spec.NICS) > 0 {
+	if len(spec.NICS) > 0 {
 		foundPrimary := false
 
 		var foundNICS []string
-		for i, nic := range bmh.Spec.NICS {
+		for i, nic := range spec.NICS {
 
 			duplicateNIC := false
 			for _, foundNIC := range foundNICS {
@@ -104,6 +130,61 @@ func (w *BareMetalHardwareWebhook) validateNICs(bmh *baremetalv1alpha1.BareMetal
 					foundPrimary = true
 				}
 			}
+
+			if nic.Bond != nil {
+				// the xmit hash policy is only used by some bond modes
+				if len(nic.Bond.XmitHashPolicy) > 0 {
+					switch nic.Bond.Mode {
+					case baremetalv1alpha1.BondModeBalanceXOR, baremetalv1alpha1.BondModeLACP, baremetalv1alpha1.BondModeBalanceTLB:
+						break
+					default:
+						allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("nics").Index(i).Child("bond").Child("xmitHashPolicy"), nic.Bond.XmitHashPolicy, "xmit hash policy can only be set when the bond mode is balance-xor, lacp or balance-tlb"))
+					}
+				}
+
+				// the lacp rate is only used by lacp
+				if len(nic.Bond.LACPRate) > 0 && nic.Bond.Mode != baremetalv1alpha1.BondModeLACP {
+					allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("nics").Index(i).Child("bond").Child("lacpRate"), nic.Bond.LACPRate, "lacp rate can only be set when the bond mode is lacp"))
+				}
+			}
+
+			// a nic can only be attached to a network once
+			networkRefs := nic.NetworkRefs()
+			for j, networkRef := range networkRefs {
+				for k := 0; k < j; k++ {
+					if networkRefs[k] == networkRef {
+						allErrs = append(allErrs, field.Duplicate(field.NewPath("spec").Child("nics").Index(i).Child("additionalNetworkRefs").Index(j-1), networkRef))
+						break
+					}
+				}
+			}
+
+			// requested addresses must be for a network the nic is attached to
+			for j, requestedAddress := range nic.RequestedAddresses {
+				requestedPath := field.NewPath("spec").Child("nics").Index(i).Child("requestedAddresses").Index(j)
+
+				if net.ParseIP(requestedAddress.IP) == nil {
+					allErrs = append(allErrs, field.Invalid(requestedPath.Child("ip"), requestedAddress.IP, "invalid ip address"))
+				}
+
+				attached := false
+				for _, networkRef := range networkRefs {
+					if networkRef == requestedAddress.NetworkRef {
+						attached = true
+						break
+					}
+				}
+				if attached == false {
+					allErrs = append(allErrs, field.Invalid(requestedPath.Child("networkRef"), requestedAddress.NetworkRef, "nic is not attached to the network"))
+				}
+
+				for k := 0; k < j; k++ {
+					if nic.RequestedAddresses[k].NetworkRef == requestedAddress.NetworkRef {
+						allErrs = append(allErrs, field.Duplicate(requestedPath.Child("networkRef"), requestedAddress.NetworkRef))
+						break
+					}
+				}
+			}
 		}
 
 		if foundPrimary == false {
@@ -114,18 +195,14 @@ func (w *BareMetalHardwareWebhook) validateNICs(bmh *baremetalv1alpha1.BareMetal
 	return allErrs
 }
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (w *BareMetalHardwareWebhook) ValidateCreate(obj runtime.Object) error {
-	ctx := context.Background()
-	r := obj.(*baremetalv1alpha1.BareMetalHardware)
-
-	baremetalhardwarelog.Info("validate create", "name", r.Name)
-
+// validateSystemUUID checks that no BareMetalHardware or ClusterBareMetalHardware has the system uuid
+// the system uuid has to be unique across both kinds since it is how the discovery agent finds its hardware
+func validateSystemUUID(ctx context.Context, c client.Client, systemUUID types.UID) field.ErrorList {
 	var allErrs field.ErrorList
 
 	// Block creation if existing BMH
 	existingBMH := &baremetalv1alpha1.BareMetalHardwareList{}
-	err := w.client.List(ctx, existingBMH, client.MatchingFields{"spec.systemUUID": string(r.Spec.SystemUUID)})
+	err := c.List(ctx, existingBMH, client.MatchingFields{"spec.systemUUID": string(systemUUID)})
 	if err != nil {
 		allErrs = append(allErrs, field.InternalError(
 			field.NewPath("spec").Child("systemUUID"),
@@ -133,51 +210,63 @@ func (w *BareMetalHardwareWebhook) ValidateCreate(obj runtime.Object) error {
 		))
 	}
 
-	if len(existingBMH.Items) > 0 {
+	// Block creation if existing CBMH
+	existingCBMH := &baremetalv1alpha1.ClusterBareMetalHardwareList{}
+	err = c.List(ctx, existingCBMH, client.MatchingFields{"spec.systemUUID": string(systemUUID)})
+	if err != nil {
+		allErrs = append(allErrs, field.InternalError(
+			field.NewPath("spec").Child("systemUUID"),
+			err,
+		))
+	}
+
+	if len(existingBMH.Items) > 0 || len(existingCBMH.Items) > 0 {
 		allErrs = append(allErrs, field.Duplicate(
 			field.NewPath("spec").Child("systemUUID"),
-			string(r.Spec.SystemUUID),
+			string(systemUUID),
 		))
 	}
 
-	// TODO: block creation if existing CBMH
+	return allErrs
+}
+
+// validateHardwareCreate validates the creation of a BareMetalHardware or ClusterBareMetalHardware
+func validateHardwareCreate(ctx context.Context, c client.Client, hw baremetalv1alpha1.Hardware) field.ErrorList {
+	spec := hw.GetHardwareSpec()
+	status := hw.GetHardwareStatus()
+
+	allErrs := validateSystemUUID(ctx, c, spec.SystemUUID)
 
 	// Block creation when hardware is set
-	if r.Status.Hardware != nil {
+	if status.Hardware != nil {
 		allErrs = append(allErrs, field.Forbidden(field.NewPath("status").Child("hardware"), "Cannot have hardware set when creating"))
 	}
 
-	if r.Status.InstanceRef != nil {
+	if status.InstanceRef != nil {
 		allErrs = append(allErrs, field.Forbidden(field.NewPath("status").Child("instanceRef"), "Cannot have instanceRef set when creating"))
 	}
 
-	allErrs = append(allErrs, w.validateNICs(r)...)
-
-	if len(allErrs) == 0 {
-		return nil
-	}
+	allErrs = append(allErrs, validateNICs(spec)...)
 
-	return apierrors.NewInvalid(
-		schema.GroupKind{Group: baremetalv1alpha1.GroupVersion.Group, Kind: r.Kind},
-		r.Name, allErrs)
+	return allErrs
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (w *BareMetalHardwareWebhook) ValidateUpdate(obj runtime.Object, old runtime.Object) error {
-	r := obj.(*baremetalv1alpha1.BareMetalHardware)
-
-	baremetalhardwarelog.Info("validate update", "name", r.Name)
-	oldBMH := old.(*baremetalv1alpha1.BareMetalHardware)
+// validateHardwareUpdate validates the update of a BareMetalHardware or ClusterBareMetalHardware
+func validateHardwareUpdate(hw baremetalv1alpha1.Hardware, old baremetalv1alpha1.Hardware) field.ErrorList {
+	spec := hw.GetHardwareSpec()
+	status := hw.GetHardwareStatus()
+	oldSpec := old.GetHardwareSpec()
+	oldStatus := old.GetHardwareStatus()
 
 	var allErrs field.ErrorList
 
 	// never allow removing conditions
 	var existingConditions []conditionv1.ConditionType
-	for _, cond := range oldBMH.Status.GetConditions() {
+	for _, cond := range oldStatus.GetConditions() {
 		existingConditions = append(existingConditions, cond.Type)
 	}
 	for _, condType := range existingConditions {
-		cond := r.Status.GetCondition(condType)
+		cond := status.GetCondition(condType)
 		if cond == nil {
 			allErrs = append(allErrs, field.Forbidden(
 				field.NewPath("status").Child("conditions"),
@@ -187,26 +276,56 @@ func (w *BareMetalHardwareWebhook) ValidateUpdate(obj runtime.Object, old runtim
 	}
 
 	// Never allow changing system uuid
-	if r.Spec.SystemUUID != oldBMH.Spec.SystemUUID {
+	if spec.SystemUUID != oldSpec.SystemUUID {
 		allErrs = append(allErrs, field.Forbidden(
 			field.NewPath("spec").Child("systemUUID"),
 			"Cannot change the system uuid",
 		))
 	}
-	if oldBMH.Status.Hardware != nil {
+	if oldStatus.Hardware != nil {
 		// never allow changing hardware if it is already set
-		if reflect.DeepEqual(r.Status.Hardware, oldBMH.Status.Hardware) == false {
+		if reflect.DeepEqual(status.Hardware, oldStatus.Hardware) == false {
 			allErrs = append(allErrs, field.Forbidden(
 				field.NewPath("status").Child("hardware"),
 				"Cannot change the hardware",
 			))
 		} else {
 			// Validate Hardware
-			allErrs = append(allErrs, validateHardware(r.Status.Hardware, field.NewPath("status"))...)
+			allErrs = append(allErrs, validateHardware(status.Hardware, field.NewPath("status"))...)
 		}
 	}
 
-	allErrs = append(allErrs, w.validateNICs(r)...)
+	allErrs = append(allErrs, validateNICs(spec)...)
+
+	return allErrs
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (w *BareMetalHardwareWebhook) ValidateCreate(obj runtime.Object) error {
+	ctx := context.Background()
+	r := obj.(*baremetalv1alpha1.BareMetalHardware)
+
+	baremetalhardwarelog.Info("validate create", "name", r.Name)
+
+	allErrs := validateHardwareCreate(ctx, w.client, r)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: baremetalv1alpha1.GroupVersion.Group, Kind: r.Kind},
+		r.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (w *BareMetalHardwareWebhook) ValidateUpdate(obj runtime.Object, old runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.BareMetalHardware)
+
+	baremetalhardwarelog.Info("validate update", "name", r.Name)
+	oldBMH := old.(*baremetalv1alpha1.BareMetalHardware)
+
+	allErrs := validateHardwareUpdate(r, oldBMH)
 
 	if len(allErrs) == 0 {
 		return nil
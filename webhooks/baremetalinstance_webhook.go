@@ -16,12 +16,19 @@ limitations under the License.
 package webhooks
 
 import (
+	"context"
+	"fmt"
 	"net"
 	"reflect"
+	"strings"
 
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -30,6 +37,7 @@ import (
 	baremetalapi "github.com/rmb938/kube-baremetal/api"
 	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
 	conditionv1 "github.com/rmb938/kube-baremetal/apis/condition/v1"
+	"github.com/rmb938/kube-baremetal/pkg/quota"
 	"github.com/rmb938/kube-baremetal/webhook"
 	"github.com/rmb938/kube-baremetal/webhook/admission"
 )
@@ -83,6 +91,13 @@ func (w *BareMetalInstanceWebhook) ValidateCreate(obj runtime.Object) error {
 		))
 	}
 
+	if r.Status.ClusterHardware {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("status").Child("clusterHardware"),
+			"Cannot set cluster hardware during creation",
+		))
+	}
+
 	if r.Status.AgentInfo != nil {
 		allErrs = append(allErrs, field.Forbidden(
 			field.NewPath("status").Child("agentInfo"),
@@ -90,13 +105,293 @@ func (w *BareMetalInstanceWebhook) ValidateCreate(obj runtime.Object) error {
 		))
 	}
 
-	if len(allErrs) == 0 {
+	allErrs = append(allErrs, validateResources(r.Spec.Resources, field.NewPath("spec").Child("resources"))...)
+	allErrs = append(allErrs, validateAffinity(r.Spec.Affinity, field.NewPath("spec").Child("affinity"))...)
+	allErrs = append(allErrs, validateTopologySpreadConstraints(r.Spec.TopologySpreadConstraints, field.NewPath("spec").Child("topologySpreadConstraints"))...)
+	allErrs = append(allErrs, validateGang(r.Spec.Gang, field.NewPath("spec").Child("gang"))...)
+
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(
+			schema.GroupKind{Group: baremetalv1alpha1.GroupVersion.Group, Kind: r.Kind},
+			r.Name, allErrs)
+	}
+
+	return w.checkQuotas(r)
+}
+
+// helper method to check that creating the instance doesn't exceed the quotas of its namespace
+// the cpus and ram of the hardware aren't known until an instance is scheduled so the requested resources are used for pending instances
+// this is racy, instances created at the same time don't see each other so the quota filter of the scheduler is what enforces the cpus and ram
+func (w *BareMetalInstanceWebhook) checkQuotas(r *baremetalv1alpha1.BareMetalInstance) error {
+	ctx := context.Background()
+
+	bmqList := &baremetalv1alpha1.BareMetalQuotaList{}
+	err := w.client.List(ctx, bmqList, client.InNamespace(r.Namespace))
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	if len(bmqList.Items) == 0 {
 		return nil
 	}
 
-	return apierrors.NewInvalid(
-		schema.GroupKind{Group: baremetalv1alpha1.GroupVersion.Group, Kind: r.Kind},
-		r.Name, allErrs)
+	bmiList := &baremetalv1alpha1.BareMetalInstanceList{}
+	err = w.client.List(ctx, bmiList, client.InNamespace(r.Namespace))
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	bmhList := &baremetalv1alpha1.BareMetalHardwareList{}
+	err = w.client.List(ctx, bmhList, client.InNamespace(r.Namespace))
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	cbmhList := &baremetalv1alpha1.ClusterBareMetalHardwareList{}
+	err = w.client.List(ctx, cbmhList)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	instances := make([]*baremetalv1alpha1.BareMetalInstance, 0, len(bmiList.Items))
+	for i := range bmiList.Items {
+		instances = append(instances, &bmiList.Items[i])
+	}
+
+	hardware := make(map[string]*baremetalv1alpha1.BareMetalHardware, len(bmhList.Items))
+	for i := range bmhList.Items {
+		hardware[bmhList.Items[i].Name] = &bmhList.Items[i]
+	}
+
+	clusterHardware := make(map[string]*baremetalv1alpha1.BareMetalHardware, len(cbmhList.Items))
+	for i := range cbmhList.Items {
+		clusterHardware[cbmhList.Items[i].Name] = cbmhList.Items[i].AsBareMetalHardware()
+	}
+
+	usage := quota.Calculate(instances, func(bmi *baremetalv1alpha1.BareMetalInstance) *baremetalv1alpha1.BareMetalHardware {
+		if bmi.Status.ClusterHardware {
+			return clusterHardware[bmi.Status.HardwareName]
+		}
+		return hardware[bmi.Status.HardwareName]
+	})
+
+	// pending instances will use at least what they requested once they are scheduled
+	for _, bmi := range instances {
+		if len(bmi.Status.HardwareName) == 0 && bmi.DeletionTimestamp.IsZero() {
+			usage = usage.Add(quota.Requested(bmi))
+		}
+	}
+
+	usage = usage.Add(quota.Requested(r)).Add(quota.Usage{Instances: 1})
+
+	for _, bmq := range bmqList.Items {
+		exceeded := quota.Exceeded(bmq.Spec.Hard, usage)
+		if len(exceeded) == 0 {
+			continue
+		}
+
+		return apierrors.NewForbidden(
+			schema.GroupResource{Group: baremetalv1alpha1.GroupVersion.Group, Resource: "baremetalinstances"},
+			r.Name, fmt.Errorf("exceeded quota %s, limited: %s", bmq.Name, strings.Join(exceeded, ",")))
+	}
+
+	return nil
+}
+
+func validateResources(resources *baremetalv1alpha1.BareMetalInstanceResources, startPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if resources == nil {
+		return allErrs
+	}
+
+	quantities := map[string]*resource.Quantity{
+		"cpus":           resources.CPUS,
+		"ram":            resources.Ram,
+		"imageDriveSize": resources.ImageDriveSize,
+		"nicSpeed":       resources.NICSpeed,
+	}
+	for _, name := range []string{"cpus", "ram", "imageDriveSize", "nicSpeed"} {
+		quantity := quantities[name]
+		if quantity != nil && quantity.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(startPath.Child(name), quantity.String(), "must be greater than or equal to 0"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateAffinity(affinity *baremetalv1alpha1.Affinity, startPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if affinity == nil {
+		return allErrs
+	}
+
+	if affinity.HardwareAffinity != nil {
+		hardwareAffinityPath := startPath.Child("hardwareAffinity")
+
+		if required := affinity.HardwareAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			requiredPath := hardwareAffinityPath.Child("requiredDuringSchedulingIgnoredDuringExecution").Child("hardwareSelectorTerms")
+			if len(required.HardwareSelectorTerms) == 0 {
+				allErrs = append(allErrs, field.Required(requiredPath, "must have at least one hardware selector term"))
+			}
+			for i, term := range required.HardwareSelectorTerms {
+				allErrs = append(allErrs, validateHardwareSelectorTerm(term, requiredPath.Index(i))...)
+			}
+		}
+
+		for i, term := range affinity.HardwareAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			termPath := hardwareAffinityPath.Child("preferredDuringSchedulingIgnoredDuringExecution").Index(i)
+			if term.Weight < 1 || term.Weight > 100 {
+				allErrs = append(allErrs, field.Invalid(termPath.Child("weight"), term.Weight, "must be between 1 and 100"))
+			}
+			allErrs = append(allErrs, validateHardwareSelectorTerm(term.Preference, termPath.Child("preference"))...)
+		}
+	}
+
+	if affinity.InstanceAffinity != nil {
+		allErrs = append(allErrs, validateAffinityTerms(
+			affinity.InstanceAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+			affinity.InstanceAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			startPath.Child("instanceAffinity"))...)
+	}
+
+	if affinity.InstanceAntiAffinity != nil {
+		allErrs = append(allErrs, validateAffinityTerms(
+			affinity.InstanceAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+			affinity.InstanceAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			startPath.Child("instanceAntiAffinity"))...)
+	}
+
+	return allErrs
+}
+
+func validateHardwareSelectorTerm(term baremetalv1alpha1.HardwareSelectorTerm, startPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, expression := range term.MatchExpressions {
+		singleTerm := baremetalv1alpha1.HardwareSelectorTerm{MatchExpressions: []baremetalv1alpha1.HardwareSelectorRequirement{expression}}
+		if _, err := singleTerm.Selector(); err != nil {
+			allErrs = append(allErrs, field.Invalid(startPath.Child("matchExpressions").Index(i), expression, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+func validateAffinityTerms(required []baremetalv1alpha1.InstanceAffinityTerm, preferred []baremetalv1alpha1.WeightedInstanceAffinityTerm, startPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, term := range required {
+		allErrs = append(allErrs, validateAffinityTerm(term, startPath.Child("requiredDuringSchedulingIgnoredDuringExecution").Index(i))...)
+	}
+
+	for i, term := range preferred {
+		termPath := startPath.Child("preferredDuringSchedulingIgnoredDuringExecution").Index(i)
+		if term.Weight < 1 || term.Weight > 100 {
+			allErrs = append(allErrs, field.Invalid(termPath.Child("weight"), term.Weight, "must be between 1 and 100"))
+		}
+		allErrs = append(allErrs, validateAffinityTerm(term.InstanceAffinityTerm, termPath.Child("instanceAffinityTerm"))...)
+	}
+
+	return allErrs
+}
+
+func validateAffinityTerm(term baremetalv1alpha1.InstanceAffinityTerm, startPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	allErrs = append(allErrs, validateTopologyKey(term.TopologyKey, startPath.Child("topologyKey"))...)
+	allErrs = append(allErrs, validateLabelSelector(term.LabelSelector, startPath.Child("labelSelector"))...)
+
+	return allErrs
+}
+
+func validateTopologySpreadConstraints(constraints []baremetalv1alpha1.TopologySpreadConstraint, startPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	type constraintKey struct {
+		topologyKey       string
+		whenUnsatisfiable baremetalv1alpha1.UnsatisfiableConstraintAction
+	}
+	seen := make(map[constraintKey]bool)
+
+	for i, constraint := range constraints {
+		constraintPath := startPath.Index(i)
+
+		if constraint.MaxSkew < 1 {
+			allErrs = append(allErrs, field.Invalid(constraintPath.Child("maxSkew"), constraint.MaxSkew, "must be greater than 0"))
+		}
+
+		if constraint.WhenUnsatisfiable != baremetalv1alpha1.DoNotSchedule && constraint.WhenUnsatisfiable != baremetalv1alpha1.ScheduleAnyway {
+			allErrs = append(allErrs, field.NotSupported(constraintPath.Child("whenUnsatisfiable"), constraint.WhenUnsatisfiable,
+				[]string{string(baremetalv1alpha1.DoNotSchedule), string(baremetalv1alpha1.ScheduleAnyway)}))
+		}
+
+		allErrs = append(allErrs, validateTopologyKey(constraint.TopologyKey, constraintPath.Child("topologyKey"))...)
+		allErrs = append(allErrs, validateLabelSelector(constraint.LabelSelector, constraintPath.Child("labelSelector"))...)
+
+		key := constraintKey{topologyKey: constraint.TopologyKey, whenUnsatisfiable: constraint.WhenUnsatisfiable}
+		if seen[key] {
+			allErrs = append(allErrs, field.Duplicate(constraintPath, fmt.Sprintf("{%s, %s}", constraint.TopologyKey, constraint.WhenUnsatisfiable)))
+		}
+		seen[key] = true
+	}
+
+	return allErrs
+}
+
+func validateGang(gang *baremetalv1alpha1.InstanceGang, startPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if gang == nil {
+		return allErrs
+	}
+
+	if len(gang.Name) == 0 {
+		allErrs = append(allErrs, field.Required(startPath.Child("name"), "name is required"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(gang.Name) {
+			allErrs = append(allErrs, field.Invalid(startPath.Child("name"), gang.Name, msg))
+		}
+	}
+
+	if gang.MinMembers < 1 {
+		allErrs = append(allErrs, field.Invalid(startPath.Child("minMembers"), gang.MinMembers, "must be greater than 0"))
+	}
+
+	if gang.ScheduleTimeoutSeconds != nil && *gang.ScheduleTimeoutSeconds < 1 {
+		allErrs = append(allErrs, field.Invalid(startPath.Child("scheduleTimeoutSeconds"), *gang.ScheduleTimeoutSeconds, "must be greater than 0"))
+	}
+
+	return allErrs
+}
+
+func validateTopologyKey(topologyKey string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(topologyKey) == 0 {
+		return append(allErrs, field.Required(fldPath, "topologyKey is required"))
+	}
+
+	for _, msg := range validation.IsQualifiedName(topologyKey) {
+		allErrs = append(allErrs, field.Invalid(fldPath, topologyKey, msg))
+	}
+
+	return allErrs
+}
+
+func validateLabelSelector(selector *metav1.LabelSelector, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if selector == nil {
+		return allErrs
+	}
+
+	if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, selector, err.Error()))
+	}
+
+	return allErrs
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -147,6 +442,47 @@ func (w *BareMetalInstanceWebhook) ValidateUpdate(obj runtime.Object, old runtim
 		))
 	}
 
+	// never allow changing the resources
+	// quantities need a semantic comparison, i.e. 1Gi and 1024Mi are equal
+	if apiequality.Semantic.DeepEqual(r.Spec.Resources, oldBMI.Spec.Resources) == false {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("spec").Child("resources"),
+			"Cannot change the resources",
+		))
+	}
+
+	// never allow changing the affinity
+	if reflect.DeepEqual(r.Spec.Affinity, oldBMI.Spec.Affinity) == false {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("spec").Child("affinity"),
+			"Cannot change the affinity",
+		))
+	}
+
+	// never allow changing the topology spread constraints
+	if reflect.DeepEqual(r.Spec.TopologySpreadConstraints, oldBMI.Spec.TopologySpreadConstraints) == false {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("spec").Child("topologySpreadConstraints"),
+			"Cannot change the topology spread constraints",
+		))
+	}
+
+	// never allow changing the gang
+	if reflect.DeepEqual(r.Spec.Gang, oldBMI.Spec.Gang) == false {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("spec").Child("gang"),
+			"Cannot change the gang",
+		))
+	}
+
+	// never allow changing the priority, the scheduling queue is ordered by it
+	if reflect.DeepEqual(r.Spec.Priority, oldBMI.Spec.Priority) == false {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("spec").Child("priority"),
+			"Cannot change the priority",
+		))
+	}
+
 	if r.Status.AgentInfo != nil {
 		if r.Status.Phase != baremetalv1alpha1.BareMetalInstanceStatusPhaseProvisioning &&
 			r.Status.Phase != baremetalv1alpha1.BareMetalInstanceStatusPhaseCleaning {
@@ -171,6 +507,14 @@ func (w *BareMetalInstanceWebhook) ValidateUpdate(obj runtime.Object, old runtim
 				"Cannot change the scheduled hardware name",
 			))
 		}
+
+		// Don't allow changing the kind of the scheduled hardware if set
+		if len(oldBMI.Status.HardwareName) > 0 && r.Status.ClusterHardware != oldBMI.Status.ClusterHardware {
+			allErrs = append(allErrs, field.Forbidden(
+				field.NewPath("status").Child("clusterHardware"),
+				"Cannot change the cluster hardware",
+			))
+		}
 	}
 
 	if len(allErrs) == 0 {
@@ -16,13 +16,17 @@ limitations under the License.
 package webhooks
 
 import (
-	"bytes"
+	"context"
+	"fmt"
 	"net"
 	"reflect"
+	"sort"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -30,6 +34,7 @@ import (
 
 	baremetalapi "github.com/rmb938/kube-baremetal/api"
 	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/pkg/ipam"
 	"github.com/rmb938/kube-baremetal/webhook"
 	"github.com/rmb938/kube-baremetal/webhook/admission"
 )
@@ -63,16 +68,18 @@ func (w *BareMetalNetworkWebhook) Default(obj runtime.Object) {
 			r.Finalizers = append(r.Finalizers, baremetalv1alpha1.BareMetalNetworkFinalizer)
 		}
 	}
+
+	// move the deprecated start and end into the ranges
+	if len(r.Spec.Start) > 0 && len(r.Spec.End) > 0 {
+		r.Spec.Ranges = r.Spec.AllRanges()
+		r.Spec.Start = ""
+		r.Spec.End = ""
+	}
 }
 
 var _ webhook.Validator = &BareMetalNetworkWebhook{}
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (w *BareMetalNetworkWebhook) ValidateCreate(obj runtime.Object) error {
-	r := obj.(*baremetalv1alpha1.BareMetalNetwork)
-
-	baremetalnetworklog.Info("validate create", "name", r.Name)
-
+func (w *BareMetalNetworkWebhook) validateSpec(r *baremetalv1alpha1.BareMetalNetwork) field.ErrorList {
 	var allErrs field.ErrorList
 
 	// validate network
@@ -85,46 +92,78 @@ func (w *BareMetalNetworkWebhook) ValidateCreate(obj runtime.Object) error {
 		networkIP = cidrIP.Mask(network.Mask)
 	}
 
-	// validate start
-	start := net.ParseIP(r.Spec.Start)
-	if start == nil {
-		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("start"), r.Spec.Start, "invalid start address"))
-	} else {
-		if network != nil {
-			if network.Contains(start) == false {
-				allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("start"), r.Spec.Start, "start address is not in cidr"))
-			}
-		}
+	// validate ranges
+	if len(r.Spec.Start) > 0 || len(r.Spec.End) > 0 {
+		// both are moved into the ranges when they are set
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("start"), r.Spec.Start, "start and end are deprecated and must be set together, use ranges instead"))
+	} else if len(r.Spec.Ranges) == 0 {
+		allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("ranges"), "at least one range is required"))
 	}
+	for i, specRange := range r.Spec.Ranges {
+		allErrs = append(allErrs, validateRange(specRange.Start, specRange.End, network, field.NewPath("spec").Child("ranges").Index(i))...)
 
-	// validate end
-	end := net.ParseIP(r.Spec.End)
-	if end == nil {
-		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("start"), r.Spec.End, "invalid end address"))
-	} else {
-		if network != nil {
-			if network.Contains(end) == false {
-				allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("end"), r.Spec.End, "end address is not in cidr"))
+		start := net.ParseIP(specRange.Start)
+		end := net.ParseIP(specRange.End)
+		if start == nil || end == nil {
+			continue
+		}
+
+		// the allocation bitmap is stored in the status so ranges can't be too big
+		if _, err := ipam.RangeSize(start, end); err == ipam.ErrRangeTooBig {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("ranges").Index(i).Child("end"), specRange.End, err.Error()))
+		}
+
+		for j := 0; j < i; j++ {
+			otherStart := net.ParseIP(r.Spec.Ranges[j].Start)
+			otherEnd := net.ParseIP(r.Spec.Ranges[j].End)
+			if otherStart == nil || otherEnd == nil {
+				continue
+			}
+
+			if ipam.Overlaps(start, end, otherStart, otherEnd) {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("ranges").Index(i), specRange, fmt.Sprintf("range overlaps with range %d", j)))
 			}
 		}
 	}
 
-	// validate ordering
-	if start != nil && end != nil {
-		if bytes.Compare(start, end) >= 0 {
-			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("start"), r.Spec.Start, "start address must be greater then end address"))
+	// validate exclusions
+	for i, exclusion := range r.Spec.Exclusions {
+		end := exclusion.End
+		if len(end) == 0 {
+			end = exclusion.Start
+		}
+
+		allErrs = append(allErrs, validateRange(exclusion.Start, end, network, field.NewPath("spec").Child("exclusions").Index(i))...)
+
+		start := net.ParseIP(exclusion.Start)
+		endIP := net.ParseIP(end)
+		if start == nil || endIP == nil {
+			continue
+		}
+
+		for j := 0; j < i; j++ {
+			otherStart := net.ParseIP(r.Spec.Exclusions[j].Start)
+			otherEnd := otherStart
+			if len(r.Spec.Exclusions[j].End) > 0 {
+				otherEnd = net.ParseIP(r.Spec.Exclusions[j].End)
+			}
+			if otherStart == nil || otherEnd == nil {
+				continue
+			}
+
+			if ipam.Overlaps(start, endIP, otherStart, otherEnd) {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("exclusions").Index(i), exclusion, fmt.Sprintf("exclusion overlaps with exclusion %d", j)))
+			}
 		}
 	}
 
 	// validate gateway
 	gateway := net.ParseIP(r.Spec.Gateway)
 	if gateway == nil {
-		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("gateway"), r.Spec.End, "invalid gateway address"))
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("gateway"), r.Spec.Gateway, "invalid gateway address"))
 	} else {
-		if network != nil {
-			if network.Contains(gateway) == false {
-				allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("gateway"), r.Spec.End, "gateway address is not in cidr"))
-			}
+		if network != nil && validGateway(gateway, network) == false {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("gateway"), r.Spec.Gateway, "gateway address is not in cidr"))
 		}
 	}
 
@@ -156,6 +195,33 @@ func (w *BareMetalNetworkWebhook) ValidateCreate(obj runtime.Object) error {
 		}
 	}
 
+	// validate routes
+	allErrs = append(allErrs, validateRoutes(r.Spec.Routes, network, field.NewPath("spec").Child("routes"))...)
+
+	// validate mtu, ipv6 requires a larger minimum
+	if r.Spec.MTU > 0 && networkIP != nil && networkIP.To4() == nil && r.Spec.MTU < 1280 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("mtu"), r.Spec.MTU, "mtu must be at least 1280 for ipv6 networks"))
+	}
+
+	// validate dns
+	if r.Spec.DNS != nil {
+		domain := strings.TrimSuffix(r.Spec.DNS.Domain, ".")
+		for _, msg := range validation.IsDNS1123Subdomain(domain) {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("dns").Child("domain"), r.Spec.DNS.Domain, msg))
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (w *BareMetalNetworkWebhook) ValidateCreate(obj runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.BareMetalNetwork)
+
+	baremetalnetworklog.Info("validate create", "name", r.Name)
+
+	allErrs := w.validateSpec(r)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -165,6 +231,79 @@ func (w *BareMetalNetworkWebhook) ValidateCreate(obj runtime.Object) error {
 		r.Name, allErrs)
 }
 
+func validateRange(startAddress, endAddress string, network *net.IPNet, startPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// validate start
+	start := net.ParseIP(startAddress)
+	if start == nil {
+		allErrs = append(allErrs, field.Invalid(startPath.Child("start"), startAddress, "invalid start address"))
+	} else if network != nil && network.Contains(start) == false {
+		allErrs = append(allErrs, field.Invalid(startPath.Child("start"), startAddress, "start address is not in cidr"))
+	}
+
+	// validate end
+	end := net.ParseIP(endAddress)
+	if end == nil {
+		allErrs = append(allErrs, field.Invalid(startPath.Child("end"), endAddress, "invalid end address"))
+	} else if network != nil && network.Contains(end) == false {
+		allErrs = append(allErrs, field.Invalid(startPath.Child("end"), endAddress, "end address is not in cidr"))
+	}
+
+	// validate ordering
+	if start != nil && end != nil {
+		if (start.To4() == nil) != (end.To4() == nil) {
+			allErrs = append(allErrs, field.Invalid(startPath.Child("end"), endAddress, "end ip version is different then start ip version"))
+		} else if ipam.Compare(start, end) > 0 {
+			allErrs = append(allErrs, field.Invalid(startPath.Child("start"), startAddress, "start address must be less then or equal to the end address"))
+		}
+	}
+
+	return allErrs
+}
+
+// validGateway returns if the gateway can be used by hosts on the network
+// ipv6 gateways are commonly the router's link-local address so those are allowed outside of the network
+func validGateway(gateway net.IP, network *net.IPNet) bool {
+	if network.Contains(gateway) {
+		return true
+	}
+
+	return gateway.To4() == nil && gateway.IsLinkLocalUnicast()
+}
+
+func validateRoutes(routes []baremetalv1alpha1.BareMetalNetworkRoute, network *net.IPNet, startPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, route := range routes {
+		_, destination, err := net.ParseCIDR(route.Destination)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(startPath.Index(i).Child("destination"), route.Destination, "invalid destination cidr"))
+		} else if network != nil && (destination.IP.To4() == nil) != (network.IP.To4() == nil) {
+			allErrs = append(allErrs, field.Invalid(startPath.Index(i).Child("destination"), route.Destination, "destination ip version is different then cidr ip version"))
+		}
+
+		gateway := net.ParseIP(route.Gateway)
+		if gateway == nil {
+			allErrs = append(allErrs, field.Invalid(startPath.Index(i).Child("gateway"), route.Gateway, "invalid gateway address"))
+		} else if network != nil && validGateway(gateway, network) == false {
+			allErrs = append(allErrs, field.Invalid(startPath.Index(i).Child("gateway"), route.Gateway, "gateway address is not in cidr"))
+		}
+
+		for j, r := range routes {
+			if i == j {
+				continue
+			}
+
+			if route.Destination == r.Destination && route.Metric == r.Metric {
+				allErrs = append(allErrs, field.Duplicate(startPath.Index(i), route.Destination))
+			}
+		}
+	}
+
+	return allErrs
+}
+
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (w *BareMetalNetworkWebhook) ValidateUpdate(obj runtime.Object, old runtime.Object) error {
 	r := obj.(*baremetalv1alpha1.BareMetalNetwork)
@@ -174,13 +313,32 @@ func (w *BareMetalNetworkWebhook) ValidateUpdate(obj runtime.Object, old runtime
 
 	var allErrs field.ErrorList
 
-	if reflect.DeepEqual(r.Spec, oldBMN.Spec) == false {
+	// never allow changing the cidr
+	if r.Spec.CIDR != oldBMN.Spec.CIDR {
 		allErrs = append(allErrs, field.Forbidden(
-			field.NewPath("spec"),
-			"Cannot change the spec",
+			field.NewPath("spec").Child("cidr"),
+			"Cannot change the cidr",
 		))
 	}
 
+	// never allow changing the gateway
+	if r.Spec.Gateway != oldBMN.Spec.Gateway {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("spec").Child("gateway"),
+			"Cannot change the gateway",
+		))
+	}
+
+	if reflect.DeepEqual(r.Spec, oldBMN.Spec) == false {
+		specErrs := w.validateSpec(r)
+		allErrs = append(allErrs, specErrs...)
+
+		// only check for orphans when the new spec is valid otherwise the allocator can't be created
+		if len(specErrs) == 0 {
+			allErrs = append(allErrs, w.validateOrphans(r, oldBMN)...)
+		}
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -190,6 +348,68 @@ func (w *BareMetalNetworkWebhook) ValidateUpdate(obj runtime.Object, old runtime
 		r.Name, allErrs)
 }
 
+// make sure every allocated address is still allocatable with the new spec
+func (w *BareMetalNetworkWebhook) validateOrphans(r *baremetalv1alpha1.BareMetalNetwork, oldBMN *baremetalv1alpha1.BareMetalNetwork) field.ErrorList {
+	ctx := context.Background()
+	var allErrs field.ErrorList
+
+	allocated := make(map[string]net.IP)
+
+	oldAlloc, err := ipam.NewAllocator(oldBMN)
+	if err != nil {
+		return append(allErrs, field.InternalError(field.NewPath("spec"), err))
+	}
+	oldAlloc.ForEach(func(ip net.IP) {
+		// quarantined addresses are not used by anything so they can be removed
+		if oldAlloc.Quarantined(ip) {
+			return
+		}
+		allocated[ip.String()] = ip
+	})
+
+	// endpoints may have addresses that are not in the pools yet
+	bmeList := &baremetalv1alpha1.BareMetalEndpointList{}
+	err = w.client.List(ctx, bmeList, client.InNamespace(r.Namespace), client.MatchingFields{"spec.networkRef.group,kind,name": baremetalv1alpha1.GroupVersion.Group + ".BareMetalNetwork." + r.Name})
+	if err != nil {
+		return append(allErrs, field.InternalError(field.NewPath("spec"), err))
+	}
+	for _, bme := range bmeList.Items {
+		if bme.Status.Address == nil {
+			continue
+		}
+
+		ip := net.ParseIP(bme.Status.Address.IP)
+		if ip != nil {
+			allocated[ip.String()] = ip
+		}
+	}
+
+	newAlloc, err := ipam.NewAllocator(&baremetalv1alpha1.BareMetalNetwork{Spec: r.Spec})
+	if err != nil {
+		return append(allErrs, field.InternalError(field.NewPath("spec"), err))
+	}
+
+	var orphans []string
+	for key, ip := range allocated {
+		if newAlloc.Contains(ip) == false || newAlloc.Excluded(ip) {
+			orphans = append(orphans, key)
+		}
+	}
+
+	if len(orphans) > 0 {
+		sort.Strings(orphans)
+
+		message := fmt.Sprintf("Cannot remove or exclude allocated addresses %s", strings.Join(orphans, ", "))
+		if len(orphans) > 10 {
+			message = fmt.Sprintf("Cannot remove or exclude allocated addresses %s and %d more", strings.Join(orphans[:10], ", "), len(orphans)-10)
+		}
+
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), message))
+	}
+
+	return allErrs
+}
+
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
 func (w *BareMetalNetworkWebhook) ValidateDelete(obj runtime.Object) error {
 	r := obj.(*baremetalv1alpha1.BareMetalNetwork)
@@ -0,0 +1,129 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/webhook"
+	"github.com/rmb938/kube-baremetal/webhook/admission"
+)
+
+// log is for logging in this package.
+var clusterbaremetalhardwarelog = logf.Log.WithName("clusterbaremetalhardware-resource")
+
+type ClusterBareMetalHardwareWebhook struct {
+	client client.Client
+}
+
+func (w *ClusterBareMetalHardwareWebhook) SetupWebhookWithManager(mgr ctrl.Manager) {
+	w.client = mgr.GetClient()
+	hookServer := mgr.GetWebhookServer()
+
+	hookServer.Register("/mutate-baremetal-com-rmb938-v1alpha1-clusterbaremetalhardware", admission.DefaultingWebhookFor(w, &baremetalv1alpha1.ClusterBareMetalHardware{}))
+	hookServer.Register("/validate-baremetal-com-rmb938-v1alpha1-clusterbaremetalhardware", admission.ValidatingWebhookFor(w, &baremetalv1alpha1.ClusterBareMetalHardware{}))
+}
+
+var _ webhook.Defaulter = &ClusterBareMetalHardwareWebhook{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (w *ClusterBareMetalHardwareWebhook) Default(obj runtime.Object) {
+	r := obj.(*baremetalv1alpha1.ClusterBareMetalHardware)
+
+	clusterbaremetalhardwarelog.Info("default", "name", r.Name)
+
+	defaultHardware(r)
+}
+
+var _ webhook.Validator = &ClusterBareMetalHardwareWebhook{}
+
+func validateNamespaces(namespaces []string) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, namespace := range namespaces {
+		namespacePath := field.NewPath("spec").Child("namespaces").Index(i)
+
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			allErrs = append(allErrs, field.Invalid(namespacePath, namespace, strings.Join(errs, ", ")))
+		}
+
+		for j := 0; j < i; j++ {
+			if namespaces[j] == namespace {
+				allErrs = append(allErrs, field.Duplicate(namespacePath, namespace))
+				break
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (w *ClusterBareMetalHardwareWebhook) ValidateCreate(obj runtime.Object) error {
+	ctx := context.Background()
+	r := obj.(*baremetalv1alpha1.ClusterBareMetalHardware)
+
+	clusterbaremetalhardwarelog.Info("validate create", "name", r.Name)
+
+	allErrs := validateHardwareCreate(ctx, w.client, r)
+	allErrs = append(allErrs, validateNamespaces(r.Spec.Namespaces)...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: baremetalv1alpha1.GroupVersion.Group, Kind: r.Kind},
+		r.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (w *ClusterBareMetalHardwareWebhook) ValidateUpdate(obj runtime.Object, old runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.ClusterBareMetalHardware)
+
+	clusterbaremetalhardwarelog.Info("validate update", "name", r.Name)
+	oldCBMH := old.(*baremetalv1alpha1.ClusterBareMetalHardware)
+
+	allErrs := validateHardwareUpdate(r, oldCBMH)
+	allErrs = append(allErrs, validateNamespaces(r.Spec.Namespaces)...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: baremetalv1alpha1.GroupVersion.Group, Kind: r.Kind},
+		r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (w *ClusterBareMetalHardwareWebhook) ValidateDelete(obj runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.ClusterBareMetalHardware)
+	clusterbaremetalhardwarelog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/webhook"
+	"github.com/rmb938/kube-baremetal/webhook/admission"
+)
+
+// log is for logging in this package.
+var dhcpnetworklog = logf.Log.WithName("dhcpnetwork-resource")
+
+type DHCPNetworkWebhook struct {
+	client client.Client
+}
+
+func (w *DHCPNetworkWebhook) SetupWebhookWithManager(mgr ctrl.Manager) {
+	w.client = mgr.GetClient()
+	hookServer := mgr.GetWebhookServer()
+
+	hookServer.Register("/mutate-baremetal-com-rmb938-v1alpha1-dhcpnetwork", admission.DefaultingWebhookFor(w, &baremetalv1alpha1.DHCPNetwork{}))
+	hookServer.Register("/validate-baremetal-com-rmb938-v1alpha1-dhcpnetwork", admission.ValidatingWebhookFor(w, &baremetalv1alpha1.DHCPNetwork{}))
+}
+
+var _ webhook.Defaulter = &DHCPNetworkWebhook{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (w *DHCPNetworkWebhook) Default(obj runtime.Object) {
+	r := obj.(*baremetalv1alpha1.DHCPNetwork)
+
+	dhcpnetworklog.Info("default", "name", r.Name)
+
+	// set the default ip family
+	if len(r.Spec.IPFamily) == 0 {
+		r.Spec.IPFamily = baremetalv1alpha1.IPFamilyIPv4
+	}
+}
+
+var _ webhook.Validator = &DHCPNetworkWebhook{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (w *DHCPNetworkWebhook) ValidateCreate(obj runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.DHCPNetwork)
+
+	dhcpnetworklog.Info("validate create", "name", r.Name)
+
+	// the crd schema validates everything
+	return nil
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (w *DHCPNetworkWebhook) ValidateUpdate(obj runtime.Object, old runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.DHCPNetwork)
+	oldDHCPN := old.(*baremetalv1alpha1.DHCPNetwork)
+
+	dhcpnetworklog.Info("validate update", "name", r.Name)
+
+	var allErrs field.ErrorList
+
+	// endpoints already have the ip family in their address so never allow changing it
+	if r.Spec.IPFamily != oldDHCPN.Spec.IPFamily {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("spec").Child("ipFamily"),
+			"Cannot change the ipFamily",
+		))
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: baremetalv1alpha1.GroupVersion.Group, Kind: r.Kind},
+		r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (w *DHCPNetworkWebhook) ValidateDelete(obj runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.DHCPNetwork)
+
+	dhcpnetworklog.Info("validate delete", "name", r.Name)
+
+	// TODO(user): fill in your validation logic upon object deletion.
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"net/url"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	baremetalv1alpha1 "github.com/rmb938/kube-baremetal/api/v1alpha1"
+	"github.com/rmb938/kube-baremetal/webhook"
+	"github.com/rmb938/kube-baremetal/webhook/admission"
+)
+
+// log is for logging in this package.
+var httpipamnetworklog = logf.Log.WithName("httpipamnetwork-resource")
+
+type HTTPIPAMNetworkWebhook struct {
+	client client.Client
+}
+
+func (w *HTTPIPAMNetworkWebhook) SetupWebhookWithManager(mgr ctrl.Manager) {
+	w.client = mgr.GetClient()
+	hookServer := mgr.GetWebhookServer()
+
+	hookServer.Register("/mutate-baremetal-com-rmb938-v1alpha1-httpipamnetwork", admission.DefaultingWebhookFor(w, &baremetalv1alpha1.HTTPIPAMNetwork{}))
+	hookServer.Register("/validate-baremetal-com-rmb938-v1alpha1-httpipamnetwork", admission.ValidatingWebhookFor(w, &baremetalv1alpha1.HTTPIPAMNetwork{}))
+}
+
+var _ webhook.Defaulter = &HTTPIPAMNetworkWebhook{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (w *HTTPIPAMNetworkWebhook) Default(obj runtime.Object) {
+	r := obj.(*baremetalv1alpha1.HTTPIPAMNetwork)
+
+	httpipamnetworklog.Info("default", "name", r.Name)
+
+	// set the default timeout
+	if r.Spec.TimeoutSeconds == 0 {
+		r.Spec.TimeoutSeconds = 30
+	}
+}
+
+var _ webhook.Validator = &HTTPIPAMNetworkWebhook{}
+
+func (w *HTTPIPAMNetworkWebhook) validateSpec(r *baremetalv1alpha1.HTTPIPAMNetwork) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// validate url
+	u, err := url.Parse(r.Spec.URL)
+	if err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("url"), r.Spec.URL, err.Error()))
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("url"), r.Spec.URL, "url scheme must be http or https"))
+	} else if len(u.Host) == 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("url"), r.Spec.URL, "url must have a host"))
+	}
+
+	// validate token secret
+	if r.Spec.TokenSecretRef != nil && len(r.Spec.TokenSecretRef.Name) == 0 {
+		allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("tokenSecretRef").Child("name"), "token secret name must be set"))
+	}
+
+	return allErrs
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (w *HTTPIPAMNetworkWebhook) ValidateCreate(obj runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.HTTPIPAMNetwork)
+
+	httpipamnetworklog.Info("validate create", "name", r.Name)
+
+	allErrs := w.validateSpec(r)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: baremetalv1alpha1.GroupVersion.Group, Kind: r.Kind},
+		r.Name, allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (w *HTTPIPAMNetworkWebhook) ValidateUpdate(obj runtime.Object, old runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.HTTPIPAMNetwork)
+
+	httpipamnetworklog.Info("validate update", "name", r.Name)
+
+	// all allocation state is in the external ipam so the spec can be changed
+	allErrs := w.validateSpec(r)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: baremetalv1alpha1.GroupVersion.Group, Kind: r.Kind},
+		r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (w *HTTPIPAMNetworkWebhook) ValidateDelete(obj runtime.Object) error {
+	r := obj.(*baremetalv1alpha1.HTTPIPAMNetwork)
+
+	httpipamnetworklog.Info("validate delete", "name", r.Name)
+
+	// TODO(user): fill in your validation logic upon object deletion.
+	return nil
+}